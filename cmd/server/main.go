@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	"github.com/ole-larsen/binance-subscriber/internal/server"
 	"github.com/ole-larsen/binance-subscriber/internal/server/config"
@@ -9,9 +11,21 @@ import (
 
 // main runs the build information and prints it to the provided writer.
 func main() {
-	ctx, cancel := context.WithCancel(context.Background())
+	settings, err := config.GetConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if config.PrintRequested() {
+		if err = settings.Dump(os.Stdout); err != nil {
+			panic(err)
+		}
 
-	settings := config.GetConfig()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 
 	srv, err := server.SetupFunc(settings)
 	if err != nil {
@@ -0,0 +1,430 @@
+// Package alert evaluates rules over stored quotes and notifies about them by webhooks or logs.
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// Alert statuses.
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+const (
+	// DefaultCheckInterval is the interval of evaluating age conditions and pending rules without updates.
+	DefaultCheckInterval = time.Second
+	// DefaultMaxRules limits the number of rules, every rule is evaluated on every stored quote.
+	DefaultMaxRules = 100
+)
+
+var (
+	ErrNotFound     = NewError(errors.New("rule not found"))
+	ErrTooManyRules = NewError(errors.New("too many rules"))
+	ErrConfigured   = NewError(errors.New("rule is configured"))
+)
+
+// Alert is a firing or resolved rule.
+type Alert struct {
+	Time      time.Time `json:"time"`
+	Rule      string    `json:"rule"`
+	Symbol    string    `json:"symbol"`
+	Condition string    `json:"condition"`
+	Status    string    `json:"status"`
+	Webhook   string    `json:"-"`
+	Value     float64   `json:"value"`
+	// Submitted alerts of rules added by Engine.Submit are not signed and posted to public addresses only.
+	Submitted bool `json:"-"`
+}
+
+// Notifier delivers alerts, it must not block.
+type Notifier interface {
+	Notify(a Alert)
+}
+
+// Engine evaluates rules on every stored quote and on a timer for age conditions.
+type Engine struct {
+	rules        map[string]*rule
+	webhookHosts map[string]bool
+	notifier     Notifier
+	now          func() time.Time
+	maxRules     int
+	mx           sync.Mutex
+}
+
+// rule keeps evaluation state of every symbol.
+type rule struct {
+	Rule
+	cond      *condition
+	symbols   map[string]*state
+	submitted bool
+}
+
+type state struct {
+	since   time.Time // comparison holds since, zero if it does not
+	fired   time.Time
+	updated time.Time
+	value   float64
+	side    int
+	firing  bool
+}
+
+func NewEngine() *Engine {
+	return &Engine{
+		rules:    make(map[string]*rule),
+		now:      time.Now,
+		maxRules: DefaultMaxRules,
+	}
+}
+
+// SetNotifier sets receiver of alerts, nil discards them.
+func (e *Engine) SetNotifier(n Notifier) *Engine {
+	e.notifier = n
+	return e
+}
+
+// SetClock replaces time.Now.
+func (e *Engine) SetClock(now func() time.Time) *Engine {
+	e.now = now
+	return e
+}
+
+// SetMaxRules limits the number of rules, zero or negative removes the limit.
+func (e *Engine) SetMaxRules(n int) *Engine {
+	e.maxRules = n
+	return e
+}
+
+// SetWebhookHosts sets hosts webhooks of submitted rules may post to, empty allows none.
+func (e *Engine) SetWebhookHosts(hosts []string) *Engine {
+	e.webhookHosts = make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		e.webhookHosts[strings.ToLower(host)] = true
+	}
+
+	return e
+}
+
+// Set adds rule or replaces rule with the same id, state of replaced rule is reset.
+func (e *Engine) Set(r Rule) error {
+	return e.set(r, false)
+}
+
+// Submit adds rule of API client like Set. Its webhook host must be allowed by SetWebhookHosts,
+// alerts of the rule are not signed and posted to public addresses only. Rules added by Set
+// are not replaced, ErrConfigured is returned for them.
+func (e *Engine) Submit(r Rule) error {
+	if r.Webhook != "" {
+		u, err := url.Parse(r.Webhook)
+		if err == nil && !e.webhookHosts[strings.ToLower(u.Hostname())] {
+			return NewError(fmt.Errorf("rule %q: webhook host %q is not allowed", r.ID, u.Hostname()))
+		}
+	}
+
+	return e.set(r, true)
+}
+
+func (e *Engine) set(r Rule, submitted bool) error {
+	cond, err := r.parse()
+	if err != nil {
+		return NewError(err)
+	}
+
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	current, ok := e.rules[r.ID]
+	if ok && submitted && !current.submitted {
+		return ErrConfigured
+	}
+
+	if !ok && e.maxRules > 0 && len(e.rules) >= e.maxRules {
+		return ErrTooManyRules
+	}
+
+	e.rules[r.ID] = &rule{Rule: r, cond: cond, symbols: make(map[string]*state), submitted: submitted}
+
+	return nil
+}
+
+// Delete removes rule added by Submit, ErrNotFound is returned for unknown id and ErrConfigured
+// for rules added by Set.
+func (e *Engine) Delete(id string) error {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	r, ok := e.rules[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if !r.submitted {
+		return ErrConfigured
+	}
+
+	delete(e.rules, id)
+
+	return nil
+}
+
+// Get returns rule by id.
+func (e *Engine) Get(id string) (Rule, bool) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	r, ok := e.rules[id]
+	if !ok {
+		return Rule{}, false
+	}
+
+	return r.Rule, true
+}
+
+// Rules returns every rule sorted by id.
+func (e *Engine) Rules() []Rule {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	rules := make([]Rule, 0, len(e.rules))
+	for _, r := range e.rules {
+		rules = append(rules, r.Rule)
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+
+	return rules
+}
+
+// Observe evaluates rules of the stored quote.
+func (e *Engine) Observe(data *storage.Data) {
+	if e == nil {
+		return
+	}
+
+	bid, errBid := strconv.ParseFloat(data.Bid, 64)
+	ask, errAsk := strconv.ParseFloat(data.Ask, 64)
+
+	if errBid != nil || errAsk != nil {
+		return
+	}
+
+	e.mx.Lock()
+
+	now := e.now()
+
+	var alerts []Alert
+
+	for _, r := range e.rules {
+		if r.Symbol != "" && r.Symbol != data.Symbol {
+			continue
+		}
+
+		st := r.state(data.Symbol)
+		st.updated = now
+
+		if r.cond.metric == MetricAge {
+			if st.firing {
+				st.firing = false
+				alerts = append(alerts, *r.alert(data.Symbol, StatusResolved, 0, now))
+			}
+
+			continue
+		}
+
+		st.value = value(r.cond.metric, bid, ask)
+
+		if a := r.evaluate(data.Symbol, st, now); a != nil {
+			alerts = append(alerts, *a)
+		}
+	}
+
+	e.mx.Unlock()
+
+	e.notify(alerts)
+}
+
+// Check evaluates age conditions and comparisons pending for Rule.For or Cooldown.
+func (e *Engine) Check() {
+	if e == nil {
+		return
+	}
+
+	e.mx.Lock()
+
+	now := e.now()
+
+	var alerts []Alert
+
+	for _, r := range e.rules {
+		for symbol, st := range r.symbols {
+			if r.cond.metric == MetricAge {
+				st.value = now.Sub(st.updated).Seconds()
+				if !st.firing && r.cond.holds(st.value, 0) && now.Sub(st.fired) >= time.Duration(r.Cooldown) {
+					st.firing = true
+					st.fired = now
+					alerts = append(alerts, *r.alert(symbol, StatusFiring, st.value, now))
+				}
+
+				continue
+			}
+
+			if !st.since.IsZero() && !st.firing {
+				if a := r.evaluate(symbol, st, now); a != nil {
+					alerts = append(alerts, *a)
+				}
+			}
+		}
+	}
+
+	e.mx.Unlock()
+
+	e.notify(alerts)
+}
+
+// Run checks rules every interval until ctx is done.
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	if e == nil {
+		return
+	}
+
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.Check()
+		}
+	}
+}
+
+func (e *Engine) notify(alerts []Alert) {
+	for _, a := range alerts {
+		metrics.Alerts.WithLabelValues(a.Status).Inc()
+
+		if e.notifier != nil {
+			e.notifier.Notify(a)
+		}
+	}
+}
+
+func (r *rule) state(symbol string) *state {
+	st, ok := r.symbols[symbol]
+	if !ok {
+		st = &state{}
+		r.symbols[symbol] = st
+	}
+
+	return st
+}
+
+// evaluate applies the last value of symbol to the rule.
+func (r *rule) evaluate(symbol string, st *state, now time.Time) *Alert {
+	cooled := st.fired.IsZero() || now.Sub(st.fired) >= time.Duration(r.Cooldown)
+
+	if r.cond.crossing() {
+		side := r.cond.side(st.value, r.Hysteresis)
+		if side == 0 || side == st.side {
+			return nil
+		}
+
+		crossed := st.side != 0
+		st.side = side
+
+		if !crossed || !cooled ||
+			(r.cond.op == OpCrossesAbove && side < 0) || (r.cond.op == OpCrossesBelow && side > 0) {
+			return nil
+		}
+
+		st.fired = now
+
+		return r.alert(symbol, StatusFiring, st.value, now)
+	}
+
+	if st.firing {
+		if !r.cond.resolved(st.value, r.Hysteresis) {
+			return nil
+		}
+
+		st.firing = false
+		st.since = time.Time{}
+
+		return r.alert(symbol, StatusResolved, st.value, now)
+	}
+
+	if !r.cond.holds(st.value, 0) {
+		st.since = time.Time{}
+		return nil
+	}
+
+	if st.since.IsZero() {
+		st.since = now
+	}
+
+	if now.Sub(st.since) < time.Duration(r.For) || !cooled {
+		return nil
+	}
+
+	st.firing = true
+	st.fired = now
+
+	return r.alert(symbol, StatusFiring, st.value, now)
+}
+
+func (r *rule) alert(symbol, status string, v float64, now time.Time) *Alert {
+	return &Alert{
+		Time:      now,
+		Rule:      r.ID,
+		Symbol:    symbol,
+		Condition: r.Condition,
+		Status:    status,
+		Webhook:   r.Webhook,
+		Value:     v,
+		Submitted: r.submitted,
+	}
+}
+
+// value computes metric of the quote.
+func value(metric string, bid, ask float64) float64 {
+	const bps = 10000
+
+	mid := (bid + ask) / 2 //nolint:mnd // explanation: mean of bid and ask
+
+	switch metric {
+	case MetricBid:
+		return bid
+	case MetricAsk:
+		return ask
+	case MetricMid:
+		return mid
+	case MetricSpread:
+		return ask - bid
+	case MetricSpreadBps:
+		if mid == 0 {
+			return 0
+		}
+
+		return (ask - bid) / mid * bps
+	}
+
+	return 0
+}
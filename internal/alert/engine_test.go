@@ -0,0 +1,304 @@
+package alert_test
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ole-larsen/binance-subscriber/internal/alert"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+type recorder struct {
+	alerts []alert.Alert
+	mx     sync.Mutex
+}
+
+func (r *recorder) Notify(a alert.Alert) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.alerts = append(r.alerts, a)
+}
+
+// take returns statuses of recorded alerts and forgets them.
+func (r *recorder) take() []string {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	statuses := make([]string, 0, len(r.alerts))
+	for _, a := range r.alerts {
+		statuses = append(statuses, a.Status+" "+a.Symbol)
+	}
+
+	r.alerts = nil
+
+	return statuses
+}
+
+// rules returns sorted rules of recorded alerts and forgets them.
+func (r *recorder) rules() []string {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	rules := make([]string, 0, len(r.alerts))
+	for _, a := range r.alerts {
+		rules = append(rules, a.Rule)
+	}
+
+	r.alerts = nil
+
+	sort.Strings(rules)
+
+	return rules
+}
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newEngine(t *testing.T, rules ...alert.Rule) (*alert.Engine, *recorder, *clock) {
+	t.Helper()
+
+	rec := &recorder{}
+	clk := &clock{now: time.Unix(1700000000, 0)}
+	e := alert.NewEngine().SetNotifier(rec).SetClock(clk.Now)
+
+	for _, r := range rules {
+		require.NoError(t, e.Set(r))
+	}
+
+	return e, rec, clk
+}
+
+func quote(symbol, bid, ask string) *storage.Data {
+	return &storage.Data{Symbol: symbol, Bid: bid, Ask: ask}
+}
+
+func TestEngine_SpreadFor(t *testing.T) {
+	e, rec, clk := newEngine(t, alert.Rule{
+		ID:         "spread",
+		Symbol:     "BTCUSDT",
+		Condition:  "spread_bps > 5",
+		For:        alert.Duration(10 * time.Second),
+		Hysteresis: 1,
+	})
+
+	// 10 / 100000 = 1 bps
+	e.Observe(quote("BTCUSDT", "99995", "100005"))
+	assert.Empty(t, rec.take())
+
+	// ~6 bps must hold for 10s
+	e.Observe(quote("BTCUSDT", "99970", "100030"))
+	e.Observe(quote("ETHUSDT", "1", "2"))
+	clk.advance(5 * time.Second)
+	e.Check()
+	assert.Empty(t, rec.take())
+
+	clk.advance(5 * time.Second)
+	e.Check()
+	assert.Equal(t, []string{"firing BTCUSDT"}, rec.take())
+
+	// 4.6 bps is within hysteresis band, 2 bps resolves
+	e.Observe(quote("BTCUSDT", "99977", "100023"))
+	assert.Empty(t, rec.take())
+
+	e.Observe(quote("BTCUSDT", "99990", "100010"))
+	assert.Equal(t, []string{"resolved BTCUSDT"}, rec.take())
+
+	// interrupted condition starts over
+	e.Observe(quote("BTCUSDT", "99970", "100030"))
+	clk.advance(9 * time.Second)
+	e.Observe(quote("BTCUSDT", "99990", "100010"))
+	clk.advance(9 * time.Second)
+	e.Observe(quote("BTCUSDT", "99970", "100030"))
+	e.Check()
+	assert.Empty(t, rec.take())
+}
+
+func TestEngine_Crosses(t *testing.T) {
+	e, rec, clk := newEngine(t,
+		alert.Rule{ID: "cross", Symbol: "ETHUSDT", Condition: "bid crosses 3000", Hysteresis: 5},
+		alert.Rule{ID: "above", Condition: "bid crosses_above 3000", Cooldown: alert.Duration(time.Minute)},
+	)
+
+	// the first value sets the side
+	e.Observe(quote("ETHUSDT", "2990", "2991"))
+	assert.Empty(t, rec.take())
+
+	e.Observe(quote("ETHUSDT", "3010", "3011"))
+	assert.Equal(t, []string{"above", "cross"}, rec.rules())
+
+	// "cross" ignores moves within 3000 +/- 5, "above" is cooling down
+	e.Observe(quote("ETHUSDT", "2998", "2999"))
+	e.Observe(quote("ETHUSDT", "3003", "3004"))
+	assert.Empty(t, rec.take())
+
+	e.Observe(quote("ETHUSDT", "2990", "2991"))
+	assert.Equal(t, []string{"cross"}, rec.rules())
+
+	clk.advance(time.Minute)
+	e.Observe(quote("ETHUSDT", "3010", "3011"))
+	assert.Equal(t, []string{"above", "cross"}, rec.rules())
+}
+
+func TestEngine_Age(t *testing.T) {
+	e, rec, clk := newEngine(t, alert.Rule{ID: "stale", Condition: "age > 30s", Cooldown: alert.Duration(time.Minute)})
+
+	e.Observe(quote("BTCUSDT", "1", "2"))
+	e.Observe(quote("ETHUSDT", "1", "2"))
+
+	clk.advance(20 * time.Second)
+	e.Observe(quote("ETHUSDT", "1", "2"))
+	clk.advance(11 * time.Second)
+	e.Check()
+	assert.Equal(t, []string{"firing BTCUSDT"}, rec.take())
+
+	e.Check()
+	assert.Empty(t, rec.take())
+
+	e.Observe(quote("BTCUSDT", "1", "2"))
+	assert.Equal(t, []string{"resolved BTCUSDT"}, rec.take())
+
+	// stale again within cooldown
+	clk.advance(31 * time.Second)
+	e.Observe(quote("ETHUSDT", "1", "2"))
+	e.Check()
+	assert.Empty(t, rec.take())
+
+	clk.advance(time.Minute)
+	e.Check()
+	assert.ElementsMatch(t, []string{"firing BTCUSDT", "firing ETHUSDT"}, rec.take())
+}
+
+func TestEngine_Rules(t *testing.T) {
+	e, _, _ := newEngine(t, alert.Rule{ID: "b", Condition: "ask < 1"}, alert.Rule{ID: "a", Condition: "mid >= 2"})
+
+	rules := e.Rules()
+	require.Len(t, rules, 2)
+	assert.Equal(t, "a", rules[0].ID)
+
+	r, ok := e.Get("b")
+	require.True(t, ok)
+	assert.Equal(t, "ask < 1", r.Condition)
+
+	// configured rules are kept
+	require.ErrorIs(t, e.Delete("a"), alert.ErrConfigured)
+
+	require.NoError(t, e.Submit(alert.Rule{ID: "c", Condition: "bid > 1"}))
+	require.NoError(t, e.Delete("c"))
+	require.ErrorIs(t, e.Delete("c"), alert.ErrNotFound)
+
+	_, ok = e.Get("c")
+	assert.False(t, ok)
+}
+
+func TestEngine_Submit(t *testing.T) {
+	e, rec, _ := newEngine(t, alert.Rule{ID: "configured", Condition: "bid > 1", Webhook: "http://localhost:9000/hook"})
+	e.SetMaxRules(2).SetWebhookHosts([]string{"Hooks.Example.com"})
+
+	err := e.Submit(alert.Rule{ID: "internal", Condition: "bid > 1", Webhook: "http://169.254.169.254/latest"})
+	require.ErrorContains(t, err, `webhook host "169.254.169.254" is not allowed`)
+
+	// symbols are upper cased
+	require.NoError(t, e.Submit(alert.Rule{ID: "lower", Symbol: " btcusdt", Condition: "bid > 1"}))
+
+	r, ok := e.Get("lower")
+	require.True(t, ok)
+	assert.Equal(t, "BTCUSDT", r.Symbol)
+	require.NoError(t, e.Delete("lower"))
+
+	require.NoError(t, e.Submit(alert.Rule{ID: "api", Condition: "bid > 1", Webhook: "https://hooks.example.com/a"}))
+	require.ErrorIs(t, e.Submit(alert.Rule{ID: "more", Condition: "bid > 1"}), alert.ErrTooManyRules)
+	require.ErrorIs(t, e.Set(alert.Rule{ID: "more", Condition: "bid > 1"}), alert.ErrTooManyRules)
+
+	// replacing rule does not add one, configured rules are not replaced
+	require.NoError(t, e.Submit(alert.Rule{ID: "api", Condition: "bid > 2"}))
+	require.ErrorIs(t, e.Submit(alert.Rule{ID: "configured", Condition: "bid > 2"}), alert.ErrConfigured)
+
+	e.Observe(quote("BTCUSDT", "3", "4"))
+
+	submitted := map[string]bool{}
+	for _, a := range rec.alerts {
+		submitted[a.Rule] = a.Submitted
+	}
+
+	assert.Equal(t, map[string]bool{"configured": false, "api": true}, submitted)
+}
+
+func TestCheckWebhookHost(t *testing.T) {
+	for _, host := range []string{"hooks.example.com", "93.184.216.34", "[2606:2800:220:1::1]"} {
+		assert.NoError(t, alert.CheckWebhookHost(host), host)
+	}
+
+	for _, host := range []string{"", "localhost", "api.localhost", "127.0.0.1", "10.1.2.3", "192.168.0.1",
+		"169.254.169.254", "0.0.0.0", "[::1]", "fd00::1", "::ffff:127.0.0.1"} {
+		assert.Error(t, alert.CheckWebhookHost(host), host)
+	}
+}
+
+func TestRule_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		rule alert.Rule
+	}{
+		{name: "valid", rule: alert.Rule{ID: "a", Condition: "spread > 0.5", Webhook: "https://example.com/hook"}},
+		{name: "id", rule: alert.Rule{Condition: "bid > 1"}, want: "id is required"},
+		{name: "symbol", rule: alert.Rule{ID: "a", Symbol: "BTC-USDT", Condition: "bid > 1"}, want: `symbol "BTC-USDT" must be`},
+		{name: "format", rule: alert.Rule{ID: "a", Condition: "bid>1"}, want: "must look like <metric> <operator> <threshold>"},
+		{name: "metric", rule: alert.Rule{ID: "a", Condition: "volume > 1"}, want: `unknown metric "volume"`},
+		{name: "operator", rule: alert.Rule{ID: "a", Condition: "bid == 1"}, want: `unknown operator "=="`},
+		{name: "threshold", rule: alert.Rule{ID: "a", Condition: "bid > high"}, want: `threshold "high" is not a number`},
+		{name: "age", rule: alert.Rule{ID: "a", Condition: "age > 30"}, want: "age threshold must be a positive duration"},
+		{name: "age operator", rule: alert.Rule{ID: "a", Condition: "age < 30s"}, want: "age supports > and >= only"},
+		{name: "webhook", rule: alert.Rule{ID: "a", Condition: "bid > 1", Webhook: "ftp://x"}, want: "must be a http://"},
+		{name: "negative", rule: alert.Rule{ID: "a", Condition: "bid > 1", Hysteresis: -1}, want: "must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if tt.want == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestRule_Encoding(t *testing.T) {
+	var fromJSON alert.Rule
+
+	require.NoError(t, json.Unmarshal([]byte(`{"id":"a","condition":"bid > 1","for":"10s","cooldown":"1m"}`), &fromJSON))
+	assert.Equal(t, alert.Duration(10*time.Second), fromJSON.For)
+	assert.Equal(t, alert.Duration(time.Minute), fromJSON.Cooldown)
+
+	data, err := json.Marshal(fromJSON)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"a","condition":"bid > 1","for":"10s","cooldown":"1m0s"}`, string(data))
+
+	require.Error(t, json.Unmarshal([]byte(`{"for":10}`), &fromJSON))
+
+	var fromYAML alert.Rule
+
+	require.NoError(t, yaml.Unmarshal([]byte("id: a\ncondition: bid > 1\nfor: 10s\n"), &fromYAML))
+	assert.Equal(t, alert.Duration(10*time.Second), fromYAML.For)
+
+	require.Error(t, yaml.Unmarshal([]byte("for: soon\n"), &fromYAML))
+}
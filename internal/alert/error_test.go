@@ -0,0 +1,22 @@
+package alert_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/alert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	require.NoError(t, alert.NewError(nil))
+
+	stdErr := errors.New("something went wrong")
+	err := alert.NewError(stdErr)
+
+	var alertErr *alert.Error
+	require.True(t, errors.As(err, &alertErr))
+	assert.Equal(t, "[alert]: something went wrong", alertErr.Error())
+	assert.True(t, errors.Is(err, stdErr))
+}
@@ -0,0 +1,225 @@
+package alert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// Metrics of quotes conditions are evaluated on. Age is seconds since the last update.
+const (
+	MetricBid       = "bid"
+	MetricAsk       = "ask"
+	MetricMid       = "mid"
+	MetricSpread    = "spread"
+	MetricSpreadBps = "spread_bps"
+	MetricAge       = "age"
+)
+
+// Operators of conditions. Crossing operators fire when the value moves to the other
+// side of the threshold, comparisons fire when they hold for Rule.For.
+const (
+	OpGreater        = ">"
+	OpGreaterOrEqual = ">="
+	OpLess           = "<"
+	OpLessOrEqual    = "<="
+	OpCrosses        = "crosses"
+	OpCrossesAbove   = "crosses_above"
+	OpCrossesBelow   = "crosses_below"
+)
+
+// Rule fires when its condition over quotes of Symbol, every symbol if empty, is met.
+// Condition is "<metric> <operator> <threshold>", e.g. "spread_bps > 5", "bid crosses 3000"
+// or "age > 30s". Fired rule is not fired again until it is resolved, i.e. the value moves
+// back past the threshold by Hysteresis, and Cooldown since the last firing passes.
+// Alerts are posted to Webhook or logged when it is empty. Symbol is upper cased on validation.
+type Rule struct {
+	ID         string   `json:"id"                   yaml:"id"`
+	Symbol     string   `json:"symbol,omitempty"     yaml:"symbol,omitempty"`
+	Condition  string   `json:"condition"            yaml:"condition"`
+	Webhook    string   `json:"webhook,omitempty"    yaml:"webhook,omitempty"`
+	For        Duration `json:"for,omitempty"        yaml:"for,omitempty"`
+	Cooldown   Duration `json:"cooldown,omitempty"   yaml:"cooldown,omitempty"`
+	Hysteresis float64  `json:"hysteresis,omitempty" yaml:"hysteresis,omitempty"`
+}
+
+// condition is a parsed Rule.Condition.
+type condition struct {
+	metric    string
+	op        string
+	threshold float64
+}
+
+// Validate checks rule fields and parses its condition.
+func (r *Rule) Validate() error {
+	_, err := r.parse()
+	return err
+}
+
+func (r *Rule) parse() (*condition, error) {
+	var errs []error
+
+	if r.ID == "" {
+		errs = append(errs, errors.New("id is required"))
+	}
+
+	r.Symbol = strings.ToUpper(strings.TrimSpace(r.Symbol))
+	if r.Symbol != "" && !storage.ValidSymbol(r.Symbol) {
+		errs = append(errs, fmt.Errorf("symbol %q must be upper case letters, digits or underscores", r.Symbol))
+	}
+
+	c, err := parseCondition(r.Condition)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if r.Webhook != "" {
+		if u, err := url.Parse(r.Webhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("webhook %q must be a http:// or https:// url", r.Webhook))
+		}
+	}
+
+	if r.For < 0 || r.Cooldown < 0 || r.Hysteresis < 0 {
+		errs = append(errs, errors.New("for, cooldown and hysteresis must not be negative"))
+	}
+
+	if err = errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("rule %q: %w", r.ID, err)
+	}
+
+	return c, nil
+}
+
+func parseCondition(s string) (*condition, error) {
+	const parts = 3
+
+	fields := strings.Fields(s)
+	if len(fields) != parts {
+		return nil, fmt.Errorf("condition %q must look like <metric> <operator> <threshold>", s)
+	}
+
+	c := &condition{metric: strings.ToLower(fields[0]), op: strings.ToLower(fields[1])}
+
+	switch c.metric {
+	case MetricBid, MetricAsk, MetricMid, MetricSpread, MetricSpreadBps:
+	case MetricAge:
+		if c.op != OpGreater && c.op != OpGreaterOrEqual {
+			return nil, fmt.Errorf("condition %q: age supports > and >= only", s)
+		}
+
+		d, err := time.ParseDuration(fields[2])
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("condition %q: age threshold must be a positive duration", s)
+		}
+
+		c.threshold = d.Seconds()
+
+		return c, nil
+	default:
+		return nil, fmt.Errorf("condition %q: unknown metric %q", s, fields[0])
+	}
+
+	switch c.op {
+	case OpGreater, OpGreaterOrEqual, OpLess, OpLessOrEqual, OpCrosses, OpCrossesAbove, OpCrossesBelow:
+	default:
+		return nil, fmt.Errorf("condition %q: unknown operator %q", s, fields[1])
+	}
+
+	threshold, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return nil, fmt.Errorf("condition %q: threshold %q is not a number", s, fields[2])
+	}
+
+	c.threshold = threshold
+
+	return c, nil
+}
+
+// crossing reports whether condition fires on crossing the threshold.
+func (c *condition) crossing() bool {
+	return c.op == OpCrosses || c.op == OpCrossesAbove || c.op == OpCrossesBelow
+}
+
+// holds compares value with threshold shifted by offset.
+func (c *condition) holds(value, offset float64) bool {
+	switch c.op {
+	case OpGreater:
+		return value > c.threshold+offset
+	case OpGreaterOrEqual:
+		return value >= c.threshold+offset
+	case OpLess:
+		return value < c.threshold+offset
+	case OpLessOrEqual:
+		return value <= c.threshold+offset
+	}
+
+	return false
+}
+
+// resolved reports whether value moved back past the threshold by hysteresis.
+func (c *condition) resolved(value, hysteresis float64) bool {
+	if c.op == OpLess || c.op == OpLessOrEqual {
+		return !c.holds(value, hysteresis)
+	}
+
+	return !c.holds(value, -hysteresis)
+}
+
+// side returns 1 above the threshold band, -1 below it and 0 inside.
+func (c *condition) side(value, hysteresis float64) int {
+	switch {
+	case value > c.threshold+hysteresis:
+		return 1
+	case value < c.threshold-hysteresis:
+		return -1
+	}
+
+	return 0
+}
+
+// Duration is time.Duration written as "10s" in JSON and YAML.
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration %s must be a string like \"10s\"", data)
+	}
+
+	return d.parse(s)
+}
+
+func (d Duration) MarshalYAML() (any, error) {
+	return d.String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	return d.parse(node.Value)
+}
+
+func (d *Duration) parse(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("duration %q: %w", s, err)
+	}
+
+	*d = Duration(v)
+
+	return nil
+}
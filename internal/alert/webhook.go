@@ -0,0 +1,300 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/log"
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+)
+
+const (
+	DefaultRetries = 3
+	// DefaultBuffer is the number of alerts waiting for dispatch, newer ones are dropped.
+	DefaultBuffer = 1000
+	// DefaultHostBuffer is the number of alerts waiting for delivery to one webhook host, newer ones are dropped.
+	DefaultHostBuffer = 100
+
+	// SignatureHeader carries "sha256=" hex HMAC-SHA256 of "<timestamp>.<body>" keyed by the secret.
+	SignatureHeader = "X-Signature"
+	// TimestampHeader carries unix time of the signature, receivers should reject old ones.
+	TimestampHeader = "X-Signature-Timestamp"
+
+	minBackoff     = 500 * time.Millisecond
+	webhookTimeout = 10 * time.Second
+)
+
+// Dispatcher posts alerts to their webhooks or logs alerts of rules without webhook.
+// Every webhook host has its own worker, so a slow host delays its own alerts only.
+// Failed deliveries are retried with backoff on network errors, 429 and 5xx responses.
+type Dispatcher struct {
+	client  *http.Client
+	public  *http.Client
+	logger  *log.Logger
+	alerts  chan Alert
+	secret  []byte
+	retries int
+	backoff time.Duration
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		client:  &http.Client{Timeout: webhookTimeout},
+		public:  publicClient(),
+		alerts:  make(chan Alert, DefaultBuffer),
+		retries: DefaultRetries,
+		backoff: minBackoff,
+	}
+}
+
+// SetSecret sets HMAC key of webhook signatures, empty disables signing.
+func (d *Dispatcher) SetSecret(secret string) *Dispatcher {
+	d.secret = []byte(secret)
+	return d
+}
+
+// SetRetries sets the number of retries after the first failed delivery.
+func (d *Dispatcher) SetRetries(retries int) *Dispatcher {
+	d.retries = retries
+	return d
+}
+
+// SetBackoff sets delay before the first retry, it doubles with every next one.
+func (d *Dispatcher) SetBackoff(backoff time.Duration) *Dispatcher {
+	d.backoff = backoff
+	return d
+}
+
+func (d *Dispatcher) SetClient(c *http.Client) *Dispatcher {
+	d.client = c
+	return d
+}
+
+// SetLogger sets logger of alerts without webhook and of failed deliveries.
+func (d *Dispatcher) SetLogger(l *log.Logger) *Dispatcher {
+	d.logger = l
+	return d
+}
+
+// Notify queues alert, it is dropped if the queue is full.
+func (d *Dispatcher) Notify(a Alert) {
+	select {
+	case d.alerts <- a:
+	default:
+		metrics.AlertNotifications.WithLabelValues("dropped").Inc()
+	}
+}
+
+// Run logs alerts without webhook and queues the others to workers of their webhook hosts
+// until ctx is done, it returns after the workers exit.
+func (d *Dispatcher) Run(ctx context.Context) {
+	if d == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	hosts := make(map[string]chan Alert)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case a := <-d.alerts:
+			if a.Webhook == "" {
+				d.log(&a)
+				continue
+			}
+
+			host := a.Webhook
+			if u, err := url.Parse(a.Webhook); err == nil {
+				host = u.Host
+			}
+
+			queue, ok := hosts[host]
+			if !ok {
+				queue = make(chan Alert, DefaultHostBuffer)
+				hosts[host] = queue
+
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+					d.work(ctx, queue)
+				}()
+			}
+
+			select {
+			case queue <- a:
+			default:
+				metrics.AlertNotifications.WithLabelValues("dropped").Inc()
+			}
+		}
+	}
+}
+
+// work delivers alerts of one webhook host one by one until ctx is done.
+func (d *Dispatcher) work(ctx context.Context, queue <-chan Alert) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case a := <-queue:
+			d.deliver(ctx, &a)
+		}
+	}
+}
+
+func (d *Dispatcher) log(a *Alert) {
+	metrics.AlertNotifications.WithLabelValues("logged").Inc()
+
+	if d.logger != nil {
+		d.logger.Warnw("alert",
+			"rule", a.Rule,
+			"symbol", a.Symbol,
+			"condition", a.Condition,
+			"status", a.Status,
+			"value", a.Value,
+		)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, a *Alert) {
+	body, err := json.Marshal(a)
+	if err != nil {
+		d.fail(a, err)
+		return
+	}
+
+	backoff := d.backoff
+
+	for attempt := 0; ; attempt++ {
+		retry, err := d.post(ctx, a, body)
+		if err == nil {
+			metrics.AlertNotifications.WithLabelValues("delivered").Inc()
+			return
+		}
+
+		if !retry || attempt >= d.retries {
+			d.fail(a, err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			d.fail(a, ctx.Err())
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+// post sends body of alert and reports whether failed request may be retried. Alerts of
+// configured rules are signed, submitted ones are not and go to public addresses only.
+func (d *Dispatcher) post(ctx context.Context, a *Alert, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	client := d.client
+	if a.Submitted {
+		client = d.public
+	} else if len(d.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(d.secret, timestamp, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+		return retry, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+
+	return false, nil
+}
+
+func (d *Dispatcher) fail(a *Alert, err error) {
+	metrics.AlertNotifications.WithLabelValues("failed").Inc()
+
+	if d.logger != nil {
+		d.logger.Errorw("alert webhook failed", "rule", a.Rule, "symbol", a.Symbol, "error", err)
+	}
+}
+
+// CheckWebhookHost rejects localhost and loopback, link-local, private and unspecified addresses.
+func CheckWebhookHost(host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("webhook host %q is not public", host)
+	}
+
+	if ip, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil && !public(ip) {
+		return fmt.Errorf("webhook host %q is not public", host)
+	}
+
+	return nil
+}
+
+// publicClient does not follow proxies and refuses connections to addresses that are not public,
+// hostnames are checked after they are resolved.
+func publicClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil || !public(addr.Addr()) {
+				return fmt.Errorf("webhook address %s is not public", address)
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}
+}
+
+func public(ip netip.Addr) bool {
+	ip = ip.Unmap()
+
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// Sign returns signature of webhook body sent at timestamp.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,182 @@
+package alert_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/ole-larsen/binance-subscriber/internal/alert"
+	"github.com/ole-larsen/binance-subscriber/internal/log"
+)
+
+// dispatch runs d until the test is done and queues alerts.
+func dispatch(t *testing.T, d *alert.Dispatcher, alerts ...alert.Alert) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	go d.Run(ctx)
+
+	for _, a := range alerts {
+		d.Notify(a)
+	}
+}
+
+func TestDispatcher_Webhook(t *testing.T) {
+	var calls atomic.Int32
+
+	received := make(chan alert.Alert, 1)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		// the first attempt fails
+		if calls.Add(1) == 1 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		timestamp := r.Header.Get(alert.TimestampHeader)
+		assert.Equal(t, alert.Sign([]byte("secret"), timestamp, body), r.Header.Get(alert.SignatureHeader))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var a alert.Alert
+		assert.NoError(t, json.Unmarshal(body, &a))
+
+		received <- a
+	}))
+	defer ts.Close()
+
+	d := alert.NewDispatcher().SetSecret("secret").SetBackoff(time.Millisecond)
+	dispatch(t, d, alert.Alert{Rule: "spread", Symbol: "BTCUSDT", Status: alert.StatusFiring, Webhook: ts.URL, Value: 6})
+
+	select {
+	case a := <-received:
+		assert.Equal(t, "spread", a.Rule)
+		assert.Equal(t, "BTCUSDT", a.Symbol)
+		assert.InDelta(t, 6, a.Value, 0)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook is not delivered")
+	}
+
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestDispatcher_Failures(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+
+	var calls atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		status := http.StatusInternalServerError
+		if r.URL.Path == "/bad" {
+			status = http.StatusBadRequest
+		}
+
+		rw.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	d := alert.NewDispatcher().
+		SetRetries(2).
+		SetBackoff(time.Millisecond).
+		SetLogger(&log.Logger{SugaredLogger: zap.New(core).Sugar()})
+
+	dispatch(t, d,
+		alert.Alert{Rule: "retried", Webhook: ts.URL + "/down"},
+		alert.Alert{Rule: "rejected", Webhook: ts.URL + "/bad"},
+		alert.Alert{Rule: "logged", Symbol: "ETHUSDT", Status: alert.StatusFiring},
+	)
+
+	require.Eventually(t, func() bool { return logs.Len() == 3 }, 5*time.Second, time.Millisecond)
+
+	// 3 attempts of the unavailable webhook, 4xx is not retried
+	assert.Equal(t, int32(4), calls.Load())
+
+	// logged alert does not wait for deliveries
+	entries := logs.All()
+	assert.Equal(t, "alert", entries[0].Message)
+	assert.Equal(t, "ETHUSDT", entries[0].ContextMap()["symbol"])
+	assert.Equal(t, "alert webhook failed", entries[1].Message)
+	assert.Equal(t, "retried", entries[1].ContextMap()["rule"])
+	assert.Equal(t, "rejected", entries[2].ContextMap()["rule"])
+}
+
+func TestDispatcher_Hosts(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+
+	release := make(chan struct{})
+
+	slow := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	received := make(chan string, 1)
+
+	fast := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		received <- r.URL.Path
+	}))
+	defer fast.Close()
+
+	d := alert.NewDispatcher().SetLogger(&log.Logger{SugaredLogger: zap.New(core).Sugar()})
+
+	// alerts of other hosts and logged ones are not blocked by the hanging host
+	dispatch(t, d,
+		alert.Alert{Rule: "slow", Webhook: slow.URL + "/first"},
+		alert.Alert{Rule: "slow", Webhook: slow.URL + "/second"},
+		alert.Alert{Rule: "fast", Webhook: fast.URL + "/hook"},
+		alert.Alert{Rule: "logged"},
+	)
+
+	select {
+	case path := <-received:
+		assert.Equal(t, "/hook", path)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook is blocked by another host")
+	}
+
+	require.Eventually(t, func() bool { return logs.Len() == 1 }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, "logged", logs.All()[0].ContextMap()["rule"])
+}
+
+func TestDispatcher_Submitted(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+
+	var calls atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		calls.Add(1)
+	}))
+	defer ts.Close()
+
+	d := alert.NewDispatcher().
+		SetSecret("secret").
+		SetRetries(0).
+		SetLogger(&log.Logger{SugaredLogger: zap.New(core).Sugar()})
+
+	// alerts of API rules are not posted to loopback addresses
+	dispatch(t, d, alert.Alert{Rule: "api", Webhook: ts.URL, Submitted: true})
+
+	require.Eventually(t, func() bool { return logs.Len() == 1 }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, "alert webhook failed", logs.All()[0].Message)
+	assert.Contains(t, logs.All()[0].ContextMap()["error"], "is not public")
+	assert.Zero(t, calls.Load())
+}
@@ -0,0 +1,22 @@
+package arbitrage_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/arbitrage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	require.NoError(t, arbitrage.NewError(nil))
+
+	stdErr := errors.New("something went wrong")
+	err := arbitrage.NewError(stdErr)
+
+	var arbitrageErr *arbitrage.Error
+	require.True(t, errors.As(err, &arbitrageErr))
+	assert.Equal(t, "[arbitrage]: something went wrong", arbitrageErr.Error())
+	assert.True(t, errors.Is(err, stdErr))
+}
@@ -0,0 +1,329 @@
+// Package arbitrage monitors theoretical edges of triangular routes and of pairs of symbols
+// quoting the same asset on every stored top of book quote.
+package arbitrage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/fanout"
+	"github.com/ole-larsen/binance-subscriber/internal/log"
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// Opportunity statuses.
+const (
+	StatusOpen   = "open"
+	StatusClosed = "closed"
+)
+
+const (
+	// DefaultFeeBPS is the fee of every leg in basis points, Binance spot taker fee.
+	DefaultFeeBPS = 10
+	// DefaultThresholdBPS is the edge in basis points after fees opportunities are emitted above.
+	DefaultThresholdBPS = 5
+)
+
+// Fill is the price a leg is traded at, ask for buy and bid for sell.
+type Fill struct {
+	Symbol string  `json:"symbol"`
+	Side   string  `json:"side"`
+	Price  float64 `json:"price"`
+}
+
+// Opportunity is an edge of a route above threshold. Open opportunity is emitted again
+// whenever its edge changes and once closed when the edge falls below threshold or a leg
+// loses its quote.
+type Opportunity struct {
+	Time      time.Time `json:"time"`
+	Opened    time.Time `json:"opened"`
+	Route     string    `json:"route"`
+	Kind      string    `json:"kind"`
+	Direction string    `json:"direction"`
+	Status    string    `json:"status"`
+	Legs      []Fill    `json:"legs"`
+	EdgeBPS   float64   `json:"edge_bps"`
+}
+
+// Subscriber receives opportunities emitted after Subscribe, they are published with empty symbol.
+type Subscriber = fanout.Subscriber[Opportunity]
+
+// Monitor recalculates edges of routes whenever quote of one of their legs changes and
+// publishes opportunities to subscribers.
+type Monitor struct {
+	*fanout.Fanout[Opportunity]
+	routes    []*route
+	legs      map[string][]*route
+	quotes    map[string]quote
+	logger    *log.Logger
+	now       func() time.Time
+	fee       float64
+	threshold float64
+	mx        sync.Mutex
+}
+
+// quote is the latest top of book of a leg.
+type quote struct {
+	bid float64
+	ask float64
+}
+
+func New() *Monitor {
+	return &Monitor{
+		Fanout:    fanout.New[Opportunity](),
+		legs:      make(map[string][]*route),
+		quotes:    make(map[string]quote),
+		now:       time.Now,
+		fee:       DefaultFeeBPS / bps,
+		threshold: DefaultThresholdBPS,
+	}
+}
+
+// SetFee sets fee of every leg in basis points.
+func (m *Monitor) SetFee(feeBPS float64) *Monitor {
+	m.fee = feeBPS / bps
+	return m
+}
+
+// SetThreshold sets edge in basis points after fees opportunities are emitted above.
+func (m *Monitor) SetThreshold(thresholdBPS float64) *Monitor {
+	m.threshold = thresholdBPS
+	return m
+}
+
+// SetLogger sets logger of opened and closed opportunities.
+func (m *Monitor) SetLogger(l *log.Logger) *Monitor {
+	m.logger = l
+	return m
+}
+
+// SetClock replaces time.Now.
+func (m *Monitor) SetClock(now func() time.Time) *Monitor {
+	m.now = now
+	return m
+}
+
+// Set replaces triangles and pairs, their ids must be unique. Open opportunities are discarded.
+func (m *Monitor) Set(triangles []Triangle, pairs []Pair) error {
+	var routes []*route
+
+	ids := make(map[string]struct{}, len(triangles)+len(pairs))
+
+	add := func(id string, r []*route) error {
+		if _, ok := ids[id]; ok {
+			return fmt.Errorf("duplicate id %q", id)
+		}
+
+		ids[id] = struct{}{}
+		routes = append(routes, r...)
+
+		return nil
+	}
+
+	for i := range triangles {
+		if err := triangles[i].Validate(); err != nil {
+			return NewError(err)
+		}
+
+		if err := add(triangles[i].ID, triangles[i].routes()); err != nil {
+			return NewError(err)
+		}
+	}
+
+	for i := range pairs {
+		if err := pairs[i].Validate(); err != nil {
+			return NewError(err)
+		}
+
+		if err := add(pairs[i].ID, pairs[i].routes()); err != nil {
+			return NewError(err)
+		}
+	}
+
+	legs := make(map[string][]*route)
+
+	for _, r := range routes {
+		for _, symbol := range r.symbols() {
+			legs[symbol] = append(legs[symbol], r)
+		}
+	}
+
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.routes = routes
+	m.legs = legs
+
+	return nil
+}
+
+// Symbols returns sorted symbols of legs.
+func (m *Monitor) Symbols() []string {
+	if m == nil {
+		return nil
+	}
+
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	symbols := make([]string, 0, len(m.legs))
+	for symbol := range m.legs {
+		symbols = append(symbols, symbol)
+	}
+
+	sort.Strings(symbols)
+
+	return symbols
+}
+
+// Observe records the stored quote and emits opportunities of routes through it.
+// Malformed quote removes the leg until the next valid one.
+func (m *Monitor) Observe(data *storage.Data) {
+	if m == nil {
+		return
+	}
+
+	m.mx.Lock()
+
+	routes, ok := m.legs[data.Symbol]
+	if !ok {
+		m.mx.Unlock()
+		return
+	}
+
+	bid, errBid := strconv.ParseFloat(data.Bid, 64)
+	ask, errAsk := strconv.ParseFloat(data.Ask, 64)
+
+	if errBid != nil || errAsk != nil || bid <= 0 || ask <= 0 {
+		delete(m.quotes, data.Symbol)
+	} else {
+		m.quotes[data.Symbol] = quote{bid: bid, ask: ask}
+	}
+
+	now := m.now()
+
+	var events, transitions []Opportunity
+
+	for _, r := range routes {
+		o, transition := m.evaluate(r, now)
+		if o == nil {
+			continue
+		}
+
+		events = append(events, *o)
+
+		if transition {
+			transitions = append(transitions, *o)
+		}
+	}
+
+	m.publish(events)
+	m.mx.Unlock()
+
+	m.log(transitions)
+}
+
+// Opportunities returns open opportunities sorted by route and direction.
+func (m *Monitor) Opportunities() []Opportunity {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	res := make([]Opportunity, 0)
+
+	for _, r := range m.routes {
+		if r.open != nil {
+			res = append(res, *r.open)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Route != res[j].Route {
+			return res[i].Route < res[j].Route
+		}
+
+		return res[i].Direction < res[j].Direction
+	})
+
+	return res
+}
+
+// evaluate recalculates edge of route and returns opportunity to emit, nil if there is none
+// or the open one has not changed, and whether it is opened or closed.
+func (m *Monitor) evaluate(r *route, now time.Time) (*Opportunity, bool) {
+	edge, fills, ok := r.edge(m.quotes, m.fee)
+
+	if !ok || edge <= m.threshold {
+		if r.open == nil {
+			return nil, false
+		}
+
+		closed := *r.open
+		closed.Time = now
+		closed.Status = StatusClosed
+
+		if ok {
+			closed.EdgeBPS = edge
+			closed.Legs = fills
+		}
+
+		r.open = nil
+
+		return &closed, true
+	}
+
+	if r.open != nil && r.open.EdgeBPS == edge {
+		return nil, false
+	}
+
+	o := &Opportunity{
+		Time:      now,
+		Opened:    now,
+		Route:     r.id,
+		Kind:      r.kind,
+		Direction: r.direction,
+		Status:    StatusOpen,
+		Legs:      fills,
+		EdgeBPS:   edge,
+	}
+
+	opened := r.open == nil
+
+	if opened {
+		metrics.ArbitrageOpportunities.WithLabelValues(r.kind).Inc()
+	} else {
+		o.Opened = r.open.Opened
+	}
+
+	r.open = o
+
+	return o, opened
+}
+
+// publish sends opportunities to subscribers without blocking.
+func (m *Monitor) publish(events []Opportunity) {
+	for _, o := range events {
+		m.Publish("", o)
+	}
+}
+
+// log logs opened and closed opportunities.
+func (m *Monitor) log(transitions []Opportunity) {
+	if m.logger == nil {
+		return
+	}
+
+	for _, o := range transitions {
+		m.logger.Infow("arbitrage opportunity "+o.Status,
+			"route", o.Route,
+			"kind", o.Kind,
+			"direction", o.Direction,
+			"edge_bps", o.EdgeBPS,
+			"legs", o.Legs,
+			"opened", o.Opened,
+		)
+	}
+}
@@ -0,0 +1,175 @@
+package arbitrage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/arbitrage"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+func receive(t *testing.T, sub *arbitrage.Subscriber) []arbitrage.Opportunity {
+	t.Helper()
+
+	var res []arbitrage.Opportunity
+
+	for {
+		select {
+		case o := <-sub.Events():
+			res = append(res, o)
+		default:
+			return res
+		}
+	}
+}
+
+func TestMonitor_Triangle(t *testing.T) {
+	now := time.Unix(1700000000, 0).UTC()
+
+	m := arbitrage.New().SetClock(func() time.Time { return now })
+	require.NoError(t, m.Set([]arbitrage.Triangle{{
+		ID: "usdt-btc-eth",
+		Legs: []arbitrage.Leg{
+			{Symbol: "btcusdt", Side: arbitrage.SideBuy},
+			{Symbol: "ETHBTC", Side: arbitrage.SideBuy},
+			{Symbol: "ETHUSDT", Side: arbitrage.SideSell},
+		},
+	}}, nil))
+
+	assert.Equal(t, []string{"BTCUSDT", "ETHBTC", "ETHUSDT"}, m.Symbols())
+
+	sub := m.Subscribe(nil, 0)
+	defer m.Unsubscribe(sub)
+
+	m.Observe(&storage.Data{Symbol: "BTCUSDT", Bid: "99990", Ask: "100000"})
+	m.Observe(&storage.Data{Symbol: "ETHBTC", Bid: "0.0299", Ask: "0.03"})
+	assert.Empty(t, receive(t, sub))
+
+	// 1 / 100000 / 0.03 * 3030 after three 10 bps fees
+	m.Observe(&storage.Data{Symbol: "ETHUSDT", Bid: "3030", Ask: "3031"})
+
+	events := receive(t, sub)
+	require.Len(t, events, 1)
+	assert.Equal(t, "usdt-btc-eth", events[0].Route)
+	assert.Equal(t, arbitrage.KindTriangle, events[0].Kind)
+	assert.Equal(t, arbitrage.DirectionForward, events[0].Direction)
+	assert.Equal(t, arbitrage.StatusOpen, events[0].Status)
+	assert.Equal(t, now, events[0].Opened)
+	assert.InDelta(t, 69.7303, events[0].EdgeBPS, 1e-4)
+	assert.Equal(t, []arbitrage.Fill{
+		{Symbol: "BTCUSDT", Side: arbitrage.SideBuy, Price: 100000},
+		{Symbol: "ETHBTC", Side: arbitrage.SideBuy, Price: 0.03},
+		{Symbol: "ETHUSDT", Side: arbitrage.SideSell, Price: 3030},
+	}, events[0].Legs)
+	assert.Equal(t, events, m.Opportunities())
+
+	// unchanged edge is not emitted again
+	m.Observe(&storage.Data{Symbol: "ETHUSDT", Bid: "3030", Ask: "3031"})
+	assert.Empty(t, receive(t, sub))
+
+	opened := now
+	now = now.Add(time.Second)
+
+	m.Observe(&storage.Data{Symbol: "ETHUSDT", Bid: "3020", Ask: "3021"})
+
+	events = receive(t, sub)
+	require.Len(t, events, 1)
+	assert.Equal(t, arbitrage.StatusOpen, events[0].Status)
+	assert.Equal(t, opened, events[0].Opened)
+	assert.Equal(t, now, events[0].Time)
+	assert.InDelta(t, 36.4969, events[0].EdgeBPS, 1e-4)
+
+	m.Observe(&storage.Data{Symbol: "ETHUSDT", Bid: "3000", Ask: "3001"})
+
+	events = receive(t, sub)
+	require.Len(t, events, 1)
+	assert.Equal(t, arbitrage.StatusClosed, events[0].Status)
+	assert.InDelta(t, -29.97, events[0].EdgeBPS, 1e-4)
+	assert.Empty(t, m.Opportunities())
+}
+
+func TestMonitor_Pair(t *testing.T) {
+	m := arbitrage.New().SetFee(0).SetThreshold(1)
+	require.NoError(t, m.Set(nil, []arbitrage.Pair{{ID: "btc", Symbols: []string{"BTCUSDT", "BTCUSDC"}}}))
+
+	sub := m.Subscribe(nil, 1)
+	defer m.Unsubscribe(sub)
+
+	m.Observe(&storage.Data{Symbol: "BTCUSDT", Bid: "100", Ask: "101"})
+	m.Observe(&storage.Data{Symbol: "BTCUSDC", Bid: "102", Ask: "103"})
+
+	opportunities := m.Opportunities()
+	require.Len(t, opportunities, 1)
+	assert.Equal(t, arbitrage.KindPair, opportunities[0].Kind)
+	assert.Equal(t, arbitrage.DirectionForward, opportunities[0].Direction)
+	assert.InDelta(t, 99.0099, opportunities[0].EdgeBPS, 1e-4)
+
+	// malformed quote closes opportunity, it is dropped for the full subscriber
+	m.Observe(&storage.Data{Symbol: "BTCUSDC", Bid: "", Ask: "103"})
+	assert.Empty(t, m.Opportunities())
+	assert.Equal(t, uint64(1), m.Dropped(sub))
+
+	events := receive(t, sub)
+	require.Len(t, events, 1)
+	assert.Equal(t, arbitrage.StatusOpen, events[0].Status)
+
+	m.Observe(&storage.Data{Symbol: "BTCUSDC", Bid: "99", Ask: "99.5"})
+
+	events = receive(t, sub)
+	require.Len(t, events, 1)
+	assert.Equal(t, arbitrage.DirectionReverse, events[0].Direction)
+	assert.Equal(t, []arbitrage.Fill{
+		{Symbol: "BTCUSDC", Side: arbitrage.SideBuy, Price: 99.5},
+		{Symbol: "BTCUSDT", Side: arbitrage.SideSell, Price: 100},
+	}, events[0].Legs)
+
+	var nilMonitor *arbitrage.Monitor
+	nilMonitor.Observe(&storage.Data{Symbol: "BTCUSDT"})
+}
+
+func TestMonitor_Set(t *testing.T) {
+	legs := []arbitrage.Leg{
+		{Symbol: "BTCUSDT", Side: arbitrage.SideBuy},
+		{Symbol: "ETHBTC", Side: arbitrage.SideBuy},
+		{Symbol: "ETHUSDT", Side: arbitrage.SideSell},
+	}
+
+	tests := []struct {
+		name      string
+		want      string
+		triangles []arbitrage.Triangle
+		pairs     []arbitrage.Pair
+	}{
+		{
+			name:      "duplicate id",
+			triangles: []arbitrage.Triangle{{ID: "btc", Legs: legs}},
+			pairs:     []arbitrage.Pair{{ID: "btc", Symbols: []string{"BTCUSDT", "BTCUSDC"}}},
+			want:      `[arbitrage]: duplicate id "btc"`,
+		},
+		{
+			name:      "legs",
+			triangles: []arbitrage.Triangle{{ID: "t", Legs: []arbitrage.Leg{{Symbol: "BTCUSDT", Side: "long"}, {}}}},
+			want: `triangle "t": 2 legs, expected 3` + "\n" + `legs[0]: side "long" must be buy or sell` + "\n" +
+				"legs[1]: symbol is required\n" + `side "" must be buy or sell`,
+		},
+		{
+			name:  "pair",
+			pairs: []arbitrage.Pair{{Symbols: []string{"BTCUSDT", "btcusdt"}}},
+			want:  `pair "": id is required` + "\nsymbols must differ",
+		},
+		{
+			name:  "pair symbols",
+			pairs: []arbitrage.Pair{{ID: "p", Symbols: []string{"BTCUSDT"}}},
+			want:  `pair "p": 1 symbols, expected 2`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := arbitrage.New().Set(tt.triangles, tt.pairs)
+			require.ErrorContains(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package arbitrage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Sides of legs, buy fills at ask and sell at bid.
+const (
+	SideBuy  = "buy"
+	SideSell = "sell"
+)
+
+// Kinds of routes.
+const (
+	KindTriangle = "triangle"
+	KindPair     = "pair"
+)
+
+// Directions of routes, reverse route goes through legs backwards with opposite sides.
+const (
+	DirectionForward = "forward"
+	DirectionReverse = "reverse"
+)
+
+// bps is the number of basis points in one.
+const bps = 10_000.0
+
+// Leg buys or sells Symbol.
+type Leg struct {
+	Symbol string `json:"symbol" yaml:"symbol"`
+	Side   string `json:"side"   yaml:"side"`
+}
+
+// Triangle converts an asset back to itself through three Legs, e.g. buy BTCUSDT, buy ETHBTC
+// and sell ETHUSDT starts and ends in USDT. The reverse route is monitored as well.
+type Triangle struct {
+	ID   string `json:"id"   yaml:"id"`
+	Legs []Leg  `json:"legs" yaml:"legs"`
+}
+
+// Pair compares two Symbols quoting the same asset on different venues or in different
+// quote currencies, e.g. BTCUSDT and a synthetic instrument, by buying one at ask and
+// selling the other at bid in both directions.
+type Pair struct {
+	ID      string   `json:"id"      yaml:"id"`
+	Symbols []string `json:"symbols" yaml:"symbols"`
+}
+
+// Validate checks id and legs of triangle.
+func (t *Triangle) Validate() error {
+	var errs []error
+
+	if t.ID == "" {
+		errs = append(errs, errors.New("id is required"))
+	}
+
+	if len(t.Legs) != 3 {
+		errs = append(errs, fmt.Errorf("%d legs, expected 3", len(t.Legs)))
+	}
+
+	for i := range t.Legs {
+		if err := t.Legs[i].validate(); err != nil {
+			errs = append(errs, fmt.Errorf("legs[%d]: %w", i, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("triangle %q: %w", t.ID, err)
+	}
+
+	return nil
+}
+
+// Validate checks id and symbols of pair.
+func (p *Pair) Validate() error {
+	var errs []error
+
+	if p.ID == "" {
+		errs = append(errs, errors.New("id is required"))
+	}
+
+	if len(p.Symbols) != 2 {
+		errs = append(errs, fmt.Errorf("%d symbols, expected 2", len(p.Symbols)))
+	}
+
+	for i, symbol := range p.Symbols {
+		if symbol == "" {
+			errs = append(errs, fmt.Errorf("symbols[%d] is required", i))
+		}
+	}
+
+	if len(p.Symbols) == 2 && strings.EqualFold(p.Symbols[0], p.Symbols[1]) {
+		errs = append(errs, errors.New("symbols must differ"))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("pair %q: %w", p.ID, err)
+	}
+
+	return nil
+}
+
+func (l *Leg) validate() error {
+	var errs []error
+
+	if l.Symbol == "" {
+		errs = append(errs, errors.New("symbol is required"))
+	}
+
+	if l.Side != SideBuy && l.Side != SideSell {
+		errs = append(errs, fmt.Errorf("side %q must be buy or sell", l.Side))
+	}
+
+	return errors.Join(errs...)
+}
+
+// route is a direction of a triangle or pair with its open opportunity.
+type route struct {
+	open      *Opportunity
+	id        string
+	kind      string
+	direction string
+	legs      []Leg
+}
+
+func (t *Triangle) routes() []*route {
+	forward := make([]Leg, len(t.Legs))
+	reverse := make([]Leg, len(t.Legs))
+
+	for i, leg := range t.Legs {
+		forward[i] = Leg{Symbol: strings.ToUpper(leg.Symbol), Side: leg.Side}
+		reverse[len(t.Legs)-1-i] = Leg{Symbol: forward[i].Symbol, Side: opposite(leg.Side)}
+	}
+
+	return []*route{
+		{id: t.ID, kind: KindTriangle, direction: DirectionForward, legs: forward},
+		{id: t.ID, kind: KindTriangle, direction: DirectionReverse, legs: reverse},
+	}
+}
+
+func (p *Pair) routes() []*route {
+	a, b := strings.ToUpper(p.Symbols[0]), strings.ToUpper(p.Symbols[1])
+
+	return []*route{
+		{
+			id: p.ID, kind: KindPair, direction: DirectionForward,
+			legs: []Leg{{Symbol: a, Side: SideBuy}, {Symbol: b, Side: SideSell}},
+		},
+		{
+			id: p.ID, kind: KindPair, direction: DirectionReverse,
+			legs: []Leg{{Symbol: b, Side: SideBuy}, {Symbol: a, Side: SideSell}},
+		},
+	}
+}
+
+// edge returns edge in basis points of trading through legs paying fee on every leg and
+// prices of legs, false if a leg has no quote.
+func (r *route) edge(quotes map[string]quote, fee float64) (float64, []Fill, bool) {
+	amount := 1.0
+	fills := make([]Fill, 0, len(r.legs))
+
+	for _, leg := range r.legs {
+		q, ok := quotes[leg.Symbol]
+		if !ok {
+			return 0, nil, false
+		}
+
+		price := q.bid
+
+		if leg.Side == SideBuy {
+			price = q.ask
+			amount /= price
+		} else {
+			amount *= price
+		}
+
+		amount *= 1 - fee
+
+		fills = append(fills, Fill{Symbol: leg.Symbol, Side: leg.Side, Price: price})
+	}
+
+	return (amount - 1) * bps, fills, true
+}
+
+// symbols returns distinct symbols of legs.
+func (r *route) symbols() []string {
+	symbols := make([]string, 0, len(r.legs))
+
+	for _, leg := range r.legs {
+		if !slices.Contains(symbols, leg.Symbol) {
+			symbols = append(symbols, leg.Symbol)
+		}
+	}
+
+	return symbols
+}
+
+func opposite(side string) string {
+	if side == SideBuy {
+		return SideSell
+	}
+
+	return SideBuy
+}
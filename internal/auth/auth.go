@@ -0,0 +1,167 @@
+// Package auth authenticates REST and websocket clients by API keys and JWT.
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/helpers"
+)
+
+var (
+	ErrNoCredentials      = NewError(errors.New("credentials are missing"))
+	ErrInvalidCredentials = NewError(errors.New("invalid credentials"))
+)
+
+// Options describes accepted credentials. Authentication is disabled when
+// neither keys file nor JWT secret nor JWKS file is set.
+type Options struct {
+	KeysFile  string
+	JWTSecret string
+	JWKSFile  string
+	Issuer    string
+	Audience  string
+}
+
+// Authenticator checks API keys and JWT. Keys and JWKS files can be rotated at runtime.
+type Authenticator struct {
+	keys atomic.Pointer[KeyStore]
+	jwks atomic.Pointer[JWKS]
+	now  func() time.Time
+	opts Options
+}
+
+// New loads keys and JWKS files.
+func New(opts Options) (*Authenticator, error) {
+	a := &Authenticator{
+		opts: opts,
+		now:  time.Now,
+	}
+
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Enabled reports whether any credentials are configured.
+func (a *Authenticator) Enabled() bool {
+	return a != nil && (a.opts.KeysFile != "" || a.opts.JWTSecret != "" || a.opts.JWKSFile != "")
+}
+
+// Reload re-reads keys and JWKS files. Current keys are kept if files are invalid.
+func (a *Authenticator) Reload() error {
+	if a == nil {
+		return nil
+	}
+
+	var (
+		keys *KeyStore
+		jwks *JWKS
+		err  error
+	)
+
+	if a.opts.KeysFile != "" {
+		if keys, err = LoadKeys(a.opts.KeysFile); err != nil {
+			return err
+		}
+	}
+
+	if a.opts.JWKSFile != "" {
+		if jwks, err = LoadJWKS(a.opts.JWKSFile); err != nil {
+			return err
+		}
+	}
+
+	a.keys.Store(keys)
+	a.jwks.Store(jwks)
+
+	return nil
+}
+
+// Watch reloads keys and JWKS files when they change until ctx is done.
+func (a *Authenticator) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	if !a.Enabled() || interval <= 0 {
+		return
+	}
+
+	helpers.WatchFiles(ctx, interval, func() {
+		if err := a.Reload(); err != nil {
+			onError(err)
+		}
+	}, a.opts.KeysFile, a.opts.JWKSFile)
+}
+
+// Authenticate returns identity of the request. Credentials are read from
+// "Authorization: Bearer", "X-API-Key" header or "api_key"/"access_token" query
+// parameters, the latter are needed for browser websocket clients. Without them
+// a verified TLS client certificate mapped in the keys file is accepted.
+func (a *Authenticator) Authenticate(r *http.Request) (*Identity, error) {
+	var cert *x509.Certificate
+
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		cert = r.TLS.VerifiedChains[0][0]
+	}
+
+	return a.Verify(credentials(r), cert)
+}
+
+// Verify returns identity of API key or JWT credential, or of verified client
+// certificate if credential is empty. It is used by non-HTTP transports.
+func (a *Authenticator) Verify(credential string, cert *x509.Certificate) (*Identity, error) {
+	if !a.Enabled() {
+		return Anonymous, nil
+	}
+
+	if credential == "" {
+		if id, ok := a.keys.Load().LookupCertificate(cert, a.now()); ok {
+			return id, nil
+		}
+
+		return nil, ErrNoCredentials
+	}
+
+	// JWT has exactly three dot separated parts, API keys have none
+	if strings.Count(credential, ".") == 2 && (a.opts.JWTSecret != "" || a.opts.JWKSFile != "") {
+		id, err := verifyJWT(credential, []byte(a.opts.JWTSecret), a.jwks.Load(), a.opts.Issuer, a.opts.Audience)
+		if err != nil {
+			return nil, NewError(fmt.Errorf("invalid token: %w", err))
+		}
+
+		return id, nil
+	}
+
+	id, ok := a.keys.Load().Lookup(credential, a.now())
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	return id, nil
+}
+
+func credentials(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	query := r.URL.Query()
+
+	if key := query.Get("api_key"); key != "" {
+		return key
+	}
+
+	return query.Get("access_token")
+}
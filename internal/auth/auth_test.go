@@ -0,0 +1,318 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ole-larsen/binance-subscriber/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func request(header, value, query string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/ws"+query, http.NoBody)
+	if header != "" {
+		r.Header.Set(header, value)
+	}
+
+	return r
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.Claims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return signed
+}
+
+func claims(scope string, ttl time.Duration) *auth.Claims {
+	return &auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "algo",
+			Issuer:    "issuer",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+		Scope: scope,
+	}
+}
+
+func TestAuthenticator_Disabled(t *testing.T) {
+	a, err := auth.New(auth.Options{})
+	require.NoError(t, err)
+	assert.False(t, a.Enabled())
+
+	id, err := a.Authenticate(request("", "", ""))
+	require.NoError(t, err)
+	assert.Equal(t, auth.Anonymous, id)
+	assert.True(t, id.HasScope(auth.ScopeSubscriptionsManage))
+
+	var nilAuth *auth.Authenticator
+
+	id, err = nilAuth.Authenticate(request("", "", ""))
+	require.NoError(t, err)
+	assert.Equal(t, auth.Anonymous, id)
+}
+
+func TestAuthenticator_APIKeys(t *testing.T) {
+	digest := sha256.Sum256([]byte("hashed-key"))
+
+	keys := writeFile(t, "keys.yaml", `
+keys:
+  - id: dashboard
+    key: plain-key
+    scopes: [quotes:read]
+  - id: ops
+    sha256: `+hex.EncodeToString(digest[:])+`
+    scopes: [admin]
+  - id: old
+    key: expired-key
+    scopes: [quotes:read]
+    expires_at: 2020-01-01T00:00:00Z
+`)
+
+	a, err := auth.New(auth.Options{KeysFile: keys})
+	require.NoError(t, err)
+	assert.True(t, a.Enabled())
+
+	tests := []struct {
+		err     error
+		request *http.Request
+		name    string
+		subject string
+		scope   string
+	}{
+		{name: "bearer", request: request("Authorization", "Bearer plain-key", ""), subject: "dashboard", scope: auth.ScopeQuotesRead},
+		{name: "header", request: request("X-API-Key", "hashed-key", ""), subject: "ops", scope: auth.ScopeSubscriptionsManage},
+		{name: "query", request: request("", "", "?api_key=plain-key"), subject: "dashboard", scope: auth.ScopeQuotesRead},
+		{name: "missing", request: request("", "", ""), err: auth.ErrNoCredentials},
+		{name: "unknown", request: request("X-API-Key", "nope", ""), err: auth.ErrInvalidCredentials},
+		{name: "expired", request: request("X-API-Key", "expired-key", ""), err: auth.ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := a.Authenticate(tt.request)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.subject, id.Subject)
+			assert.True(t, id.HasScope(tt.scope))
+		})
+	}
+
+	// rotation: new key is accepted, removed key is rejected, invalid file keeps current keys
+	require.NoError(t, os.WriteFile(keys, []byte("keys:\n  - id: dashboard\n    key: new-key\n    scopes: [quotes:read]\n"), 0o600))
+	require.NoError(t, a.Reload())
+
+	_, err = a.Authenticate(request("X-API-Key", "new-key", ""))
+	require.NoError(t, err)
+
+	_, err = a.Authenticate(request("X-API-Key", "plain-key", ""))
+	require.ErrorIs(t, err, auth.ErrInvalidCredentials)
+
+	require.NoError(t, os.WriteFile(keys, []byte("keys:\n  - id: broken\n    scopes: [everything]\n"), 0o600))
+	require.Error(t, a.Reload())
+
+	_, err = a.Authenticate(request("X-API-Key", "new-key", ""))
+	require.NoError(t, err)
+}
+
+func TestLoadKeys_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{name: "missing id", content: "keys:\n  - key: k\n    scopes: [admin]\n", errMsg: "id is required"},
+		{name: "both key and digest", content: "keys:\n  - id: a\n    key: k\n    sha256: abc\n    scopes: [admin]\n", errMsg: "set either key, sha256 or common_name"},
+		{name: "no key", content: "keys:\n  - id: a\n    scopes: [admin]\n", errMsg: "key, sha256 hex digest or common_name is required"},
+		{name: "unknown scope", content: "keys:\n  - id: a\n    key: k\n    scopes: [root]\n", errMsg: `unknown scope "root"`},
+		{name: "no scopes", content: "keys:\n  - id: a\n    key: k\n", errMsg: "at least one scope is required"},
+		{name: "unknown field", content: "keys:\n  - id: a\n    secret: k\n", errMsg: "field secret not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := auth.LoadKeys(writeFile(t, "keys.yaml", tt.content))
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestAuthenticator_HMAC(t *testing.T) {
+	secret := []byte("top-secret")
+
+	a, err := auth.New(auth.Options{JWTSecret: string(secret), Issuer: "issuer"})
+	require.NoError(t, err)
+
+	token := sign(t, jwt.SigningMethodHS256, secret, "", claims("quotes:read subscriptions:manage", time.Minute))
+
+	id, err := a.Authenticate(request("Authorization", "Bearer "+token, ""))
+	require.NoError(t, err)
+	assert.Equal(t, "algo", id.Subject)
+	assert.True(t, id.HasScope(auth.ScopeSubscriptionsManage))
+	assert.False(t, id.HasScope(auth.ScopeAdmin))
+
+	expired := sign(t, jwt.SigningMethodHS256, secret, "", claims("quotes:read", -time.Minute))
+	_, err = a.Authenticate(request("", "", "?access_token="+expired))
+	assert.ErrorContains(t, err, "token is expired")
+
+	forged := sign(t, jwt.SigningMethodHS256, []byte("other"), "", claims("admin", time.Minute))
+	_, err = a.Authenticate(request("Authorization", "Bearer "+forged, ""))
+	assert.ErrorContains(t, err, "invalid token")
+
+	wrongIssuer := claims("admin", time.Minute)
+	wrongIssuer.Issuer = "someone"
+	_, err = a.Authenticate(request("Authorization", "Bearer "+sign(t, jwt.SigningMethodHS256, secret, "", wrongIssuer), ""))
+	assert.ErrorContains(t, err, "invalid issuer")
+}
+
+func TestAuthenticator_JWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	enc := base64.RawURLEncoding.EncodeToString
+
+	jwks, err := json.Marshal(map[string]any{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "n": enc(rsaKey.N.Bytes()), "e": enc(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "OKP", "kid": "ed-1", "crv": "Ed25519", "x": enc(edPub)},
+		},
+	})
+	require.NoError(t, err)
+
+	a, err := auth.New(auth.Options{JWKSFile: writeFile(t, "jwks.json", string(jwks))})
+	require.NoError(t, err)
+
+	token := sign(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", &auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "svc", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+		Scopes:           []string{auth.ScopeAdmin},
+	})
+
+	id, err := a.Authenticate(request("Authorization", "Bearer "+token, ""))
+	require.NoError(t, err)
+	assert.Equal(t, "svc", id.Subject)
+	assert.True(t, id.HasScope(auth.ScopeQuotesRead))
+
+	token = sign(t, jwt.SigningMethodEdDSA, edKey, "ed-1", claims("quotes:read", time.Minute))
+	_, err = a.Authenticate(request("Authorization", "Bearer "+token, ""))
+	require.NoError(t, err)
+
+	token = sign(t, jwt.SigningMethodRS256, rsaKey, "rsa-2", claims("quotes:read", time.Minute))
+	_, err = a.Authenticate(request("Authorization", "Bearer "+token, ""))
+	assert.ErrorContains(t, err, `unknown kid "rsa-2"`)
+
+	// hmac tokens are rejected without secret
+	token = sign(t, jwt.SigningMethodHS256, []byte("secret"), "", claims("admin", time.Minute))
+	_, err = a.Authenticate(request("Authorization", "Bearer "+token, ""))
+	assert.ErrorContains(t, err, "hmac tokens are not accepted")
+
+	_, err = auth.LoadJWKS(writeFile(t, "jwks.json", `{"keys":[{"kty":"EC","kid":"x","crv":"P-192"}]}`))
+	assert.ErrorContains(t, err, `unsupported curve "P-192"`)
+}
+
+func TestAuthenticator_ClientCertificate(t *testing.T) {
+	keys := writeFile(t, "keys.yaml", `
+keys:
+  - id: pricing
+    common_name: pricing.internal
+    scopes: [quotes:read]
+  - id: reader
+    key: read-key
+    scopes: [quotes:read]
+`)
+
+	a, err := auth.New(auth.Options{KeysFile: keys})
+	require.NoError(t, err)
+
+	withCert := func(cert *x509.Certificate) *http.Request {
+		r := request("", "", "")
+		r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+
+		return r
+	}
+
+	id, err := a.Authenticate(withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "pricing.internal"}}))
+	require.NoError(t, err)
+	assert.Equal(t, "pricing", id.Subject)
+
+	id, err = a.Authenticate(withCert(&x509.Certificate{DNSNames: []string{"other", "pricing.internal"}}))
+	require.NoError(t, err)
+	assert.Equal(t, "pricing", id.Subject)
+
+	_, err = a.Authenticate(withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}}))
+	require.ErrorIs(t, err, auth.ErrNoCredentials)
+
+	// explicit credentials take precedence over certificate
+	r := withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "pricing.internal"}})
+	r.Header.Set("X-API-Key", "read-key")
+
+	id, err = a.Authenticate(r)
+	require.NoError(t, err)
+	assert.Equal(t, "reader", id.Subject)
+
+	// unverified peer certificates are ignored
+	r = request("", "", "")
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "pricing.internal"}}}}
+	_, err = a.Authenticate(r)
+	require.ErrorIs(t, err, auth.ErrNoCredentials)
+}
+
+func TestAuthenticator_Watch(t *testing.T) {
+	keys := writeFile(t, "keys.yaml", "keys:\n  - id: a\n    key: first\n    scopes: [quotes:read]\n")
+
+	a, err := auth.New(auth.Options{KeysFile: keys})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go a.Watch(ctx, 10*time.Millisecond, func(err error) {
+		t.Error(err)
+	})
+
+	time.Sleep(30 * time.Millisecond)
+	require.NoError(t, os.WriteFile(keys, []byte("keys:\n  - id: a\n    key: second\n    scopes: [quotes:read]\n"), 0o600))
+
+	require.Eventually(t, func() bool {
+		_, err := a.Authenticate(request("X-API-Key", "second", ""))
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+}
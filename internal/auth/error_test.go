@@ -0,0 +1,22 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	require.NoError(t, auth.NewError(nil))
+
+	stdErr := errors.New("something went wrong")
+	err := auth.NewError(stdErr)
+
+	var authErr *auth.Error
+	require.True(t, errors.As(err, &authErr))
+	assert.Equal(t, "[auth]: something went wrong", authErr.Error())
+	assert.True(t, errors.Is(err, stdErr))
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Scopes granted to API keys and tokens.
+const (
+	ScopeQuotesRead = "quotes:read"
+	// ScopeSubscriptionsManage grants reloading configuration, which changes upstream subscriptions.
+	ScopeSubscriptionsManage = "subscriptions:manage"
+	ScopeAlertsManage        = "alerts:manage"
+	// ScopeAdmin grants admin/debug endpoints and implies every other scope.
+	ScopeAdmin = "admin"
+)
+
+// Identity is an authenticated API client.
+type Identity struct {
+	Subject string
+	Scopes  []string
+}
+
+// Anonymous is used when authentication is disabled, it has every scope.
+var Anonymous = &Identity{
+	Subject: "anonymous",
+	Scopes:  []string{ScopeAdmin},
+}
+
+// HasScope reports whether identity is granted the scope.
+func (i *Identity) HasScope(scope string) bool {
+	if i == nil {
+		return false
+	}
+
+	return slices.Contains(i.Scopes, scope) || slices.Contains(i.Scopes, ScopeAdmin)
+}
+
+type contextKey struct{}
+
+// WithIdentity returns context carrying identity.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns identity stored by the authentication middleware, nil if none.
+func FromContext(ctx context.Context) *Identity {
+	id, ok := ctx.Value(contextKey{}).(*Identity)
+	if !ok {
+		return nil
+	}
+
+	return id
+}
@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWKS is a set of public keys used to verify asymmetric tokens, indexed by kid.
+type JWKS struct {
+	keys map[string]any
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// LoadJWKS reads JSON Web Key Set file with RSA, EC and Ed25519 public keys.
+func LoadJWKS(path string) (*JWKS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err = json.Unmarshal(data, &set); err != nil {
+		return nil, NewError(fmt.Errorf("jwks file %s: %w", path, err))
+	}
+
+	jwks := &JWKS{keys: make(map[string]any, len(set.Keys))}
+
+	for i := range set.Keys {
+		key, err := set.Keys[i].publicKey()
+		if err != nil {
+			return nil, NewError(fmt.Errorf("jwks file %s: key %q: %w", path, set.Keys[i].Kid, err))
+		}
+
+		jwks.keys[set.Keys[i].Kid] = key
+	}
+
+	return jwks, nil
+}
+
+// Len returns number of loaded keys.
+func (s *JWKS) Len() int {
+	if s == nil {
+		return 0
+	}
+
+	return len(s.keys)
+}
+
+func (k *jwk) publicKey() (any, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// Claims of access tokens. Scopes are read from space separated "scope" or "scopes" array.
+type Claims struct {
+	jwt.RegisteredClaims
+	Scope  string   `json:"scope,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// verifyJWT checks token signature with HMAC secret or JWKS key and returns its identity.
+func verifyJWT(token string, secret []byte, jwks *JWKS, issuer, audience string) (*Identity, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithExpirationRequired(),
+		jwt.WithValidMethods([]string{"HS256", "HS384", "HS512", "RS256", "RS384", "RS512",
+			"PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+	}
+
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+
+	var claims Claims
+
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+			if len(secret) == 0 {
+				return nil, errors.New("hmac tokens are not accepted")
+			}
+
+			return secret, nil
+		}
+
+		kid, _ := t.Header["kid"].(string)
+
+		if jwks == nil {
+			return nil, errors.New("asymmetric tokens are not accepted")
+		}
+
+		key, ok := jwks.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown kid %q", kid)
+		}
+
+		return key, nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := claims.Scopes
+	if claims.Scope != "" {
+		scopes = append(scopes, strings.Fields(claims.Scope)...)
+	}
+
+	return &Identity{Subject: claims.Subject, Scopes: scopes}, nil
+}
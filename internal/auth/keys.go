@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Key is an API key entry of the keys file. Either plain key, its sha256 hex digest or
+// common name of a verified client certificate (mTLS) is set.
+// Several keys of the same client can be active at once to rotate them without downtime.
+type Key struct {
+	ExpiresAt  time.Time `yaml:"expires_at"`
+	ID         string    `yaml:"id"`
+	Key        string    `yaml:"key"`
+	SHA256     string    `yaml:"sha256"`
+	CommonName string    `yaml:"common_name"`
+	Scopes     []string  `yaml:"scopes"`
+}
+
+type keysFile struct {
+	Keys []Key `yaml:"keys"`
+}
+
+// KeyStore looks up API keys by their sha256 digest and client certificates by common name.
+type KeyStore struct {
+	keys  map[string]*Key
+	certs map[string]*Key
+}
+
+// LoadKeys reads YAML keys file:
+//
+//	keys:
+//	  - id: dashboard
+//	    sha256: 9f86d0...
+//	    scopes: [quotes:read]
+//	    expires_at: 2026-01-01T00:00:00Z
+//	  - id: pricing-service
+//	    common_name: pricing.internal
+//	    scopes: [quotes:read]
+func LoadKeys(path string) (*KeyStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	var f keysFile
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err = dec.Decode(&f); err != nil {
+		return nil, NewError(fmt.Errorf("keys file %s: %w", path, err))
+	}
+
+	store := &KeyStore{
+		keys:  make(map[string]*Key, len(f.Keys)),
+		certs: make(map[string]*Key),
+	}
+
+	var errs []error
+
+	for i := range f.Keys {
+		k := &f.Keys[i]
+
+		digest := strings.ToLower(k.SHA256)
+
+		switch {
+		case k.ID == "":
+			errs = append(errs, fmt.Errorf("keys[%d]: id is required", i))
+			continue
+		case countSet(k.Key, digest, k.CommonName) > 1:
+			errs = append(errs, fmt.Errorf("keys[%d] %s: set either key, sha256 or common_name", i, k.ID))
+			continue
+		case k.CommonName != "":
+		case k.Key != "":
+			digest = hash(k.Key)
+		case len(digest) != sha256.Size*2:
+			errs = append(errs, fmt.Errorf("keys[%d] %s: key, sha256 hex digest or common_name is required", i, k.ID))
+			continue
+		}
+
+		if err = validateScopes(k.Scopes); err != nil {
+			errs = append(errs, fmt.Errorf("keys[%d] %s: %w", i, k.ID, err))
+			continue
+		}
+
+		if k.CommonName != "" {
+			store.certs[k.CommonName] = k
+			continue
+		}
+
+		store.keys[digest] = k
+	}
+
+	if err = errors.Join(errs...); err != nil {
+		return nil, NewError(fmt.Errorf("keys file %s: %w", path, err))
+	}
+
+	return store, nil
+}
+
+// Lookup returns identity of a valid, not expired key.
+func (s *KeyStore) Lookup(key string, now time.Time) (*Identity, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	return s.identity(s.keys[hash(key)], now)
+}
+
+// LookupCertificate returns identity mapped to a verified client certificate by its
+// subject common name or DNS names.
+func (s *KeyStore) LookupCertificate(cert *x509.Certificate, now time.Time) (*Identity, bool) {
+	if s == nil || cert == nil {
+		return nil, false
+	}
+
+	for _, name := range append([]string{cert.Subject.CommonName}, cert.DNSNames...) {
+		if id, ok := s.identity(s.certs[name], now); ok {
+			return id, true
+		}
+	}
+
+	return nil, false
+}
+
+func (s *KeyStore) identity(k *Key, now time.Time) (*Identity, bool) {
+	if k == nil || (!k.ExpiresAt.IsZero() && now.After(k.ExpiresAt)) {
+		return nil, false
+	}
+
+	return &Identity{Subject: k.ID, Scopes: k.Scopes}, true
+}
+
+// Len returns number of loaded keys.
+func (s *KeyStore) Len() int {
+	if s == nil {
+		return 0
+	}
+
+	return len(s.keys) + len(s.certs)
+}
+
+func countSet(values ...string) int {
+	n := 0
+
+	for _, v := range values {
+		if v != "" {
+			n++
+		}
+	}
+
+	return n
+}
+
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeQuotesRead, ScopeSubscriptionsManage, ScopeAlertsManage, ScopeAdmin:
+		default:
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package exchange_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/exchange"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	require.NoError(t, exchange.NewError(nil))
+
+	stdErr := errors.New("something went wrong")
+	err := exchange.NewError(stdErr)
+
+	var exchangeErr *exchange.Error
+	require.True(t, errors.As(err, &exchangeErr))
+	assert.Equal(t, "[exchange]: something went wrong", exchangeErr.Error())
+	assert.True(t, errors.Is(err, stdErr))
+}
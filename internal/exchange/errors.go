@@ -0,0 +1,28 @@
+package exchange
+
+import (
+	"fmt"
+)
+
+// Error - custom exchange error.
+type Error struct {
+	err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("[exchange]: %v", e.err)
+}
+
+func NewError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{
+		err: err,
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,223 @@
+// Package exchange contains Binance exchangeInfo snapshot used to validate
+// instruments and to format prices and quantities.
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const StatusTrading = "TRADING"
+
+// Info is a snapshot of /api/v3/exchangeInfo, only required fields are kept.
+type Info struct {
+	symbols map[string]*Symbol
+	Symbols []Symbol `json:"symbols"`
+}
+
+//nolint:tagliatelle // explanation: binance naming
+type Symbol struct {
+	Symbol     string   `json:"symbol"`
+	Status     string   `json:"status"`
+	BaseAsset  string   `json:"baseAsset"`
+	QuoteAsset string   `json:"quoteAsset"`
+	Filters    []Filter `json:"filters"`
+}
+
+//nolint:tagliatelle // explanation: binance naming
+type Filter struct {
+	FilterType string `json:"filterType"`
+	TickSize   string `json:"tickSize,omitempty"`
+	StepSize   string `json:"stepSize,omitempty"`
+}
+
+// Filters contains cached PRICE_FILTER and LOT_SIZE values of the symbol.
+type Filters struct {
+	TickSize string
+	StepSize string
+}
+
+// Fetch downloads exchangeInfo snapshot.
+func Fetch(ctx context.Context, client *http.Client, url string) (*Info, error) {
+	var info Info
+
+	if err := get(ctx, client, url, &info); err != nil {
+		return nil, err
+	}
+
+	info.index()
+
+	return &info, nil
+}
+
+// get requests url and decodes JSON response into v.
+func get(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return NewError(err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return NewError(err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return NewError(fmt.Errorf("%s: unexpected status %s", url, resp.Status))
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return NewError(fmt.Errorf("failed to decode %s: %w", url, err))
+	}
+
+	return nil
+}
+
+// LoadFile reads exchangeInfo snapshot saved to a local file.
+func LoadFile(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	defer f.Close()
+
+	return decode(f)
+}
+
+func decode(r io.Reader) (*Info, error) {
+	var info Info
+
+	if err := json.NewDecoder(r).Decode(&info); err != nil {
+		return nil, NewError(fmt.Errorf("failed to decode exchangeInfo: %w", err))
+	}
+
+	info.index()
+
+	return &info, nil
+}
+
+func (i *Info) index() {
+	i.symbols = make(map[string]*Symbol, len(i.Symbols))
+
+	for k := range i.Symbols {
+		i.symbols[i.Symbols[k].Symbol] = &i.Symbols[k]
+	}
+}
+
+// Symbol looks up symbol case-insensitively.
+func (i *Info) Symbol(symbol string) (*Symbol, bool) {
+	symbol = strings.ToUpper(symbol)
+
+	if i.symbols == nil {
+		for k := range i.Symbols {
+			if i.Symbols[k].Symbol == symbol {
+				return &i.Symbols[k], true
+			}
+		}
+
+		return nil, false
+	}
+
+	s, ok := i.symbols[symbol]
+
+	return s, ok
+}
+
+// Validate checks that every stream refers to a known symbol in TRADING status.
+func (i *Info) Validate(streams []string) error {
+	var errs []error
+
+	for _, stream := range streams {
+		symbol, _, _ := strings.Cut(stream, "@")
+
+		s, ok := i.Symbol(symbol)
+
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("%s: unknown symbol %q", stream, strings.ToUpper(symbol)))
+		case s.Status != StatusTrading:
+			errs = append(errs, fmt.Errorf("%s: symbol %s is not trading (status %s)", stream, s.Symbol, s.Status))
+		}
+	}
+
+	return NewError(errors.Join(errs...))
+}
+
+// Filters returns cached tick size and lot size of the symbol.
+func (i *Info) Filters(symbol string) (Filters, bool) {
+	s, ok := i.Symbol(symbol)
+	if !ok {
+		return Filters{}, false
+	}
+
+	var f Filters
+
+	for _, filter := range s.Filters {
+		switch filter.FilterType {
+		case "PRICE_FILTER":
+			f.TickSize = filter.TickSize
+		case "LOT_SIZE":
+			f.StepSize = filter.StepSize
+		}
+	}
+
+	return f, true
+}
+
+// FormatPrice trims price to the precision of the symbol tick size.
+// Unknown symbols are returned as is.
+func (i *Info) FormatPrice(symbol, price string) string {
+	f, ok := i.Filters(symbol)
+	if !ok || f.TickSize == "" {
+		return price
+	}
+
+	return format(price, precision(f.TickSize))
+}
+
+// FormatQty trims quantity to the precision of the symbol lot size.
+func (i *Info) FormatQty(symbol, qty string) string {
+	f, ok := i.Filters(symbol)
+	if !ok || f.StepSize == "" {
+		return qty
+	}
+
+	return format(qty, precision(f.StepSize))
+}
+
+// precision returns number of meaningful decimals of a step like "0.01000000".
+func precision(step string) int {
+	_, frac, ok := strings.Cut(step, ".")
+	if !ok {
+		return 0
+	}
+
+	return len(strings.TrimRight(frac, "0"))
+}
+
+// format truncates or pads decimal string to the given number of decimals.
+// Strings are used to keep exchange precision without float rounding.
+func format(value string, decimals int) string {
+	whole, frac, _ := strings.Cut(value, ".")
+
+	if decimals == 0 {
+		return whole
+	}
+
+	if len(frac) > decimals {
+		frac = frac[:decimals]
+	} else {
+		frac += strings.Repeat("0", decimals-len(frac))
+	}
+
+	return whole + "." + frac
+}
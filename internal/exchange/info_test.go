@@ -0,0 +1,95 @@
+package exchange_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/exchange"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testdata = "testdata/exchangeInfo.json"
+
+func TestLoadFile(t *testing.T) {
+	info, err := exchange.LoadFile(testdata)
+	require.NoError(t, err)
+	require.Len(t, info.Symbols, 4)
+
+	s, ok := info.Symbol("btcusdt")
+	require.True(t, ok)
+	assert.Equal(t, "USDT", s.QuoteAsset)
+
+	_, err = exchange.LoadFile("testdata/missing.json")
+	require.Error(t, err)
+}
+
+func TestFetch(t *testing.T) {
+	data, err := os.ReadFile(testdata)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/exchangeInfo" {
+			http.NotFound(rw, r)
+			return
+		}
+
+		_, _ = rw.Write(data)
+	}))
+	defer ts.Close()
+
+	info, err := exchange.Fetch(context.Background(), ts.Client(), ts.URL+"/api/v3/exchangeInfo")
+	require.NoError(t, err)
+	assert.Len(t, info.Symbols, 4)
+
+	_, err = exchange.Fetch(context.Background(), ts.Client(), ts.URL+"/wrong")
+	assert.ErrorContains(t, err, "unexpected status 404")
+}
+
+func TestInfo_Validate(t *testing.T) {
+	info, err := exchange.LoadFile(testdata)
+	require.NoError(t, err)
+
+	require.NoError(t, info.Validate([]string{"btcusdt@depth", "ethusdt@bookTicker"}))
+
+	err = info.Validate([]string{"btcusdt@depth", "btcusd@depth", "lunausdt@depth"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `btcusd@depth: unknown symbol "BTCUSD"`)
+	assert.Contains(t, err.Error(), "lunausdt@depth: symbol LUNAUSDT is not trading (status BREAK)")
+}
+
+func TestInfo_Format(t *testing.T) {
+	info, err := exchange.LoadFile(testdata)
+	require.NoError(t, err)
+
+	f, ok := info.Filters("BTCUSDT")
+	require.True(t, ok)
+	assert.Equal(t, exchange.Filters{TickSize: "0.01000000", StepSize: "0.00001000"}, f)
+
+	tests := []struct {
+		name   string
+		symbol string
+		value  string
+		want   string
+		qty    bool
+	}{
+		{name: "trim price", symbol: "BTCUSDT", value: "97000.01000000", want: "97000.01"},
+		{name: "pad price", symbol: "BTCUSDT", value: "97000", want: "97000.00"},
+		{name: "small tick", symbol: "ETHBTC", value: "0.03512000", want: "0.03512"},
+		{name: "qty", symbol: "ETHUSDT", value: "1.23456789", want: "1.2345", qty: true},
+		{name: "unknown symbol", symbol: "XXXUSDT", value: "1.00000000", want: "1.00000000"},
+		{name: "no filters", symbol: "LUNAUSDT", value: "1.00000000", want: "1.00000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.qty {
+				assert.Equal(t, tt.want, info.FormatQty(tt.symbol, tt.value))
+			} else {
+				assert.Equal(t, tt.want, info.FormatPrice(tt.symbol, tt.value))
+			}
+		})
+	}
+}
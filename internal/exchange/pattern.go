@@ -0,0 +1,206 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultPatternStream is used when selector has no @stream suffix.
+const DefaultPatternStream = "bookTicker"
+
+// Pattern is an instrument which expands to several streams:
+// glob over symbols, e.g. "*usdt@bookTicker", or selector, e.g. "quote=USDT,top=50@bookTicker".
+type Pattern struct {
+	Glob   string
+	Quote  string
+	Base   string
+	Stream string
+	Top    int
+}
+
+//nolint:tagliatelle // explanation: binance naming
+type Ticker struct {
+	Symbol      string `json:"symbol"`
+	QuoteVolume string `json:"quoteVolume"`
+}
+
+// IsPattern reports whether instrument has to be expanded.
+func IsPattern(instrument string) bool {
+	return strings.ContainsAny(instrument, "*?[=")
+}
+
+// ParsePattern parses glob or selector instrument.
+func ParsePattern(instrument string) (*Pattern, error) {
+	spec, stream, ok := strings.Cut(instrument, "@")
+	if ok && stream == "" {
+		return nil, NewError(fmt.Errorf("%q: empty stream", instrument))
+	}
+
+	if !strings.Contains(spec, "=") {
+		if !ok {
+			return nil, NewError(fmt.Errorf("%q must look like <glob>@<stream>", instrument))
+		}
+
+		if _, err := path.Match(spec, ""); err != nil {
+			return nil, NewError(fmt.Errorf("%q: %w", instrument, err))
+		}
+
+		return &Pattern{Glob: strings.ToUpper(spec), Stream: stream}, nil
+	}
+
+	if !ok {
+		stream = DefaultPatternStream
+	}
+
+	p := &Pattern{Stream: stream}
+
+	for _, kv := range strings.Split(spec, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(kv), "=")
+		if !found || value == "" {
+			return nil, NewError(fmt.Errorf("%q: expected key=value, got %q", instrument, kv))
+		}
+
+		switch key {
+		case "quote":
+			p.Quote = strings.ToUpper(value)
+		case "base":
+			p.Base = strings.ToUpper(value)
+		case "top":
+			top, err := strconv.Atoi(value)
+			if err != nil || top < 1 {
+				return nil, NewError(fmt.Errorf("%q: top must be a positive number", instrument))
+			}
+
+			p.Top = top
+		default:
+			return nil, NewError(fmt.Errorf("%q: unknown selector key %q (supported: quote, base, top)", instrument, key))
+		}
+	}
+
+	return p, nil
+}
+
+// Match reports whether trading symbol satisfies the pattern.
+func (p *Pattern) Match(s *Symbol) bool {
+	if s.Status != StatusTrading {
+		return false
+	}
+
+	if p.Glob != "" {
+		ok, err := path.Match(p.Glob, s.Symbol)
+		return err == nil && ok
+	}
+
+	return (p.Quote == "" || p.Quote == s.QuoteAsset) && (p.Base == "" || p.Base == s.BaseAsset)
+}
+
+// NeedsTickers reports whether any instrument ranks symbols by 24h volume.
+func NeedsTickers(instruments []string) bool {
+	for _, instrument := range instruments {
+		if !IsPattern(instrument) {
+			continue
+		}
+
+		if p, err := ParsePattern(instrument); err == nil && p.Top > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Expand replaces patterns with matching streams. Plain instruments are kept as is,
+// duplicates are removed with the first occurrence order preserved.
+func (i *Info) Expand(instruments []string, tickers []Ticker) ([]string, error) {
+	var (
+		errs     []error
+		expanded = make([]string, 0, len(instruments))
+		seen     = make(map[string]struct{}, len(instruments))
+	)
+
+	add := func(stream string) {
+		if _, ok := seen[stream]; !ok {
+			seen[stream] = struct{}{}
+			expanded = append(expanded, stream)
+		}
+	}
+
+	volumes := make(map[string]float64, len(tickers))
+
+	for _, t := range tickers {
+		volume, err := strconv.ParseFloat(t.QuoteVolume, 64)
+		if err == nil {
+			volumes[t.Symbol] = volume
+		}
+	}
+
+	for _, instrument := range instruments {
+		if !IsPattern(instrument) {
+			add(instrument)
+			continue
+		}
+
+		p, err := ParsePattern(instrument)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if p.Top > 0 && tickers == nil {
+			errs = append(errs, fmt.Errorf("%q: top requires 24h ticker data", instrument))
+			continue
+		}
+
+		var symbols []string
+
+		for k := range i.Symbols {
+			if p.Match(&i.Symbols[k]) {
+				symbols = append(symbols, i.Symbols[k].Symbol)
+			}
+		}
+
+		if p.Top > 0 {
+			sort.SliceStable(symbols, func(a, b int) bool {
+				return volumes[symbols[a]] > volumes[symbols[b]]
+			})
+
+			if len(symbols) > p.Top {
+				symbols = symbols[:p.Top]
+			}
+		} else {
+			sort.Strings(symbols)
+		}
+
+		if len(symbols) == 0 {
+			errs = append(errs, fmt.Errorf("%q: no trading symbols match", instrument))
+			continue
+		}
+
+		for _, symbol := range symbols {
+			add(strings.ToLower(symbol) + "@" + p.Stream)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, NewError(err)
+	}
+
+	return expanded, nil
+}
+
+// FetchTickers downloads 24h ticker statistics used to rank symbols by quote volume.
+func FetchTickers(ctx context.Context, client *http.Client, url string) ([]Ticker, error) {
+	var tickers []Ticker
+
+	if err := get(ctx, client, url, &tickers); err != nil {
+		return nil, err
+	}
+
+	return tickers, nil
+}
@@ -0,0 +1,138 @@
+package exchange_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/exchange"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		want       *exchange.Pattern
+		name       string
+		instrument string
+		errMsg     string
+	}{
+		{
+			name:       "glob",
+			instrument: "*usdt@bookTicker",
+			want:       &exchange.Pattern{Glob: "*USDT", Stream: "bookTicker"},
+		},
+		{
+			name:       "selector with default stream",
+			instrument: "quote=USDT,top=50",
+			want:       &exchange.Pattern{Quote: "USDT", Top: 50, Stream: "bookTicker"},
+		},
+		{
+			name:       "selector with stream",
+			instrument: "base=eth,quote=btc@depth",
+			want:       &exchange.Pattern{Base: "ETH", Quote: "BTC", Stream: "depth"},
+		},
+		{name: "glob without stream", instrument: "*usdt", errMsg: "must look like <glob>@<stream>"},
+		{name: "bad glob", instrument: "[usdt@depth", errMsg: "syntax error in pattern"},
+		{name: "bad top", instrument: "quote=USDT,top=many", errMsg: "top must be a positive number"},
+		{name: "unknown key", instrument: "venue=binance", errMsg: `unknown selector key "venue"`},
+		{name: "empty stream", instrument: "quote=USDT@", errMsg: "empty stream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, exchange.IsPattern(tt.instrument))
+
+			p, err := exchange.ParsePattern(tt.instrument)
+			if tt.errMsg != "" {
+				assert.ErrorContains(t, err, tt.errMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p)
+		})
+	}
+
+	assert.False(t, exchange.IsPattern("btcusdt@depth"))
+}
+
+func TestInfo_Expand(t *testing.T) {
+	info, err := exchange.LoadFile(testdata)
+	require.NoError(t, err)
+
+	tickers := []exchange.Ticker{
+		{Symbol: "BTCUSDT", QuoteVolume: "100"},
+		{Symbol: "ETHUSDT", QuoteVolume: "200"},
+		{Symbol: "ETHBTC", QuoteVolume: "1"},
+	}
+
+	tests := []struct {
+		name        string
+		errMsg      string
+		instruments []string
+		tickers     []exchange.Ticker
+		want        []string
+	}{
+		{
+			name:        "glob skips non trading symbols",
+			instruments: []string{"*usdt@bookTicker"},
+			want:        []string{"btcusdt@bookTicker", "ethusdt@bookTicker"},
+		},
+		{
+			name:        "plain instruments are kept and deduplicated",
+			instruments: []string{"ethusdt@bookTicker", "*usdt@bookTicker", "ethbtc@depth"},
+			want:        []string{"ethusdt@bookTicker", "btcusdt@bookTicker", "ethbtc@depth"},
+		},
+		{
+			name:        "top by quote volume",
+			instruments: []string{"quote=USDT,top=1"},
+			tickers:     tickers,
+			want:        []string{"ethusdt@bookTicker"},
+		},
+		{
+			name:        "base selector",
+			instruments: []string{"base=ETH@depth"},
+			want:        []string{"ethbtc@depth", "ethusdt@depth"},
+		},
+		{
+			name:        "top without tickers",
+			instruments: []string{"quote=USDT,top=1"},
+			errMsg:      "top requires 24h ticker data",
+		},
+		{
+			name:        "nothing matches",
+			instruments: []string{"*eur@depth"},
+			errMsg:      "no trading symbols match",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := info.Expand(tt.instruments, tt.tickers)
+			if tt.errMsg != "" {
+				assert.ErrorContains(t, err, tt.errMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	assert.True(t, exchange.NeedsTickers([]string{"btcusdt@depth", "quote=USDT,top=5"}))
+	assert.False(t, exchange.NeedsTickers([]string{"*usdt@depth"}))
+}
+
+func TestFetchTickers(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(rw).Encode([]map[string]string{
+			{"symbol": "BTCUSDT", "quoteVolume": "123.45"},
+		})
+	}))
+	defer ts.Close()
+
+	tickers, err := exchange.FetchTickers(context.Background(), ts.Client(), ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []exchange.Ticker{{Symbol: "BTCUSDT", QuoteVolume: "123.45"}}, tickers)
+}
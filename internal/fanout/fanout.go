@@ -0,0 +1,104 @@
+// Package fanout publishes events to subscribers without blocking publishers.
+package fanout
+
+import (
+	"sync"
+)
+
+// DefaultBuffer is the number of events queued per subscriber before they are dropped.
+const DefaultBuffer = 1024
+
+// Subscriber receives events of its symbols published after Subscribe.
+type Subscriber[T any] struct {
+	events  chan T
+	symbols map[string]struct{}
+	dropped uint64
+}
+
+// Events returns channel of events, it is closed on Unsubscribe.
+func (s *Subscriber[T]) Events() <-chan T {
+	return s.events
+}
+
+func (s *Subscriber[T]) match(symbol string) bool {
+	if len(s.symbols) == 0 {
+		return true
+	}
+
+	_, ok := s.symbols[symbol]
+
+	return ok
+}
+
+// Fanout sends published events to subscribers, events are dropped for subscribers
+// that do not keep up.
+type Fanout[T any] struct {
+	subs map[*Subscriber[T]]struct{}
+	mx   sync.Mutex
+}
+
+func New[T any]() *Fanout[T] {
+	return &Fanout[T]{subs: make(map[*Subscriber[T]]struct{})}
+}
+
+// Subscribe registers subscriber of events of symbols, every symbol if empty.
+func (f *Fanout[T]) Subscribe(symbols []string, buffer int) *Subscriber[T] {
+	if buffer < 1 {
+		buffer = DefaultBuffer
+	}
+
+	sub := &Subscriber[T]{
+		events:  make(chan T, buffer),
+		symbols: make(map[string]struct{}, len(symbols)),
+	}
+
+	for _, symbol := range symbols {
+		sub.symbols[symbol] = struct{}{}
+	}
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	f.subs[sub] = struct{}{}
+
+	return sub
+}
+
+// Unsubscribe stops publishing to subscriber and closes its events channel.
+func (f *Fanout[T]) Unsubscribe(sub *Subscriber[T]) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	if _, ok := f.subs[sub]; !ok {
+		return
+	}
+
+	delete(f.subs, sub)
+	close(sub.events)
+}
+
+// Dropped returns number of events dropped for subscriber.
+func (f *Fanout[T]) Dropped(sub *Subscriber[T]) uint64 {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	return sub.dropped
+}
+
+// Publish sends event of symbol to its subscribers without blocking.
+func (f *Fanout[T]) Publish(symbol string, event T) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	for s := range f.subs {
+		if !s.match(symbol) {
+			continue
+		}
+
+		select {
+		case s.events <- event:
+		default:
+			s.dropped++
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package fanout_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ole-larsen/binance-subscriber/internal/fanout"
+)
+
+func TestFanout(t *testing.T) {
+	f := fanout.New[int]()
+
+	all := f.Subscribe(nil, 1)
+	btc := f.Subscribe([]string{"BTCUSDT"}, 0)
+
+	f.Publish("ETHUSDT", 1)
+	f.Publish("BTCUSDT", 2)
+
+	// buffer of all is full, the second event is dropped
+	assert.Equal(t, 1, <-all.Events())
+	assert.Equal(t, uint64(1), f.Dropped(all))
+
+	assert.Equal(t, 2, <-btc.Events())
+	assert.Equal(t, uint64(0), f.Dropped(btc))
+
+	f.Unsubscribe(btc)
+	f.Unsubscribe(btc)
+
+	_, ok := <-btc.Events()
+	assert.False(t, ok)
+
+	// unsubscribed clients get nothing
+	assert.NotPanics(t, func() {
+		f.Publish("BTCUSDT", 3)
+	})
+}
@@ -0,0 +1,126 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/ole-larsen/binance-subscriber/internal/auth"
+	"github.com/ole-larsen/binance-subscriber/internal/grpcserver/quotesv1"
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+)
+
+// servicePrefix selects calls that require authentication.
+var servicePrefix = "/" + quotesv1.QuoteService_ServiceDesc.ServiceName + "/"
+
+func (s *Server) unaryAuth(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// unaryLimit rejects QuoteService calls of clients that exhausted their token bucket, it runs
+// after unaryAuth so clients are identified by subject.
+func (s *Server) unaryLimit(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if !strings.HasPrefix(info.FullMethod, servicePrefix) {
+		return handler(ctx, req)
+	}
+
+	if ok, retryAfter := s.limiter.Allow(peerKey(ctx), time.Now()); !ok {
+		metrics.RateLimited.WithLabelValues("requests").Inc()
+		return nil, status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %s", retryAfter)
+	}
+
+	return handler(ctx, req)
+}
+
+func (s *Server) streamAuth(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate stores identity of QuoteService call in context. Credentials are read from
+// "authorization: Bearer" or "x-api-key" metadata, without them a verified TLS client
+// certificate mapped in the keys file is accepted. Every method requires quotes:read scope,
+// ManageSubscriptions only filters symbols of its own stream.
+func (s *Server) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if !strings.HasPrefix(method, servicePrefix) {
+		return ctx, nil
+	}
+
+	id, err := s.auth.Verify(credential(ctx), certificate(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+
+	if !id.HasScope(auth.ScopeQuotesRead) {
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	return auth.WithIdentity(ctx, id), nil
+}
+
+func credential(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	for _, header := range md.Get("authorization") {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+
+	if keys := md.Get("x-api-key"); len(keys) > 0 {
+		return keys[0]
+	}
+
+	return ""
+}
+
+func certificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	return info.State.VerifiedChains[0][0]
+}
+
+// serverStream replaces context of the stream with authenticated one.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context //nolint:containedctx // explanation: grpc.ServerStream exposes context by method
+}
+
+func (ss *serverStream) Context() context.Context {
+	return ss.ctx
+}
@@ -0,0 +1,22 @@
+package grpcserver_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/grpcserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	require.NoError(t, grpcserver.NewError(nil))
+
+	stdErr := errors.New("something went wrong")
+	err := grpcserver.NewError(stdErr)
+
+	var grpcserverErr *grpcserver.Error
+	require.True(t, errors.As(err, &grpcserverErr))
+	assert.Equal(t, "[grpcserver]: something went wrong", grpcserverErr.Error())
+	assert.True(t, errors.Is(err, stdErr))
+}
@@ -0,0 +1,21 @@
+package quotesv1
+
+import (
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// NewQuote converts stored quote.
+func NewQuote(d *storage.Data) *Quote {
+	return &Quote{Symbol: d.Symbol, Bid: d.Bid, Ask: d.Ask, Synthetic: d.Synthetic, Stale: d.Stale}
+}
+
+// Data converts quote to stored one.
+func (x *Quote) Data() *storage.Data {
+	return &storage.Data{
+		Symbol:    x.GetSymbol(),
+		Bid:       x.GetBid(),
+		Ask:       x.GetAsk(),
+		Synthetic: x.GetSynthetic(),
+		Stale:     x.GetStale(),
+	}
+}
@@ -0,0 +1,167 @@
+// Package grpcserver serves quotes over gRPC with reflection and health services
+// on its own listener.
+package grpcserver
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/ole-larsen/binance-subscriber/internal/auth"
+	"github.com/ole-larsen/binance-subscriber/internal/grpcserver/quotesv1"
+	"github.com/ole-larsen/binance-subscriber/internal/httpserver"
+	"github.com/ole-larsen/binance-subscriber/internal/hub"
+	"github.com/ole-larsen/binance-subscriber/internal/ratelimit"
+)
+
+// stopTimeout bounds waiting for running streams on Stop, they are closed then.
+const stopTimeout = 5 * time.Second
+
+var ErrStopped = NewError(errors.New("server is stopped"))
+
+// Server is gRPC server of QuoteService backed by the same hub as /ws.
+type Server struct {
+	server  *grpc.Server
+	health  *health.Server
+	hub     *hub.Hub
+	auth    *auth.Authenticator
+	quota   *ratelimit.Quota
+	limiter *ratelimit.Limiter
+	TLS     *httpserver.Certificates
+	Address string
+	stopped bool
+	mx      sync.Mutex
+}
+
+func NewServer() *Server {
+	return &Server{}
+}
+
+func (s *Server) SetAddress(address string) *Server {
+	s.Address = address
+	return s
+}
+
+func (s *Server) SetHub(h *hub.Hub) *Server {
+	s.hub = h
+	return s
+}
+
+// SetAuthenticator sets authenticator of QuoteService calls, health and reflection
+// services are not authenticated. Nil disables authentication.
+func (s *Server) SetAuthenticator(a *auth.Authenticator) *Server {
+	s.auth = a
+	return s
+}
+
+// SetQuota limits concurrent streams together with websocket connections, nil disables it.
+func (s *Server) SetQuota(q *ratelimit.Quota) *Server {
+	s.quota = q
+	return s
+}
+
+// SetRateLimiter limits unary calls per client together with HTTP requests, nil disables it.
+func (s *Server) SetRateLimiter(l *ratelimit.Limiter) *Server {
+	s.limiter = l
+	return s
+}
+
+// SetTLS enables TLS, nil serves plaintext.
+func (s *Server) SetTLS(c *httpserver.Certificates) *Server {
+	s.TLS = c
+	return s
+}
+
+func (s *Server) GetAddress() string {
+	return s.Address
+}
+
+// ListenAndServe listens on Address and serves until Stop.
+func (s *Server) ListenAndServe() error {
+	lis, err := net.Listen("tcp", s.Address)
+	if err != nil {
+		return NewError(err)
+	}
+
+	return s.Serve(lis)
+}
+
+// Serve serves connections accepted by lis until Stop.
+func (s *Server) Serve(lis net.Listener) error {
+	s.mx.Lock()
+
+	if s.stopped {
+		s.mx.Unlock()
+		lis.Close()
+
+		return ErrStopped
+	}
+
+	if s.server == nil {
+		s.server = s.newServer()
+	}
+
+	server := s.server
+
+	s.mx.Unlock()
+
+	return NewError(server.Serve(lis))
+}
+
+// Stop reports not serving health status and waits for running calls to finish,
+// streams still running after stopTimeout are closed.
+func (s *Server) Stop() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.stopped = true
+
+	if s.server == nil {
+		return
+	}
+
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(stopTimeout):
+		s.server.Stop()
+	}
+}
+
+func (s *Server) newServer() *grpc.Server {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.unaryAuth, s.unaryLimit),
+		grpc.ChainStreamInterceptor(s.streamAuth),
+	}
+
+	if s.TLS != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.TLS.ConfigWithProtos("h2"))))
+	}
+
+	server := grpc.NewServer(opts...)
+
+	quotesv1.RegisterQuoteServiceServer(server, &service{hub: s.hub, quota: s.quota})
+
+	s.health = health.NewServer()
+	s.health.SetServingStatus(quotesv1.QuoteService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, s.health)
+
+	reflection.Register(server)
+
+	return server
+}
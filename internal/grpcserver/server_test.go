@@ -0,0 +1,338 @@
+package grpcserver_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/ole-larsen/binance-subscriber/internal/auth"
+	"github.com/ole-larsen/binance-subscriber/internal/grpcserver"
+	"github.com/ole-larsen/binance-subscriber/internal/grpcserver/quotesv1"
+	"github.com/ole-larsen/binance-subscriber/internal/hub"
+	"github.com/ole-larsen/binance-subscriber/internal/ratelimit"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// start serves s over in-memory listener and returns connected client.
+func start(t *testing.T, s *grpcserver.Server) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+
+	go func() {
+		_ = s.Serve(lis)
+	}()
+
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func newHub() *hub.Hub {
+	store := storage.NewMemStorage()
+	store.Set(storage.Data{Symbol: "BTCUSDT", Bid: "1", Ask: "2"})
+	store.Set(storage.Data{Symbol: "ETHUSDT", Bid: "3", Ask: "4"})
+
+	return hub.New(store)
+}
+
+func TestServer_Quotes(t *testing.T) {
+	client := quotesv1.NewQuoteServiceClient(start(t, grpcserver.NewServer().SetHub(newHub())))
+	ctx := context.Background()
+
+	q, err := client.GetQuote(ctx, &quotesv1.GetQuoteRequest{Symbol: "btcusdt"})
+	require.NoError(t, err)
+	assert.Equal(t, "1", q.GetBid())
+	assert.Equal(t, "2", q.GetAsk())
+
+	_, err = client.GetQuote(ctx, &quotesv1.GetQuoteRequest{Symbol: "XRPUSDT"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.GetQuote(ctx, &quotesv1.GetQuoteRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.GetQuote(ctx, &quotesv1.GetQuoteRequest{Symbol: "BTC-USDT"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	list, err := client.ListQuotes(ctx, &quotesv1.ListQuotesRequest{})
+	require.NoError(t, err)
+	assert.Len(t, list.GetQuotes(), 2)
+
+	list, err = client.ListQuotes(ctx, &quotesv1.ListQuotesRequest{Symbols: []string{"ETHUSDT", "XRPUSDT"}})
+	require.NoError(t, err)
+	require.Len(t, list.GetQuotes(), 1)
+	assert.Equal(t, "ETHUSDT", list.GetQuotes()[0].GetSymbol())
+}
+
+func TestServer_SyntheticQuotes(t *testing.T) {
+	h := newHub()
+	h.Set(storage.Data{Symbol: "ETH_BTC", Bid: "0.03", Ask: "0.04", Synthetic: true})
+
+	client := quotesv1.NewQuoteServiceClient(start(t, grpcserver.NewServer().SetHub(h)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	q, err := client.GetQuote(ctx, &quotesv1.GetQuoteRequest{Symbol: "eth_btc"})
+	require.NoError(t, err)
+	assert.Equal(t, "0.03", q.GetBid())
+	assert.True(t, q.GetSynthetic())
+
+	list, err := client.ListQuotes(ctx, &quotesv1.ListQuotesRequest{Symbols: []string{"ETH_BTC"}})
+	require.NoError(t, err)
+	assert.Len(t, list.GetQuotes(), 1)
+
+	stream, err := client.StreamQuotes(ctx, &quotesv1.StreamQuotesRequest{Symbols: []string{"ETH_BTC"}})
+	require.NoError(t, err)
+
+	update, err := stream.Recv()
+	require.NoError(t, err)
+	require.Len(t, update.GetQuotes(), 1)
+	assert.Equal(t, "ETH_BTC", update.GetQuotes()[0].GetSymbol())
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	conn := start(t, grpcserver.NewServer().
+		SetHub(newHub()).
+		SetRateLimiter(ratelimit.NewLimiter(1, 2)))
+	client := quotesv1.NewQuoteServiceClient(conn)
+	ctx := context.Background()
+
+	for range 2 {
+		_, err := client.ListQuotes(ctx, &quotesv1.ListQuotesRequest{})
+		require.NoError(t, err)
+	}
+
+	_, err := client.GetQuote(ctx, &quotesv1.GetQuoteRequest{Symbol: "BTCUSDT"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// health checks are not limited
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+}
+
+func TestServer_StreamQuotes(t *testing.T) {
+	h := newHub()
+	client := quotesv1.NewQuoteServiceClient(start(t, grpcserver.NewServer().SetHub(h)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamQuotes(ctx, &quotesv1.StreamQuotesRequest{Symbols: []string{"ethusdt"}})
+	require.NoError(t, err)
+
+	update, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, quotesv1.QuoteUpdate_TYPE_SNAPSHOT, update.GetType())
+	require.Len(t, update.GetQuotes(), 1)
+	assert.Equal(t, "ETHUSDT", update.GetQuotes()[0].GetSymbol())
+
+	h.Set(storage.Data{Symbol: "BTCUSDT", Bid: "5", Ask: "6"})
+	h.Set(storage.Data{Symbol: "ETHUSDT", Bid: "7", Ask: "8"})
+
+	update, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, quotesv1.QuoteUpdate_TYPE_DELTA, update.GetType())
+	assert.Equal(t, uint64(2), update.GetSeq())
+	require.Len(t, update.GetQuotes(), 1)
+	assert.Equal(t, "7", update.GetQuotes()[0].GetBid())
+
+	stream, err = client.StreamQuotes(ctx, &quotesv1.StreamQuotesRequest{Symbols: []string{"?"}})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_ManageSubscriptions(t *testing.T) {
+	h := newHub()
+	client := quotesv1.NewQuoteServiceClient(start(t, grpcserver.NewServer().SetHub(h)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.ManageSubscriptions(ctx)
+	require.NoError(t, err)
+
+	request := func(action quotesv1.SubscriptionRequest_Action, symbols ...string) {
+		require.NoError(t, stream.Send(&quotesv1.SubscriptionRequest{Action: action, Symbols: symbols}))
+	}
+
+	recv := func() *quotesv1.QuoteUpdate {
+		update, err := stream.Recv()
+		require.NoError(t, err)
+
+		return update
+	}
+
+	request(quotesv1.SubscriptionRequest_ACTION_SUBSCRIBE, "BTCUSDT")
+
+	update := recv()
+	assert.Equal(t, quotesv1.QuoteUpdate_TYPE_SNAPSHOT, update.GetType())
+	require.Len(t, update.GetQuotes(), 1)
+	assert.Equal(t, "BTCUSDT", update.GetQuotes()[0].GetSymbol())
+
+	h.Set(storage.Data{Symbol: "ETHUSDT", Bid: "5", Ask: "6"})
+	h.Set(storage.Data{Symbol: "BTCUSDT", Bid: "7", Ask: "8"})
+
+	update = recv()
+	assert.Equal(t, quotesv1.QuoteUpdate_TYPE_DELTA, update.GetType())
+	assert.Equal(t, "7", update.GetQuotes()[0].GetBid())
+
+	request(quotesv1.SubscriptionRequest_ACTION_SUBSCRIBE, "ethusdt")
+
+	update = recv()
+	assert.Equal(t, quotesv1.QuoteUpdate_TYPE_SNAPSHOT, update.GetType())
+	require.Len(t, update.GetQuotes(), 1)
+	assert.Equal(t, "5", update.GetQuotes()[0].GetBid())
+
+	request(quotesv1.SubscriptionRequest_ACTION_UNSUBSCRIBE, "BTCUSDT")
+
+	// unsubscribe is not answered, snapshot of XRPUSDT marks it is processed
+	request(quotesv1.SubscriptionRequest_ACTION_SUBSCRIBE, "XRPUSDT")
+	assert.Empty(t, recv().GetQuotes())
+
+	h.Set(storage.Data{Symbol: "BTCUSDT", Bid: "9", Ask: "10"})
+	h.Set(storage.Data{Symbol: "ETHUSDT", Bid: "11", Ask: "12"})
+
+	update = recv()
+	assert.Equal(t, "ETHUSDT", update.GetQuotes()[0].GetSymbol())
+	assert.Equal(t, "11", update.GetQuotes()[0].GetBid())
+
+	request(quotesv1.SubscriptionRequest_ACTION_UNSPECIFIED, "BTCUSDT")
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, 0, h.Len())
+}
+
+func TestServer_HealthAndReflection(t *testing.T) {
+	conn := start(t, grpcserver.NewServer().SetHub(newHub()))
+	ctx := context.Background()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: quotesv1.QuoteService_ServiceDesc.ServiceName,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+
+	info, err := stream.Recv()
+	require.NoError(t, err)
+
+	var services []string
+	for _, s := range info.GetListServicesResponse().GetService() {
+		services = append(services, s.GetName())
+	}
+
+	assert.Contains(t, services, quotesv1.QuoteService_ServiceDesc.ServiceName)
+	assert.Contains(t, services, healthpb.Health_ServiceDesc.ServiceName)
+}
+
+func TestServer_Auth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+keys:
+  - id: reader
+    key: reader-key
+    scopes: [quotes:read]
+  - id: manager
+    key: manager-key
+    scopes: [subscriptions:manage]
+`), 0o600))
+
+	a, err := auth.New(auth.Options{KeysFile: path})
+	require.NoError(t, err)
+
+	conn := start(t, grpcserver.NewServer().
+		SetHub(newHub()).
+		SetAuthenticator(a).
+		SetQuota(ratelimit.NewQuota(0, 1)))
+	client := quotesv1.NewQuoteServiceClient(conn)
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		code codes.Code
+	}{
+		{name: "no credentials", code: codes.Unauthenticated},
+		{name: "invalid key", md: metadata.Pairs("x-api-key", "nope"), code: codes.Unauthenticated},
+		{name: "missing scope", md: metadata.Pairs("authorization", "Bearer manager-key"), code: codes.PermissionDenied},
+		{name: "api key", md: metadata.Pairs("x-api-key", "reader-key"), code: codes.OK},
+		{name: "bearer", md: metadata.Pairs("authorization", "Bearer reader-key"), code: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewOutgoingContext(context.Background(), tt.md)
+
+			_, err := client.ListQuotes(ctx, &quotesv1.ListQuotesRequest{})
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+
+	// changing symbols of the own stream requires quotes:read only
+	for key, code := range map[string]codes.Code{"manager-key": codes.PermissionDenied, "reader-key": codes.OK} {
+		ctx, cancel := context.WithTimeout(metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key), 5*time.Second)
+
+		stream, err := client.ManageSubscriptions(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&quotesv1.SubscriptionRequest{
+			Action:  quotesv1.SubscriptionRequest_ACTION_SUBSCRIBE,
+			Symbols: []string{"BTCUSDT"},
+		}))
+
+		_, err = stream.Recv()
+		assert.Equal(t, code, status.Code(err), key)
+
+		cancel()
+	}
+
+	// health checks are not authenticated
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	// the second stream of the same client is over quota
+	ctx, cancel := context.WithTimeout(metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "reader-key"), 5*time.Second)
+	defer cancel()
+
+	first, err := client.StreamQuotes(ctx, &quotesv1.StreamQuotesRequest{})
+	require.NoError(t, err)
+
+	_, err = first.Recv()
+	require.NoError(t, err)
+
+	second, err := client.StreamQuotes(ctx, &quotesv1.StreamQuotesRequest{})
+	require.NoError(t, err)
+
+	_, err = second.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
@@ -0,0 +1,244 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/ole-larsen/binance-subscriber/internal/grpcserver/quotesv1"
+	"github.com/ole-larsen/binance-subscriber/internal/hub"
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+	"github.com/ole-larsen/binance-subscriber/internal/ratelimit"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+type service struct {
+	quotesv1.UnimplementedQuoteServiceServer
+	hub   *hub.Hub
+	quota *ratelimit.Quota
+}
+
+func (s *service) GetQuote(_ context.Context, req *quotesv1.GetQuoteRequest) (*quotesv1.Quote, error) {
+	symbols, err := normalize([]string{req.GetSymbol()})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(symbols) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "symbol is required")
+	}
+
+	data := s.hub.Get(symbols[0])
+	if data == nil {
+		return nil, status.Errorf(codes.NotFound, "no quote of %s", symbols[0])
+	}
+
+	return quotesv1.NewQuote(data), nil
+}
+
+func (s *service) ListQuotes(_ context.Context, req *quotesv1.ListQuotesRequest) (*quotesv1.ListQuotesResponse, error) {
+	symbols, err := normalize(req.GetSymbols())
+	if err != nil {
+		return nil, err
+	}
+
+	return &quotesv1.ListQuotesResponse{Quotes: filter(s.hub.GetAll(), set(symbols))}, nil
+}
+
+func (s *service) StreamQuotes(
+	req *quotesv1.StreamQuotesRequest,
+	stream grpc.ServerStreamingServer[quotesv1.QuoteUpdate],
+) error {
+	symbols, err := normalize(req.GetSymbols())
+	if err != nil {
+		return err
+	}
+
+	release, err := s.acquire(stream.Context())
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	sub := newSubscription(s.hub)
+	defer sub.close()
+
+	if err = stream.Send(sub.subscribe(symbols)); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case update, ok := <-sub.updates():
+			if !ok {
+				return nil
+			}
+
+			if msg := sub.next(update); msg != nil {
+				if err = stream.Send(msg); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (s *service) ManageSubscriptions(
+	stream grpc.BidiStreamingServer[quotesv1.SubscriptionRequest, quotesv1.QuoteUpdate],
+) error {
+	release, err := s.acquire(stream.Context())
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	sub := newSubscription(s.hub)
+	defer sub.close()
+
+	requests := make(chan *quotesv1.SubscriptionRequest)
+	done := make(chan error, 1)
+
+	// stream is received here and answered by the loop below
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				done <- err
+				return
+			}
+
+			select {
+			case requests <- req:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case err = <-done:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		case req := <-requests:
+			msg, err := manage(sub, req)
+			if err != nil {
+				return err
+			}
+
+			if msg != nil {
+				if err = stream.Send(msg); err != nil {
+					return err
+				}
+			}
+		case update, ok := <-sub.updates():
+			if !ok {
+				return nil
+			}
+
+			if msg := sub.next(update); msg != nil {
+				if err = stream.Send(msg); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// manage applies subscription request, subscribe returns snapshot of the added symbols.
+func manage(sub *subscription, req *quotesv1.SubscriptionRequest) (*quotesv1.QuoteUpdate, error) {
+	symbols, err := normalize(req.GetSymbols())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(symbols) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "symbols are required")
+	}
+
+	switch req.GetAction() {
+	case quotesv1.SubscriptionRequest_ACTION_SUBSCRIBE:
+		return sub.subscribe(symbols), nil
+	case quotesv1.SubscriptionRequest_ACTION_UNSUBSCRIBE:
+		sub.unsubscribe(symbols)
+		return nil, nil
+	case quotesv1.SubscriptionRequest_ACTION_UNSPECIFIED:
+	}
+
+	return nil, status.Errorf(codes.InvalidArgument, "unknown action %v", req.GetAction())
+}
+
+// acquire takes connection quota of the client.
+func (s *service) acquire(ctx context.Context) (func(), error) {
+	release, err := s.quota.Acquire(peerKey(ctx))
+	if err != nil {
+		metrics.RateLimited.WithLabelValues("connections").Inc()
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	return release, nil
+}
+
+// peerKey identifies client of the call by ratelimit.PeerKey.
+func peerKey(ctx context.Context) string {
+	addr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+	}
+
+	return ratelimit.PeerKey(ctx, addr)
+}
+
+// normalize upper cases symbols and drops empty ones.
+func normalize(symbols []string) ([]string, error) {
+	normalized := make([]string, 0, len(symbols))
+
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" {
+			continue
+		}
+
+		if !storage.ValidSymbol(symbol) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid symbol %q", symbol)
+		}
+
+		normalized = append(normalized, symbol)
+	}
+
+	return normalized, nil
+}
+
+func set(symbols []string) map[string]struct{} {
+	m := make(map[string]struct{}, len(symbols))
+
+	for _, symbol := range symbols {
+		m[symbol] = struct{}{}
+	}
+
+	return m
+}
+
+// filter converts quotes of the symbols, empty set selects all.
+func filter(quotes []*storage.Data, symbols map[string]struct{}) []*quotesv1.Quote {
+	res := make([]*quotesv1.Quote, 0, len(quotes))
+
+	for _, q := range quotes {
+		if _, ok := symbols[q.Symbol]; ok || len(symbols) == 0 {
+			res = append(res, quotesv1.NewQuote(q))
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,127 @@
+package grpcserver
+
+import (
+	"github.com/ole-larsen/binance-subscriber/internal/grpcserver/quotesv1"
+	"github.com/ole-larsen/binance-subscriber/internal/hub"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// subscription filters hub updates by symbol. Every symbol remembers sequence number
+// of its last snapshot, queued updates already included in it are skipped.
+type subscription struct {
+	hub      *hub.Hub
+	sub      *hub.Subscriber
+	symbols  map[string]uint64
+	all      bool
+	since    uint64
+	received uint64
+}
+
+func newSubscription(h *hub.Hub) *subscription {
+	sub, _, seq := h.Subscribe(hub.DefaultBuffer)
+
+	return &subscription{
+		hub:      h,
+		sub:      sub,
+		symbols:  make(map[string]uint64),
+		received: seq,
+	}
+}
+
+func (s *subscription) updates() <-chan hub.Update {
+	return s.sub.Updates()
+}
+
+func (s *subscription) close() {
+	s.hub.Unsubscribe(s.sub)
+}
+
+// subscribe adds symbols, empty list subscribes to all of them, and returns their snapshot.
+func (s *subscription) subscribe(symbols []string) *quotesv1.QuoteUpdate {
+	snapshot, seq := s.hub.Resync(s.sub)
+
+	if len(symbols) == 0 {
+		s.all = true
+		s.since = seq
+
+		return snapshotUpdate(seq, filter(snapshot, nil))
+	}
+
+	for _, symbol := range symbols {
+		s.symbols[symbol] = seq
+	}
+
+	return snapshotUpdate(seq, filter(snapshot, set(symbols)))
+}
+
+func (s *subscription) unsubscribe(symbols []string) {
+	for _, symbol := range symbols {
+		delete(s.symbols, symbol)
+	}
+}
+
+// next returns delta of subscribed symbol or, if hub dropped updates for slow
+// client, snapshot of every subscribed symbol. Nil is returned for other updates.
+func (s *subscription) next(update hub.Update) *quotesv1.QuoteUpdate {
+	gap := update.Seq != s.received+1
+	s.received = update.Seq
+
+	if gap {
+		return s.resync()
+	}
+
+	if !s.match(update) {
+		return nil
+	}
+
+	return &quotesv1.QuoteUpdate{
+		Type:   quotesv1.QuoteUpdate_TYPE_DELTA,
+		Seq:    update.Seq,
+		Quotes: []*quotesv1.Quote{quotesv1.NewQuote(&update.Data)},
+	}
+}
+
+func (s *subscription) match(update hub.Update) bool {
+	if s.all {
+		return update.Seq > s.since
+	}
+
+	since, ok := s.symbols[update.Data.Symbol]
+
+	return ok && update.Seq > since
+}
+
+func (s *subscription) resync() *quotesv1.QuoteUpdate {
+	if !s.all && len(s.symbols) == 0 {
+		return nil
+	}
+
+	snapshot, seq := s.hub.Resync(s.sub)
+
+	if s.all {
+		s.since = seq
+		return snapshotUpdate(seq, filter(snapshot, nil))
+	}
+
+	quotes := make([]*storage.Data, 0, len(s.symbols))
+
+	for _, q := range snapshot {
+		if _, ok := s.symbols[q.Symbol]; ok {
+			quotes = append(quotes, q)
+		}
+	}
+
+	for symbol := range s.symbols {
+		s.symbols[symbol] = seq
+	}
+
+	return snapshotUpdate(seq, filter(quotes, nil))
+}
+
+func snapshotUpdate(seq uint64, quotes []*quotesv1.Quote) *quotesv1.QuoteUpdate {
+	return &quotesv1.QuoteUpdate{
+		Type:   quotesv1.QuoteUpdate_TYPE_SNAPSHOT,
+		Seq:    seq,
+		Quotes: quotes,
+	}
+}
@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// WatchFiles polls files modification time and size and calls onChange when any of them
+// changes. It blocks until ctx is done. Empty paths are skipped.
+func WatchFiles(ctx context.Context, interval time.Duration, onChange func(), paths ...string) {
+	stamp := func() []time.Time {
+		stamps := make([]time.Time, 0, len(paths))
+
+		for _, path := range paths {
+			if path == "" {
+				continue
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				stamps = append(stamps, time.Time{})
+				continue
+			}
+
+			stamps = append(stamps, info.ModTime().Add(time.Duration(info.Size())))
+		}
+
+		return stamps
+	}
+
+	last := stamp()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := stamp()
+
+			for i := range current {
+				if !current[i].Equal(last[i]) {
+					last = current
+
+					onChange()
+
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package helpers_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("a"), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan struct{}, 1)
+
+	go helpers.WatchFiles(ctx, 10*time.Millisecond, func() {
+		changed <- struct{}{}
+	}, path, "")
+
+	time.Sleep(30 * time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte("ab"), 0o600))
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("change was not detected")
+	}
+}
@@ -0,0 +1,22 @@
+package httpserver_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/httpserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	require.NoError(t, httpserver.NewError(nil))
+
+	stdErr := errors.New("something went wrong")
+	err := httpserver.NewError(stdErr)
+
+	var httpserverErr *httpserver.Error
+	require.True(t, errors.As(err, &httpserverErr))
+	assert.Equal(t, "[httpserver]: something went wrong", httpserverErr.Error())
+	assert.True(t, errors.Is(err, stdErr))
+}
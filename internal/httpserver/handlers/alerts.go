@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ole-larsen/binance-subscriber/internal/alert"
+)
+
+// maxRuleSize limits body of alert rule requests.
+const maxRuleSize = 64 << 10
+
+// Alerts manages alert rules of the engine.
+type Alerts struct {
+	engine *alert.Engine
+}
+
+func NewAlerts(e *alert.Engine) *Alerts {
+	return &Alerts{engine: e}
+}
+
+// List godoc
+// @Tags Alerts
+// @Summary list alert rules
+// @ID alertsList
+// @Produce json
+// @Success 200 {array} alert.Rule
+// @Security ApiKeyAuth
+// @Router /api/v1/alerts [get].
+func (a *Alerts) List(rw http.ResponseWriter, r *http.Request) {
+	writeJSON(rw, r, http.StatusOK, a.engine.Rules())
+}
+
+// Create godoc
+// @Tags Alerts
+// @Summary create alert rule or replace rule with the same id, alerts of the rule are not signed, configured rules are not replaced
+// @ID alertsCreate
+// @Accept json
+// @Produce json
+// @Param rule body alert.Rule true "rule"
+// @Success 201 {object} alert.Rule
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/alerts [post].
+func (a *Alerts) Create(rw http.ResponseWriter, r *http.Request) {
+	var rule alert.Rule
+
+	dec := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxRuleSize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&rule); err != nil {
+		writeJSON(rw, r, http.StatusBadRequest, ErrorResponse{Status: "error", Error: err.Error()})
+		return
+	}
+
+	if err := a.engine.Submit(rule); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, alert.ErrTooManyRules) || errors.Is(err, alert.ErrConfigured) {
+			status = http.StatusConflict
+		}
+
+		writeJSON(rw, r, status, ErrorResponse{Status: "error", Error: errors.Unwrap(err).Error()})
+
+		return
+	}
+
+	// rule is returned as stored, e.g. with upper cased symbol
+	rule, _ = a.engine.Get(rule.ID)
+
+	writeJSON(rw, r, http.StatusCreated, rule)
+}
+
+// Get godoc
+// @Tags Alerts
+// @Summary get alert rule
+// @ID alertsGet
+// @Produce json
+// @Param id path string true "rule id"
+// @Success 200 {object} alert.Rule
+// @Failure 404
+// @Security ApiKeyAuth
+// @Router /api/v1/alerts/{id} [get].
+func (a *Alerts) Get(rw http.ResponseWriter, r *http.Request) {
+	rule, ok := a.engine.Get(chi.URLParam(r, "id"))
+	if !ok {
+		NotFoundRequest(rw, r)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, rule)
+}
+
+// Delete godoc
+// @Tags Alerts
+// @Summary delete alert rule
+// @ID alertsDelete
+// @Param id path string true "rule id"
+// @Success 204
+// @Failure 404
+// @Failure 409 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/alerts/{id} [delete].
+func (a *Alerts) Delete(rw http.ResponseWriter, r *http.Request) {
+	err := a.engine.Delete(chi.URLParam(r, "id"))
+	if errors.Is(err, alert.ErrConfigured) {
+		writeJSON(rw, r, http.StatusConflict, ErrorResponse{Status: "error", Error: errors.Unwrap(err).Error()})
+		return
+	}
+
+	if err != nil {
+		NotFoundRequest(rw, r)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
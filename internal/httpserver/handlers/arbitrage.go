@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/ole-larsen/binance-subscriber/internal/arbitrage"
+	"github.com/ole-larsen/binance-subscriber/internal/fanout"
+)
+
+// Arbitrage serves open arbitrage opportunities.
+type Arbitrage struct {
+	monitor *arbitrage.Monitor
+}
+
+func NewArbitrage(m *arbitrage.Monitor) *Arbitrage {
+	return &Arbitrage{monitor: m}
+}
+
+// List godoc
+// @Tags Arbitrage
+// @Summary open triangular and pair arbitrage opportunities with edges after fees
+// @ID arbitrageList
+// @Produce json
+// @Success 200 {array} arbitrage.Opportunity
+// @Security ApiKeyAuth
+// @Router /api/v1/arbitrage [get].
+func (a *Arbitrage) List(rw http.ResponseWriter, r *http.Request) {
+	writeJSON(rw, r, http.StatusOK, a.monitor.Opportunities())
+}
+
+// arbitrageMessage is an opportunity pushed in the arbitrage mode.
+type arbitrageMessage struct {
+	Type string `json:"type"`
+	arbitrage.Opportunity
+}
+
+// serveArbitrage sends {"type":"arbitrage"} messages with open opportunities, then pushes
+// opened, changed and closed ones. Opportunities are dropped for slow clients.
+func (ws *WebSocket) serveArbitrage(conn *websocket.Conn, req *modeRequest) {
+	sub := ws.arbitrage.Subscribe(nil, fanout.DefaultBuffer)
+	defer ws.arbitrage.Unsubscribe(sub)
+
+	serveEvents(conn, req.limiter, ws.arbitrage.Opportunities(), sub, func(o arbitrage.Opportunity) any {
+		return &arbitrageMessage{Type: "arbitrage", Opportunity: o}
+	})
+}
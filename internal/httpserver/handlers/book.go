@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+
+	"github.com/ole-larsen/binance-subscriber/internal/fanout"
+	"github.com/ole-larsen/binance-subscriber/internal/orderbook"
+)
+
+// DefaultBookLevels is the number of levels per side of book snapshot.
+const DefaultBookLevels = 20
+
+// Book serves order book snapshots with their analytics.
+type Book struct {
+	books *orderbook.Books
+}
+
+func NewBook(b *orderbook.Books) *Book {
+	return &Book{books: b}
+}
+
+// Get godoc
+// @Tags Book
+// @Summary order book of symbol with imbalance, depth within bps of mid, microprice and slippage
+// @ID bookGet
+// @Produce json
+// @Param symbol path string true "symbol, e.g. BTCUSDT"
+// @Param levels query int false "levels per side, 20 by default"
+// @Success 200 {object} orderbook.Snapshot
+// @Failure 400
+// @Failure 404
+// @Security ApiKeyAuth
+// @Router /api/v1/book/{symbol} [get].
+func (b *Book) Get(rw http.ResponseWriter, r *http.Request) {
+	levels := DefaultBookLevels
+
+	if v := r.URL.Query().Get("levels"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			BadRequest(rw, r)
+			return
+		}
+
+		levels = n
+	}
+
+	snapshot, ok := b.books.Snapshot(strings.ToUpper(chi.URLParam(r, "symbol")), levels)
+	if !ok {
+		NotFoundRequest(rw, r)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, snapshot)
+}
+
+// bookMessage is analytics of a book pushed in the book mode.
+type bookMessage struct {
+	Type string `json:"type"`
+	orderbook.Event
+}
+
+// serveBook sends {"type":"book"} messages with the latest analytics of symbols books,
+// then pushes them on every depth update. Updates are dropped for slow clients.
+func (ws *WebSocket) serveBook(conn *websocket.Conn, req *modeRequest) {
+	sub := ws.books.Subscribe(slices.Collect(maps.Keys(req.symbols)), fanout.DefaultBuffer)
+	defer ws.books.Unsubscribe(sub)
+
+	var latest []orderbook.Event
+
+	for _, symbol := range ws.books.Symbols() {
+		if req.symbols.match(symbol) {
+			analytics, updateID, _ := ws.books.Analytics(symbol)
+			latest = append(latest, orderbook.Event{Symbol: symbol, UpdateID: updateID, Analytics: analytics})
+		}
+	}
+
+	serveEvents(conn, req.limiter, latest, sub, func(e orderbook.Event) any {
+		return &bookMessage{Type: "book", Event: e}
+	})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"github.com/ole-larsen/binance-subscriber/internal/hub"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// conflator keeps the latest update per symbol between flushes of a conflated subscription.
+type conflator struct {
+	pending  map[string]*storage.Data
+	symbols  []string
+	received uint64
+	seq      uint64
+	gap      bool
+}
+
+func newConflator(seq uint64) *conflator {
+	return &conflator{
+		pending:  make(map[string]*storage.Data),
+		received: seq,
+		seq:      seq,
+	}
+}
+
+// add replaces pending quote of the symbol. Update not following the previous
+// one means hub dropped updates and conflated state is incomplete.
+func (c *conflator) add(update hub.Update) {
+	if update.Seq != c.received+1 {
+		c.gap = true
+	}
+
+	c.received = update.Seq
+	c.seq = update.Seq
+
+	data := update.Data
+
+	if _, ok := c.pending[data.Symbol]; !ok {
+		c.symbols = append(c.symbols, data.Symbol)
+	}
+
+	c.pending[data.Symbol] = &data
+}
+
+// flush returns pending quotes in order of first change, sequence number of the
+// last merged update and whether updates were lost since the previous flush.
+func (c *conflator) flush() (quotes []*storage.Data, seq uint64, gap bool) {
+	quotes = make([]*storage.Data, 0, len(c.symbols))
+
+	for _, symbol := range c.symbols {
+		quotes = append(quotes, c.pending[symbol])
+	}
+
+	gap = c.gap
+
+	clear(c.pending)
+	c.symbols = c.symbols[:0]
+	c.gap = false
+
+	return quotes, c.seq, gap
+}
+
+// reset drops pending quotes after snapshot with the given sequence number.
+func (c *conflator) reset(seq uint64) {
+	clear(c.pending)
+	c.symbols = c.symbols[:0]
+	c.received = seq
+	c.seq = seq
+	c.gap = false
+}
+
+func (c *conflator) empty() bool {
+	return len(c.symbols) == 0 && !c.gap
+}
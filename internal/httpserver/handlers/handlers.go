@@ -2,20 +2,12 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
-
-	"github.com/gorilla/websocket"
-	"github.com/ole-larsen/binance-subscriber/internal/storage"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(_ *http.Request) bool {
-		return true
-	},
-}
-
 type StatusResponse struct {
 	Status string `json:"status"`
 }
@@ -39,6 +31,42 @@ func StatusHandler(rw http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type ErrorResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
+// ReloadHandler godoc
+// @Tags Admin
+// @Summary reload config file and environment
+// @ID adminReload
+// @Produce json
+// @Success 200 {object} StatusResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /admin/reload [post].
+func ReloadHandler(reload func() error) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+
+		if err := reload(); err != nil {
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+
+			if e := json.NewEncoder(rw).Encode(ErrorResponse{Status: "error", Error: err.Error()}); e != nil {
+				InternalServerErrorRequest(rw, r)
+			}
+
+			return
+		}
+
+		rw.WriteHeader(http.StatusOK)
+
+		if _, err := rw.Write([]byte(`{"status":"ok"}`)); err != nil {
+			InternalServerErrorRequest(rw, r)
+			return
+		}
+	}
+}
+
 func BadRequest(rw http.ResponseWriter, _ *http.Request) {
 	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	http.Error(rw, fmt.Sprintf("%d", http.StatusBadRequest)+" bad request", http.StatusBadRequest)
@@ -54,76 +82,43 @@ func NotAllowedRequest(rw http.ResponseWriter, _ *http.Request) {
 	http.Error(rw, fmt.Sprintf("%d", http.StatusMethodNotAllowed)+" method not allowed", http.StatusMethodNotAllowed)
 }
 
+func UnauthorizedRequest(rw http.ResponseWriter, _ *http.Request) {
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rw.Header().Set("WWW-Authenticate", `Bearer realm="binance-subscriber"`)
+	http.Error(rw, fmt.Sprintf("%d", http.StatusUnauthorized)+" unauthorized", http.StatusUnauthorized)
+}
+
 func ForbiddenRequest(rw http.ResponseWriter, _ *http.Request) {
 	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	http.Error(rw, fmt.Sprintf("%d", http.StatusForbidden)+" forbidden", http.StatusForbidden)
 }
 
+func TooManyRequestsRequest(rw http.ResponseWriter, _ *http.Request) {
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	http.Error(rw, fmt.Sprintf("%d", http.StatusTooManyRequests)+" too many requests", http.StatusTooManyRequests)
+}
+
 func InternalServerErrorRequest(rw http.ResponseWriter, _ *http.Request) {
 	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	http.Error(rw, fmt.Sprintf("%d", http.StatusInternalServerError)+" internal server error", http.StatusInternalServerError)
 }
 
-// WebSocketHandler godoc
-// @Tags WebSocket
-// @Summary Handle WebSocket connections
-// @ID websocketConnection
-// @Accept  json
-// @Produce json
-// @Router /ws [get]
-func WebSocketHandler(store storage.Storage) http.HandlerFunc {
-	return func(rw http.ResponseWriter, r *http.Request) {
-		if store == nil {
-			InternalServerErrorRequest(rw, r)
-			return
-		}
-
-		data := store.GetAll()
-
-		dataStr, err := json.Marshal(data)
-
-		if err != nil {
-			InternalServerErrorRequest(rw, r)
-			return
-		}
-
-		conn, err := upgrader.Upgrade(rw, r, nil)
-		if err != nil {
-			InternalServerErrorRequest(rw, r)
-			return
-		}
-		defer conn.Close()
-
-		if err := conn.WriteMessage(websocket.TextMessage, dataStr); err != nil {
-			InternalServerErrorRequest(rw, r)
-			return
-		}
-
-		for {
-			messageType, _, err := conn.ReadMessage()
-			if err != nil {
-				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
-					break
-				}
-
-				InternalServerErrorRequest(rw, r)
+// writeJSON responds with v, conditions like "bid > 1" are not HTML escaped.
+func writeJSON(rw http.ResponseWriter, r *http.Request, status int, v any) {
+	var buf bytes.Buffer
 
-				return
-			}
-
-			data := store.GetAll()
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
 
-			dataStr, err := json.Marshal(data)
+	if err := enc.Encode(v); err != nil {
+		InternalServerErrorRequest(rw, r)
+		return
+	}
 
-			if err != nil {
-				InternalServerErrorRequest(rw, r)
-				return
-			}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
 
-			if err := conn.WriteMessage(messageType, dataStr); err != nil {
-				InternalServerErrorRequest(rw, r)
-				return
-			}
-		}
+	if _, err := rw.Write(buf.Bytes()); err != nil {
+		InternalServerErrorRequest(rw, r)
 	}
 }
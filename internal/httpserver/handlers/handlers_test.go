@@ -1,6 +1,7 @@
 package handlers_test
 
 import (
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -243,3 +244,47 @@ func TestInternalServerErrorHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestReloadHandler(t *testing.T) {
+	tests := []struct {
+		err      error
+		name     string
+		response string
+		code     int
+	}{
+		{
+			name:     "reloaded",
+			code:     http.StatusOK,
+			response: `{"status":"ok"}`,
+		},
+		{
+			name:     "invalid config",
+			err:      errors.New("[config]: log.level: unknown level \"loud\""),
+			code:     http.StatusUnprocessableEntity,
+			response: `{"status":"error","error":"[config]: log.level: unknown level \"loud\""}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/admin/reload", http.NoBody)
+			w := httptest.NewRecorder()
+			handlers.ReloadHandler(func() error {
+				return test.err
+			})(w, request)
+
+			resp := w.Result()
+
+			defer func() {
+				err := resp.Body.Close()
+				require.NoError(t, err)
+			}()
+
+			resBody, err := io.ReadAll(resp.Body)
+
+			require.NoError(t, err)
+			assert.Equal(t, test.code, resp.StatusCode)
+			assert.JSONEq(t, test.response, string(resBody))
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		})
+	}
+}
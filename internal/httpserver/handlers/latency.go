@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ole-larsen/binance-subscriber/internal/latency"
+)
+
+// Latency serves delay of upstream messages and skew of the local clock.
+type Latency struct {
+	tracker *latency.Tracker
+}
+
+func NewLatency(t *latency.Tracker) *Latency {
+	return &Latency{tracker: t}
+}
+
+// Get godoc
+// @Tags Latency
+// @Summary delay of upstream messages from exchange event and transaction times and local clock skew
+// @ID latencyGet
+// @Produce json
+// @Success 200 {object} latency.Report
+// @Security ApiKeyAuth
+// @Router /latency [get].
+func (l *Latency) Get(rw http.ResponseWriter, r *http.Request) {
+	writeJSON(rw, r, http.StatusOK, l.tracker.Report())
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/ole-larsen/binance-subscriber/internal/fanout"
+	"github.com/ole-larsen/binance-subscriber/internal/staleness"
+)
+
+// Staleness serves freshness of symbols.
+type Staleness struct {
+	tracker *staleness.Tracker
+}
+
+func NewStaleness(t *staleness.Tracker) *Staleness {
+	return &Staleness{tracker: t}
+}
+
+// List godoc
+// @Tags Staleness
+// @Summary age of the last update of every symbol and whether it is stale
+// @ID stalenessList
+// @Produce json
+// @Success 200 {array} staleness.Freshness
+// @Security ApiKeyAuth
+// @Router /api/v1/staleness [get].
+func (s *Staleness) List(rw http.ResponseWriter, r *http.Request) {
+	writeJSON(rw, r, http.StatusOK, s.tracker.Freshness())
+}
+
+// stalenessMessage is an event pushed in the staleness mode.
+type stalenessMessage struct {
+	Type string `json:"type"`
+	staleness.Event
+}
+
+// serveStaleness sends {"type":"staleness"} messages with symbols that are stale, then pushes
+// symbols going stale and recovering. Events are dropped for slow clients.
+func (ws *WebSocket) serveStaleness(conn *websocket.Conn, req *modeRequest) {
+	sub := ws.staleness.Subscribe(nil, fanout.DefaultBuffer)
+	defer ws.staleness.Unsubscribe(sub)
+
+	serveEvents(conn, req.limiter, ws.staleness.Stale(), sub, func(e staleness.Event) any {
+		return &stalenessMessage{Type: "staleness", Event: e}
+	})
+}
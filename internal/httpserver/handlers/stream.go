@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/hub"
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+	"github.com/ole-larsen/binance-subscriber/internal/ratelimit"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// DefaultHeartbeat is the interval of comments keeping idle streams open through proxies.
+const DefaultHeartbeat = 15 * time.Second
+
+// Stream serves quote updates as Server-Sent Events. Event ids are "<epoch>-<update id>",
+// epoch changes on restart so ids of another process are never resumed.
+type Stream struct {
+	hub       *hub.Hub
+	quota     *ratelimit.Quota
+	epoch     string
+	heartbeat time.Duration
+}
+
+func NewStream(h *hub.Hub) *Stream {
+	return &Stream{
+		hub:       h,
+		epoch:     strconv.FormatInt(time.Now().UnixNano(), 36),
+		heartbeat: DefaultHeartbeat,
+	}
+}
+
+// SetQuota limits concurrent streams, requests over quota are rejected with 429.
+func (s *Stream) SetQuota(q *ratelimit.Quota) *Stream {
+	s.quota = q
+	return s
+}
+
+// SetHeartbeat sets interval of keep-alive comments, zero disables them.
+func (s *Stream) SetHeartbeat(interval time.Duration) *Stream {
+	s.heartbeat = interval
+	return s
+}
+
+// ServeHTTP godoc
+// @Tags Stream
+// @Summary Stream quotes as Server-Sent Events
+// @Description "snapshot" event with quotes is followed by "quote" events. Reconnecting client
+// @Description sending Last-Event-ID gets missed quotes if they are still in the replay buffer.
+// @ID quotesStream
+// @Produce text/event-stream
+// @Param symbols query string false "comma separated symbols, all by default"
+// @Param Last-Event-ID header string false "id of the last received event"
+// @Security ApiKeyAuth
+// @Router /api/v1/stream [get]
+func (s *Stream) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if s.hub == nil {
+		InternalServerErrorRequest(rw, r)
+		return
+	}
+
+	symbols, ok := parseSymbols(r.URL.Query().Get("symbols"))
+	if !ok {
+		BadRequest(rw, r)
+		return
+	}
+
+	release, err := s.quota.Acquire(ratelimit.ClientKey(r))
+	if err != nil {
+		metrics.RateLimited.WithLabelValues("connections").Inc()
+		TooManyRequestsRequest(rw, r)
+
+		return
+	}
+	defer release()
+
+	rc := http.NewResponseController(rw)
+
+	sub, start := s.hub.SubscribeFrom(hub.DefaultBuffer, s.lastEventID(r))
+	defer s.hub.Unsubscribe(sub)
+
+	metrics.StreamClients.Inc()
+	defer metrics.StreamClients.Dec()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	// disable response buffering of nginx
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+
+	if err = s.start(rw, start, symbols); err != nil || rc.Flush() != nil {
+		return
+	}
+
+	var tick <-chan time.Time
+
+	if s.heartbeat > 0 {
+		ticker := time.NewTicker(s.heartbeat)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	received := uint64(0)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-tick:
+			if _, err = io.WriteString(rw, ": ping\n\n"); err != nil {
+				return
+			}
+		case update, ok := <-sub.Updates():
+			// gap means updates were dropped for slow client, closed stream makes it
+			// reconnect with Last-Event-ID and get them from history or a new snapshot
+			if !ok || update.Seq != received+1 {
+				return
+			}
+
+			received = update.Seq
+
+			if !symbols.match(update.Data.Symbol) {
+				continue
+			}
+
+			if err = s.event(rw, "quote", update.ID, &update.Data); err != nil {
+				return
+			}
+		}
+
+		if err = rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// start writes snapshot or quotes missed since Last-Event-ID.
+func (s *Stream) start(w io.Writer, start hub.Start, symbols symbolSet) error {
+	if _, err := io.WriteString(w, "retry: 1000\n\n"); err != nil {
+		return err
+	}
+
+	if !start.Resumed {
+		quotes := make([]*storage.Data, 0, len(start.Snapshot))
+
+		for _, q := range start.Snapshot {
+			if symbols.match(q.Symbol) {
+				quotes = append(quotes, q)
+			}
+		}
+
+		return s.event(w, "snapshot", start.ID, quotes)
+	}
+
+	for i := range start.Missed {
+		if !symbols.match(start.Missed[i].Data.Symbol) {
+			continue
+		}
+
+		if err := s.event(w, "quote", start.Missed[i].ID, &start.Missed[i].Data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Stream) event(w io.Writer, name string, id uint64, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s-%d\nevent: %s\ndata: %s\n\n", s.epoch, id, name, data)
+
+	return err
+}
+
+// lastEventID returns update id of Last-Event-ID header or "last_event_id" query
+// parameter, zero if it is missing or belongs to another process.
+func (s *Stream) lastEventID(r *http.Request) uint64 {
+	last := r.Header.Get("Last-Event-ID")
+	if last == "" {
+		last = r.URL.Query().Get("last_event_id")
+	}
+
+	epoch, id, ok := strings.Cut(last, "-")
+	if !ok || epoch != s.epoch {
+		return 0
+	}
+
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
+
+// symbolSet filters quotes by symbol, empty set matches all.
+type symbolSet map[string]struct{}
+
+func (s symbolSet) match(symbol string) bool {
+	if len(s) == 0 {
+		return true
+	}
+
+	_, ok := s[symbol]
+
+	return ok
+}
+
+// parseSymbols parses comma separated list of symbols like "BTCUSDT,ethusdt".
+func parseSymbols(list string) (symbolSet, bool) {
+	symbols := make(symbolSet)
+
+	for _, symbol := range strings.Split(list, ",") {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" {
+			continue
+		}
+
+		if !storage.ValidSymbol(symbol) {
+			return nil, false
+		}
+
+		symbols[symbol] = struct{}{}
+	}
+
+	return symbols, true
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	httpSwagger "github.com/swaggo/http-swagger/v2"
+)
+
+var swaggers sync.Map
+
+// SwaggerHandler serves swagger UI pointing to doc.json on the address the request was accepted on,
+// so the UI works whatever host and port the admin listener is bound to.
+func SwaggerHandler(rw http.ResponseWriter, r *http.Request) {
+	url := "/swagger/doc.json"
+
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+
+		url = scheme + "://" + addr.String() + url
+	}
+
+	handler, ok := swaggers.Load(url)
+	if !ok {
+		handler, _ = swaggers.LoadOrStore(url, httpSwagger.Handler(httpSwagger.URL(url)))
+	}
+
+	handler.(http.HandlerFunc).ServeHTTP(rw, r) //nolint:forcetypeassert // explanation: only handlers are stored
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+
+	"github.com/ole-larsen/binance-subscriber/internal/fanout"
+	"github.com/ole-larsen/binance-subscriber/internal/tape"
+)
+
+// Trades serves trade tape and rolling statistics of symbols.
+type Trades struct {
+	tape *tape.Tape
+}
+
+func NewTrades(t *tape.Tape) *Trades {
+	return &Trades{tape: t}
+}
+
+// List godoc
+// @Tags Trades
+// @Summary latest trades of symbol from the oldest to the newest
+// @ID tradesList
+// @Produce json
+// @Param symbol path string true "symbol, e.g. BTCUSDT"
+// @Param limit query int false "number of the latest trades, every kept trade by default"
+// @Success 200 {array} tape.Trade
+// @Failure 400
+// @Failure 404
+// @Security ApiKeyAuth
+// @Router /api/v1/trades/{symbol} [get].
+func (t *Trades) List(rw http.ResponseWriter, r *http.Request) {
+	limit := 0
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			BadRequest(rw, r)
+			return
+		}
+
+		limit = n
+	}
+
+	trades, ok := t.tape.Trades(strings.ToUpper(chi.URLParam(r, "symbol")), limit)
+	if !ok {
+		NotFoundRequest(rw, r)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, trades)
+}
+
+// Stats godoc
+// @Tags Trades
+// @Summary rolling VWAP, volume and trade count of symbol split by aggressor side
+// @ID tradesStats
+// @Produce json
+// @Param symbol path string true "symbol, e.g. BTCUSDT"
+// @Success 200 {object} tape.SymbolStats
+// @Failure 404
+// @Security ApiKeyAuth
+// @Router /api/v1/trades/{symbol}/stats [get].
+func (t *Trades) Stats(rw http.ResponseWriter, r *http.Request) {
+	stats, ok := t.tape.Stats(strings.ToUpper(chi.URLParam(r, "symbol")))
+	if !ok {
+		NotFoundRequest(rw, r)
+		return
+	}
+
+	writeJSON(rw, r, http.StatusOK, stats)
+}
+
+// DefaultStatsInterval is the interval of trades statistics pushed to websocket clients.
+const DefaultStatsInterval = time.Second
+
+// tradeMessage is a trade pushed in the trades mode.
+type tradeMessage struct {
+	Type string `json:"type"`
+	tape.Event
+}
+
+// statsMessage is statistics of a symbol pushed in the trades mode.
+type statsMessage struct {
+	Type string `json:"type"`
+	*tape.SymbolStats
+}
+
+// serveTrades sends {"type":"stats"} messages of symbols with trades, then pushes
+// {"type":"trade"} messages with every trade of symbols and statistics of symbols
+// traded since the previous statistics every stats interval. Trades are dropped for
+// slow clients. Client messages are ignored.
+func (ws *WebSocket) serveTrades(conn *websocket.Conn, req *modeRequest) {
+	symbols := req.symbols
+
+	sub := ws.tape.Subscribe(slices.Collect(maps.Keys(symbols)), fanout.DefaultBuffer)
+	defer ws.tape.Unsubscribe(sub)
+
+	traded := make(map[string]struct{})
+
+	for _, symbol := range ws.tape.Symbols() {
+		if symbols.match(symbol) {
+			traded[symbol] = struct{}{}
+		}
+	}
+
+	// stats sends statistics of traded symbols and forgets them
+	stats := func() error {
+		for symbol := range traded {
+			if s, ok := ws.tape.Stats(symbol); ok {
+				if err := conn.WriteJSON(&statsMessage{Type: "stats", SymbolStats: s}); err != nil {
+					return err
+				}
+			}
+		}
+
+		clear(traded)
+
+		return nil
+	}
+
+	if err := stats(); err != nil {
+		return
+	}
+
+	done := discard(conn, req.limiter)
+
+	var tick <-chan time.Time
+
+	if ws.statsInterval > 0 {
+		ticker := time.NewTicker(ws.statsInterval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-tick:
+			if err := stats(); err != nil {
+				return
+			}
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+
+			traded[event.Symbol] = struct{}{}
+
+			if err := conn.WriteJSON(&tradeMessage{Type: "trade", Event: event}); err != nil {
+				return
+			}
+		}
+	}
+}
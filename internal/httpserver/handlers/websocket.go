@@ -0,0 +1,534 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+
+	"github.com/ole-larsen/binance-subscriber/internal/arbitrage"
+	"github.com/ole-larsen/binance-subscriber/internal/fanout"
+	"github.com/ole-larsen/binance-subscriber/internal/hub"
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+	"github.com/ole-larsen/binance-subscriber/internal/orderbook"
+	"github.com/ole-larsen/binance-subscriber/internal/ratelimit"
+	"github.com/ole-larsen/binance-subscriber/internal/staleness"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+	"github.com/ole-larsen/binance-subscriber/internal/tape"
+	"github.com/ole-larsen/binance-subscriber/internal/wire"
+)
+
+// closeTimeout bounds writing close frame to a misbehaving client.
+const closeTimeout = time.Second
+
+// modeRequest is a /ws request parsed before upgrade.
+type modeRequest struct {
+	codec    wire.Codec
+	limiter  *rate.Limiter
+	symbols  symbolSet
+	conflate time.Duration
+}
+
+// modeHandler serves connections of a /ws mode.
+type modeHandler struct {
+	// enabled reports whether source of the mode is set, nil for modes served from storage
+	enabled func(ws *WebSocket) bool
+	serve   func(ws *WebSocket, conn *websocket.Conn, req *modeRequest)
+	// jsonOnly modes reject other encodings
+	jsonOnly bool
+	// symbols modes filter events by "symbols" query parameter
+	symbols  bool
+	conflate bool
+}
+
+// modes are handlers by "mode" query parameter, empty mode answers every client message
+// with all quotes.
+var modes = map[string]modeHandler{
+	"": {serve: (*WebSocket).serveQuotes},
+	"delta": {
+		enabled:  func(ws *WebSocket) bool { return ws.hub != nil },
+		serve:    (*WebSocket).serveDelta,
+		conflate: true,
+	},
+	"trades": {
+		enabled:  func(ws *WebSocket) bool { return ws.tape != nil },
+		serve:    (*WebSocket).serveTrades,
+		jsonOnly: true,
+		symbols:  true,
+	},
+	"book": {
+		enabled:  func(ws *WebSocket) bool { return ws.books != nil },
+		serve:    (*WebSocket).serveBook,
+		jsonOnly: true,
+		symbols:  true,
+	},
+	"arbitrage": {
+		enabled:  func(ws *WebSocket) bool { return ws.arbitrage != nil },
+		serve:    (*WebSocket).serveArbitrage,
+		jsonOnly: true,
+	},
+	"staleness": {
+		enabled:  func(ws *WebSocket) bool { return ws.staleness != nil },
+		serve:    (*WebSocket).serveStaleness,
+		jsonOnly: true,
+	},
+}
+
+// WebSocket serves quotes to websocket clients. By default every message sent by
+// client is answered with all quotes, with "mode=delta" query parameter client gets
+// snapshot followed by pushed deltas numbered by sequence. Delta subscription can be
+// conflated to the latest quote per symbol every interval for slow clients.
+// With "mode=trades" client gets pushed trades and periodic trades statistics, with
+// "mode=book" order book analytics on every depth update, with "mode=arbitrage"
+// arbitrage opportunities and with "mode=staleness" symbols going stale and recovering.
+type WebSocket struct {
+	store         storage.Storage
+	hub           *hub.Hub
+	tape          *tape.Tape
+	books         *orderbook.Books
+	arbitrage     *arbitrage.Monitor
+	staleness     *staleness.Tracker
+	quota         *ratelimit.Quota
+	upgrader      websocket.Upgrader
+	messageRate   rate.Limit
+	messageBurst  int
+	statsInterval time.Duration
+}
+
+func NewWebSocket(store storage.Storage) *WebSocket {
+	ws := &WebSocket{
+		store:         store,
+		statsInterval: DefaultStatsInterval,
+	}
+
+	return ws.SetAllowedOrigins(nil)
+}
+
+// SetAllowedOrigins sets origins allowed to open websocket. Requests without Origin header
+// (non-browser clients) are always allowed, "*" allows any origin and empty list allows
+// the same origin only.
+func (ws *WebSocket) SetAllowedOrigins(origins []string) *WebSocket {
+	allowed := make(map[string]struct{}, len(origins))
+
+	for _, origin := range origins {
+		allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = struct{}{}
+	}
+
+	ws.upgrader.CheckOrigin = func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		if _, ok := allowed["*"]; ok {
+			return true
+		}
+
+		if _, ok := allowed[strings.ToLower(origin)]; ok {
+			return true
+		}
+
+		u, err := url.Parse(origin)
+
+		return len(allowed) == 0 && err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+
+	return ws
+}
+
+// SetHub sets source of pushed updates for the delta mode.
+func (ws *WebSocket) SetHub(h *hub.Hub) *WebSocket {
+	ws.hub = h
+	return ws
+}
+
+// SetTape sets source of pushed trades for the trades mode.
+func (ws *WebSocket) SetTape(t *tape.Tape) *WebSocket {
+	ws.tape = t
+	return ws
+}
+
+// SetBooks sets source of pushed order book analytics for the book mode.
+func (ws *WebSocket) SetBooks(b *orderbook.Books) *WebSocket {
+	ws.books = b
+	return ws
+}
+
+// SetArbitrage sets source of pushed opportunities for the arbitrage mode.
+func (ws *WebSocket) SetArbitrage(m *arbitrage.Monitor) *WebSocket {
+	ws.arbitrage = m
+	return ws
+}
+
+// SetStaleness sets source of pushed events for the staleness mode.
+func (ws *WebSocket) SetStaleness(t *staleness.Tracker) *WebSocket {
+	ws.staleness = t
+	return ws
+}
+
+// SetStatsInterval sets interval of trades statistics of the trades mode.
+func (ws *WebSocket) SetStatsInterval(interval time.Duration) *WebSocket {
+	ws.statsInterval = interval
+	return ws
+}
+
+// SetQuota limits concurrent connections, requests over quota are rejected with 429.
+func (ws *WebSocket) SetQuota(q *ratelimit.Quota) *WebSocket {
+	ws.quota = q
+	return ws
+}
+
+// SetMessageRate limits inbound messages per connection, connection exceeding it
+// is closed with 1008 (policy violation). Zero rate disables the limit.
+func (ws *WebSocket) SetMessageRate(perSecond float64, burst int) *WebSocket {
+	ws.messageRate = rate.Limit(perSecond)
+	ws.messageBurst = max(burst, 1)
+
+	return ws
+}
+
+// SetCompression enables permessage-deflate negotiation with clients.
+func (ws *WebSocket) SetCompression(enabled bool) *WebSocket {
+	ws.upgrader.EnableCompression = enabled
+	return ws
+}
+
+// ServeHTTP godoc
+// @Tags WebSocket
+// @Summary Handle WebSocket connections
+// @Description Quotes encoding is selected by "encoding" query parameter or subprotocol: json (default), msgpack, protobuf or bbo.
+// @ID websocketConnection
+// @Accept  json
+// @Produce json
+// @Param encoding query string false "json, msgpack, protobuf or bbo"
+// @Param mode query string false "delta: snapshot then pushed deltas with sequence numbers, trades: pushed trades and statistics, book: pushed order book analytics, arbitrage: pushed arbitrage opportunities, staleness: pushed stale and recovered symbols"
+// @Param symbols query string false "trades and book modes comma separated symbols, all by default"
+// @Param conflate query string false "delta mode conflation interval, e.g. 250ms"
+// @Security ApiKeyAuth
+// @Router /ws [get]
+func (ws *WebSocket) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if ws.store == nil {
+		InternalServerErrorRequest(rw, r)
+		return
+	}
+
+	codec, header, ok := negotiate(r)
+	if !ok {
+		BadRequest(rw, r)
+		return
+	}
+
+	mode, ok := modes[r.URL.Query().Get("mode")]
+	if !ok {
+		BadRequest(rw, r)
+		return
+	}
+
+	if mode.enabled != nil && !mode.enabled(ws) {
+		InternalServerErrorRequest(rw, r)
+		return
+	}
+
+	var symbols symbolSet
+
+	if mode.symbols {
+		symbols, ok = parseSymbols(r.URL.Query().Get("symbols"))
+	}
+
+	if !ok || (mode.jsonOnly && codec != wire.JSON) {
+		BadRequest(rw, r)
+		return
+	}
+
+	conflate, err := wire.ParseConflation(r.URL.Query().Get("conflate"))
+	if err != nil || (conflate > 0 && !mode.conflate) {
+		BadRequest(rw, r)
+		return
+	}
+
+	req := &modeRequest{codec: codec, symbols: symbols, conflate: conflate}
+
+	release, err := ws.quota.Acquire(ratelimit.ClientKey(r))
+	if err != nil {
+		metrics.RateLimited.WithLabelValues("connections").Inc()
+		TooManyRequestsRequest(rw, r)
+
+		return
+	}
+	defer release()
+
+	conn, err := ws.upgrader.Upgrade(rw, r, header)
+	if err != nil {
+		// upgrader has already responded with http error
+		return
+	}
+	defer conn.Close()
+
+	metrics.WSClients.Inc()
+	defer metrics.WSClients.Dec()
+
+	if ws.messageRate > 0 {
+		req.limiter = rate.NewLimiter(ws.messageRate, ws.messageBurst)
+	}
+
+	mode.serve(ws, conn, req)
+}
+
+// serveQuotes sends all quotes and answers every client message with them.
+func (ws *WebSocket) serveQuotes(conn *websocket.Conn, req *modeRequest) {
+	if err := write(conn, req.codec, &wire.Frame{Quotes: ws.store.GetAll()}); err != nil {
+		return
+	}
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+
+		if !allow(conn, req.limiter) {
+			return
+		}
+
+		if err := write(conn, req.codec, &wire.Frame{Quotes: ws.store.GetAll()}); err != nil {
+			return
+		}
+	}
+}
+
+// serveDelta sends snapshot and then pushes every update as delta frame. Client
+// detecting sequence gap sends {"op":"resync"} and gets fresh snapshot.
+//
+// Conflated subscription sends pending quotes once per interval, delta seq is the
+// number of the last merged update so gaps are expected. Updates dropped by hub
+// are detected by the server and replaced with fresh snapshot.
+func (ws *WebSocket) serveDelta(conn *websocket.Conn, req *modeRequest) {
+	codec, limiter := req.codec, req.limiter
+
+	sub, snapshot, seq := ws.hub.Subscribe(hub.DefaultBuffer)
+	defer ws.hub.Unsubscribe(sub)
+
+	if err := write(conn, codec, &wire.Frame{Type: wire.FrameSnapshot, Seq: seq, Quotes: snapshot}); err != nil {
+		return
+	}
+
+	pending := newConflator(seq)
+
+	// ticker is stopped while conflation is disabled, tick is nil then
+	ticker := time.NewTicker(wire.MaxConflation)
+	defer ticker.Stop()
+
+	tick := conflate(ticker, req.conflate)
+
+	// flush sends conflated quotes or fresh snapshot if some updates were lost
+	flush := func() error {
+		if pending.empty() {
+			return nil
+		}
+
+		quotes, last, gap := pending.flush()
+		if gap {
+			snapshot, seq = ws.hub.Resync(sub)
+			pending.reset(seq)
+
+			return write(conn, codec, &wire.Frame{Type: wire.FrameSnapshot, Seq: seq, Quotes: snapshot})
+		}
+
+		seq = last
+
+		return write(conn, codec, &wire.Frame{Type: wire.FrameDelta, Seq: seq, Quotes: quotes})
+	}
+
+	commands := make(chan []byte)
+	done := make(chan struct{})
+	stop := make(chan struct{})
+
+	defer close(stop)
+
+	// gorilla websocket supports one concurrent reader and one writer, commands are
+	// read here and answered by the writer loop below
+	go func() {
+		defer close(done)
+
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil || !allow(conn, limiter) {
+				return
+			}
+
+			select {
+			case commands <- message:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		case message := <-commands:
+			cmd, err := wire.ParseCommand(message)
+			if err != nil {
+				if err = conn.WriteMessage(websocket.TextMessage, wire.EncodeError(err)); err != nil {
+					return
+				}
+
+				continue
+			}
+
+			switch cmd.Op {
+			case wire.OpResync:
+				snapshot, seq = ws.hub.Resync(sub)
+				pending.reset(seq)
+
+				err = write(conn, codec, &wire.Frame{Type: wire.FrameSnapshot, Seq: seq, Quotes: snapshot})
+			case wire.OpConflate:
+				// pending quotes are not delayed by the new interval
+				if err = flush(); err == nil {
+					tick = conflate(ticker, cmd.Conflate)
+				}
+			}
+
+			if err != nil {
+				return
+			}
+		case <-tick:
+			if err := flush(); err != nil {
+				return
+			}
+		case update, ok := <-sub.Updates():
+			if !ok {
+				return
+			}
+
+			// already included in the last snapshot
+			if update.Seq <= seq {
+				continue
+			}
+
+			if tick != nil {
+				pending.add(update)
+				continue
+			}
+
+			seq = update.Seq
+			pending.reset(seq)
+
+			err := write(conn, codec, &wire.Frame{Type: wire.FrameDelta, Seq: seq, Quotes: []*storage.Data{&update.Data}})
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// conflate resets ticker to interval and returns its channel, zero interval stops
+// ticker and returns nil channel.
+func conflate(ticker *time.Ticker, interval time.Duration) <-chan time.Time {
+	if interval <= 0 {
+		ticker.Stop()
+		return nil
+	}
+
+	ticker.Reset(interval)
+
+	return ticker.C
+}
+
+// serveEvents sends initial events, then pushes events of sub until client disconnects.
+// Events are sent as JSON messages built by message. Client messages are ignored.
+func serveEvents[T any](
+	conn *websocket.Conn,
+	limiter *rate.Limiter,
+	initial []T,
+	sub *fanout.Subscriber[T],
+	message func(T) any,
+) {
+	for _, event := range initial {
+		if err := conn.WriteJSON(message(event)); err != nil {
+			return
+		}
+	}
+
+	done := discard(conn, limiter)
+
+	for {
+		select {
+		case <-done:
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+
+			if err := conn.WriteJSON(message(event)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// discard reads and ignores client messages, returned channel is closed when client
+// disconnects or exceeds inbound messages rate.
+func discard(conn *websocket.Conn, limiter *rate.Limiter) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil || !allow(conn, limiter) {
+				return
+			}
+		}
+	}()
+
+	return done
+}
+
+// allow reports whether client is within inbound messages rate, otherwise connection
+// is closed with 1008 (policy violation).
+func allow(conn *websocket.Conn, limiter *rate.Limiter) bool {
+	if limiter == nil || limiter.Allow() {
+		return true
+	}
+
+	metrics.RateLimited.WithLabelValues("messages").Inc()
+
+	_ = conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "message rate exceeded"),
+		time.Now().Add(closeTimeout))
+
+	return false
+}
+
+// write encodes and sends frame.
+func write(conn *websocket.Conn, codec wire.Codec, f *wire.Frame) error {
+	data, err := codec.Encode(f)
+	if err != nil {
+		return err
+	}
+
+	return conn.WriteMessage(codec.MessageType(), data)
+}
+
+// negotiate selects codec by "encoding" query parameter or the first supported subprotocol
+// offered by client, JSON is used by default. Selected subprotocol is returned in response header.
+func negotiate(r *http.Request) (codec wire.Codec, header http.Header, ok bool) {
+	if name := r.URL.Query().Get("encoding"); name != "" {
+		codec, ok = wire.Lookup(strings.ToLower(name))
+		return codec, nil, ok
+	}
+
+	for _, protocol := range websocket.Subprotocols(r) {
+		if codec, ok := wire.Lookup(protocol); ok {
+			return codec, http.Header{"Sec-Websocket-Protocol": {protocol}}, true
+		}
+	}
+
+	return wire.JSON, nil, true
+}
@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/ole-larsen/binance-subscriber/internal/auth"
+	"github.com/ole-larsen/binance-subscriber/internal/httpserver/handlers"
+)
+
+// Authenticate stores identity of the request in context or responds 401.
+// Nil or disabled authenticator lets every request in as auth.Anonymous.
+func Authenticate(a *auth.Authenticator) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			id, err := a.Authenticate(r)
+			if err != nil {
+				handlers.UnauthorizedRequest(rw, r)
+				return
+			}
+
+			next.ServeHTTP(rw, r.WithContext(auth.WithIdentity(r.Context(), id)))
+		})
+	}
+}
+
+// RequireScope responds 403 unless authenticated identity has the scope.
+func RequireScope(scope string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			id := auth.FromContext(r.Context())
+			if id == nil {
+				handlers.UnauthorizedRequest(rw, r)
+				return
+			}
+
+			if !id.HasScope(scope) {
+				handlers.ForbiddenRequest(rw, r)
+				return
+			}
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/httpserver/handlers"
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+	"github.com/ole-larsen/binance-subscriber/internal/ratelimit"
+)
+
+// RateLimit responds 429 with Retry-After header when client exhausted its token bucket.
+// Clients are identified by ratelimit.ClientKey, so it must run after Authenticate.
+func RateLimit(l *ratelimit.Limiter) Middleware {
+	return limit(l, "requests", ratelimit.ClientKey)
+}
+
+// RateLimitIP responds 429 with Retry-After header when IP of the request exhausted its token
+// bucket. It runs before Authenticate, so requests with invalid credentials are throttled too.
+func RateLimitIP(l *ratelimit.Limiter) Middleware {
+	return limit(l, "ip_requests", func(r *http.Request) string {
+		return ratelimit.IPKey(r.RemoteAddr)
+	})
+}
+
+func limit(l *ratelimit.Limiter, reason string, key func(*http.Request) string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if ok, retryAfter := l.Allow(key(r), time.Now()); !ok {
+				metrics.RateLimited.WithLabelValues(reason).Inc()
+				rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				handlers.TooManyRequestsRequest(rw, r)
+
+				return
+			}
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
@@ -2,22 +2,52 @@
 package router
 
 import (
+	"time"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	httpSwagger "github.com/swaggo/http-swagger/v2"
 
+	"github.com/ole-larsen/binance-subscriber/internal/alert"
+	"github.com/ole-larsen/binance-subscriber/internal/arbitrage"
+	"github.com/ole-larsen/binance-subscriber/internal/auth"
 	"github.com/ole-larsen/binance-subscriber/internal/httpserver/handlers"
+	"github.com/ole-larsen/binance-subscriber/internal/httpserver/middlewares"
+	"github.com/ole-larsen/binance-subscriber/internal/hub"
+	"github.com/ole-larsen/binance-subscriber/internal/latency"
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+	"github.com/ole-larsen/binance-subscriber/internal/orderbook"
+	"github.com/ole-larsen/binance-subscriber/internal/ratelimit"
+	"github.com/ole-larsen/binance-subscriber/internal/staleness"
 	"github.com/ole-larsen/binance-subscriber/internal/storage"
+	"github.com/ole-larsen/binance-subscriber/internal/tape"
 )
 
 type Mux struct {
-	Router  chi.Router
-	storage storage.Storage
+	Router         chi.Router
+	storage        storage.Storage
+	hub            *hub.Hub
+	tape           *tape.Tape
+	books          *orderbook.Books
+	arbitrage      *arbitrage.Monitor
+	staleness      *staleness.Tracker
+	latency        *latency.Tracker
+	alerts         *alert.Engine
+	reloader       func() error
+	auth           *auth.Authenticator
+	limiter        *ratelimit.Limiter
+	ipLimiter      *ratelimit.Limiter
+	quota          *ratelimit.Quota
+	allowedOrigins []string
+	messageRate    float64
+	messageBurst   int
+	compression    bool
+	heartbeat      time.Duration
 }
 
 func NewMux() *Mux {
 	return &Mux{
-		Router: chi.NewRouter(),
+		Router:    chi.NewRouter(),
+		heartbeat: handlers.DefaultHeartbeat,
 	}
 }
 
@@ -26,6 +56,105 @@ func (m *Mux) SetStorage(store storage.Storage) *Mux {
 	return m
 }
 
+// SetHub sets source of pushed /ws and /api/v1/stream updates.
+func (m *Mux) SetHub(h *hub.Hub) *Mux {
+	m.hub = h
+	return m
+}
+
+// SetTape sets source of /api/v1/trades and /ws trades mode, nil disables them.
+func (m *Mux) SetTape(t *tape.Tape) *Mux {
+	m.tape = t
+	return m
+}
+
+// SetBooks sets source of /api/v1/book and /ws book mode, nil disables them.
+func (m *Mux) SetBooks(b *orderbook.Books) *Mux {
+	m.books = b
+	return m
+}
+
+// SetArbitrage sets source of /api/v1/arbitrage and /ws arbitrage mode, nil disables them.
+func (m *Mux) SetArbitrage(a *arbitrage.Monitor) *Mux {
+	m.arbitrage = a
+	return m
+}
+
+// SetStaleness sets source of /api/v1/staleness and /ws staleness mode, nil disables them.
+func (m *Mux) SetStaleness(t *staleness.Tracker) *Mux {
+	m.staleness = t
+	return m
+}
+
+// SetLatency sets source of admin /latency, nil disables it.
+func (m *Mux) SetLatency(t *latency.Tracker) *Mux {
+	m.latency = t
+	return m
+}
+
+// SetAlerts sets engine managed by /api/v1/alerts, nil disables the routes. They are mounted
+// only when authentication is enabled, anonymous clients would have alerts:manage scope.
+func (m *Mux) SetAlerts(e *alert.Engine) *Mux {
+	m.alerts = e
+	return m
+}
+
+// SetReloader sets callback for config reload admin endpoint.
+func (m *Mux) SetReloader(fn func() error) *Mux {
+	m.reloader = fn
+	return m
+}
+
+// SetAuthenticator sets authenticator for API routes, nil disables authentication.
+func (m *Mux) SetAuthenticator(a *auth.Authenticator) *Mux {
+	m.auth = a
+	return m
+}
+
+// SetAllowedOrigins sets origins allowed to open websocket connections.
+func (m *Mux) SetAllowedOrigins(origins []string) *Mux {
+	m.allowedOrigins = origins
+	return m
+}
+
+// SetRateLimiter sets per client requests limiter, nil disables rate limiting.
+func (m *Mux) SetRateLimiter(l *ratelimit.Limiter) *Mux {
+	m.limiter = l
+	return m
+}
+
+// SetIPRateLimiter sets per IP requests limiter checked before authentication, nil disables it.
+func (m *Mux) SetIPRateLimiter(l *ratelimit.Limiter) *Mux {
+	m.ipLimiter = l
+	return m
+}
+
+// SetConnectionQuota sets concurrent websocket and event stream connections quota, nil disables it.
+func (m *Mux) SetConnectionQuota(q *ratelimit.Quota) *Mux {
+	m.quota = q
+	return m
+}
+
+// SetMessageRate sets inbound websocket messages limit per connection, zero disables it.
+func (m *Mux) SetMessageRate(perSecond float64, burst int) *Mux {
+	m.messageRate = perSecond
+	m.messageBurst = burst
+
+	return m
+}
+
+// SetCompression enables permessage-deflate negotiation with websocket clients.
+func (m *Mux) SetCompression(enabled bool) *Mux {
+	m.compression = enabled
+	return m
+}
+
+// SetStreamHeartbeat sets interval of event stream keep-alive comments, zero disables them.
+func (m *Mux) SetStreamHeartbeat(interval time.Duration) *Mux {
+	m.heartbeat = interval
+	return m
+}
+
 func (m *Mux) SetMiddlewares() *Mux {
 	m.Router.Use(middleware.RequestID)
 	m.Router.Use(middleware.RealIP)
@@ -37,13 +166,101 @@ func (m *Mux) SetMiddlewares() *Mux {
 	return m
 }
 
+// SetHandlers mounts public API routes.
 func (m *Mux) SetHandlers() *Mux {
-	m.Router.Get("/ws", handlers.WebSocketHandler(m.storage))
+	m.Router.With(middlewares.RateLimitIP(m.ipLimiter)).Get("/status", handlers.StatusHandler)
+
+	m.Router.Group(func(r chi.Router) {
+		r.Use(middlewares.RateLimitIP(m.ipLimiter))
+		r.Use(middlewares.Authenticate(m.auth))
+		r.Use(middlewares.RateLimit(m.limiter))
+
+		r.With(middlewares.RequireScope(auth.ScopeQuotesRead)).
+			Get("/ws", handlers.NewWebSocket(m.storage).
+				SetHub(m.hub).
+				SetTape(m.tape).
+				SetBooks(m.books).
+				SetArbitrage(m.arbitrage).
+				SetStaleness(m.staleness).
+				SetAllowedOrigins(m.allowedOrigins).
+				SetQuota(m.quota).
+				SetMessageRate(m.messageRate, m.messageBurst).
+				SetCompression(m.compression).
+				ServeHTTP)
+
+		r.With(middlewares.RequireScope(auth.ScopeQuotesRead)).
+			Get("/api/v1/stream", handlers.NewStream(m.hub).
+				SetQuota(m.quota).
+				SetHeartbeat(m.heartbeat).
+				ServeHTTP)
+
+		if m.tape != nil {
+			trades := handlers.NewTrades(m.tape)
+
+			r.With(middlewares.RequireScope(auth.ScopeQuotesRead)).
+				Get("/api/v1/trades/{symbol}", trades.List)
+			r.With(middlewares.RequireScope(auth.ScopeQuotesRead)).
+				Get("/api/v1/trades/{symbol}/stats", trades.Stats)
+		}
+
+		if m.books != nil {
+			r.With(middlewares.RequireScope(auth.ScopeQuotesRead)).
+				Get("/api/v1/book/{symbol}", handlers.NewBook(m.books).Get)
+		}
+
+		if m.arbitrage != nil {
+			r.With(middlewares.RequireScope(auth.ScopeQuotesRead)).
+				Get("/api/v1/arbitrage", handlers.NewArbitrage(m.arbitrage).List)
+		}
+
+		if m.staleness != nil {
+			r.With(middlewares.RequireScope(auth.ScopeQuotesRead)).
+				Get("/api/v1/staleness", handlers.NewStaleness(m.staleness).List)
+		}
+
+		if m.alerts != nil && m.auth.Enabled() {
+			alerts := handlers.NewAlerts(m.alerts)
+
+			r.Route("/api/v1/alerts", func(r chi.Router) {
+				r.Use(middlewares.RequireScope(auth.ScopeAlertsManage))
+
+				r.Get("/", alerts.List)
+				r.Post("/", alerts.Create)
+				r.Get("/{id}", alerts.Get)
+				r.Delete("/{id}", alerts.Delete)
+			})
+		}
+	})
+
+	return m
+}
+
+// SetAdminHandlers mounts admin, pprof, swagger and metrics routes, they are served
+// by the admin listener and require admin scope when authentication is enabled.
+// Reload changes upstream subscriptions and requires subscriptions:manage.
+func (m *Mux) SetAdminHandlers() *Mux {
 	m.Router.Get("/status", handlers.StatusHandler)
-	m.Router.Mount("/debug", middleware.Profiler())
-	m.Router.Get("/swagger/*", httpSwagger.Handler(
-		httpSwagger.URL("http://localhost:8080/swagger/doc.json"), // The url pointing to API definition
-	))
+
+	m.Router.Group(func(r chi.Router) {
+		r.Use(middlewares.Authenticate(m.auth))
+
+		if m.reloader != nil {
+			r.With(middlewares.RequireScope(auth.ScopeSubscriptionsManage)).
+				Post("/admin/reload", handlers.ReloadHandler(m.reloader))
+		}
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequireScope(auth.ScopeAdmin))
+
+			r.Mount("/debug", middleware.Profiler())
+			r.Get("/swagger/*", handlers.SwaggerHandler)
+			r.Handle("/metrics", metrics.Handler())
+
+			if m.latency != nil {
+				r.Get("/latency", handlers.NewLatency(m.latency).Get)
+			}
+		})
+	})
 
 	return m
 }
@@ -1,15 +1,35 @@
 package router_test
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/ole-larsen/binance-subscriber/internal/alert"
+	"github.com/ole-larsen/binance-subscriber/internal/arbitrage"
+	"github.com/ole-larsen/binance-subscriber/internal/auth"
 	"github.com/ole-larsen/binance-subscriber/internal/httpserver/router"
+	"github.com/ole-larsen/binance-subscriber/internal/hub"
+	"github.com/ole-larsen/binance-subscriber/internal/latency"
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+	"github.com/ole-larsen/binance-subscriber/internal/orderbook"
+	"github.com/ole-larsen/binance-subscriber/internal/ratelimit"
+	"github.com/ole-larsen/binance-subscriber/internal/staleness"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+	"github.com/ole-larsen/binance-subscriber/internal/tape"
+	"github.com/ole-larsen/binance-subscriber/internal/wire"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -93,3 +113,1004 @@ func TestRouter(t *testing.T) {
 		}
 	}
 }
+
+func TestRouter_Auth(t *testing.T) {
+	keys := filepath.Join(t.TempDir(), "keys.yaml")
+	require.NoError(t, os.WriteFile(keys, []byte(`
+keys:
+  - id: reader
+    key: read-key
+    scopes: [quotes:read]
+  - id: ops
+    key: admin-key
+    scopes: [admin]
+  - id: deploy
+    key: deploy-key
+    scopes: [subscriptions:manage]
+`), 0o600))
+
+	a, err := auth.New(auth.Options{KeysFile: keys})
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(router.NewMux().
+		SetStorage(storage.NewMemStorage()).
+		SetAuthenticator(a).
+		SetReloader(func() error { return nil }).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	admin := httptest.NewServer(router.NewMux().
+		SetAuthenticator(a).
+		SetReloader(func() error { return nil }).
+		SetMiddlewares().
+		SetAdminHandlers().Router)
+	defer admin.Close()
+
+	tests := []struct {
+		server *httptest.Server
+		name   string
+		method string
+		path   string
+		key    string
+		status int
+	}{
+		{server: ts, name: "status is public", method: http.MethodGet, path: "/status", status: http.StatusOK},
+		{server: ts, name: "ws without key", method: http.MethodGet, path: "/ws", status: http.StatusUnauthorized},
+		{server: ts, name: "ws with wrong key", method: http.MethodGet, path: "/ws", key: "nope", status: http.StatusUnauthorized},
+		{server: ts, name: "reload on public listener", method: http.MethodPost, path: "/admin/reload", key: "admin-key",
+			status: http.StatusNotFound},
+		{server: ts, name: "debug on public listener", method: http.MethodGet, path: "/debug/pprof/", key: "admin-key",
+			status: http.StatusNotFound},
+		{server: admin, name: "admin status", method: http.MethodGet, path: "/status", status: http.StatusOK},
+		{server: admin, name: "reload with read key", method: http.MethodPost, path: "/admin/reload", key: "read-key",
+			status: http.StatusForbidden},
+		{server: admin, name: "reload with admin key", method: http.MethodPost, path: "/admin/reload", key: "admin-key",
+			status: http.StatusOK},
+		{server: admin, name: "reload with subscriptions key", method: http.MethodPost, path: "/admin/reload", key: "deploy-key",
+			status: http.StatusOK},
+		{server: admin, name: "debug with subscriptions key", method: http.MethodGet, path: "/debug/pprof/", key: "deploy-key",
+			status: http.StatusForbidden},
+		{server: admin, name: "debug with read key", method: http.MethodGet, path: "/debug/pprof/", key: "read-key",
+			status: http.StatusForbidden},
+		{server: admin, name: "debug with admin key", method: http.MethodGet, path: "/debug/pprof/", key: "admin-key",
+			status: http.StatusOK},
+		{server: admin, name: "metrics without key", method: http.MethodGet, path: "/metrics", status: http.StatusUnauthorized},
+		{server: admin, name: "metrics with admin key", method: http.MethodGet, path: "/metrics", key: "admin-key",
+			status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.server.URL+tt.path, http.NoBody)
+			require.NoError(t, err)
+
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+
+			resp, err := tt.server.Client().Do(req)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+
+	conn, resp, err := websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(ts.URL, "http")+"/ws?api_key=read-key", nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.NoError(t, conn.Close())
+}
+
+func TestRouter_AllowedOrigins(t *testing.T) {
+	ts := httptest.NewServer(router.NewMux().
+		SetStorage(storage.NewMemStorage()).
+		SetAllowedOrigins([]string{"https://dashboard.example.com"}).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	tests := []struct {
+		name   string
+		origin string
+		ok     bool
+	}{
+		{name: "no origin", ok: true},
+		{name: "allowed origin", origin: "https://dashboard.example.com", ok: true},
+		{name: "foreign origin", origin: "https://evil.example.com"},
+		{name: "same host not in list", origin: ts.URL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+
+			conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+			if resp != nil {
+				require.NoError(t, resp.Body.Close())
+			}
+
+			if !tt.ok {
+				require.Error(t, err)
+				assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, conn.Close())
+		})
+	}
+}
+
+func TestRouter_RateLimit(t *testing.T) {
+	ts := httptest.NewServer(router.NewMux().
+		SetStorage(storage.NewMemStorage()).
+		SetRateLimiter(ratelimit.NewLimiter(1, 2)).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	// plain GET passes the limiter and fails websocket handshake
+	for i, want := range []int{http.StatusBadRequest, http.StatusBadRequest, http.StatusTooManyRequests} {
+		resp, _ := testRequest(t, ts, http.MethodGet, "/ws", http.NoBody)
+		require.Equal(t, want, resp.StatusCode, "request %d", i)
+
+		if want == http.StatusTooManyRequests {
+			assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+		}
+	}
+
+	// status is limited per IP only
+	resp, _ := testRequest(t, ts, http.MethodGet, "/status", http.NoBody)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestRouter_IPRateLimit(t *testing.T) {
+	keys := filepath.Join(t.TempDir(), "keys.yaml")
+	require.NoError(t, os.WriteFile(keys, []byte(`
+keys:
+  - id: reader
+    key: read-key
+    scopes: [quotes:read]
+`), 0o600))
+
+	a, err := auth.New(auth.Options{KeysFile: keys})
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(router.NewMux().
+		SetStorage(storage.NewMemStorage()).
+		SetAuthenticator(a).
+		SetRateLimiter(ratelimit.NewLimiter(100, 100)).
+		SetIPRateLimiter(ratelimit.NewLimiter(1, 3)).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	// invalid credentials take tokens of the IP, brute force is throttled
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/v1/stream", http.NoBody)
+		require.NoError(t, err)
+		req.Header.Set("X-API-Key", fmt.Sprintf("guess-%d", i))
+
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, want, resp.StatusCode, "request %d", i)
+	}
+
+	// status shares the bucket of the IP
+	resp, _ := testRequest(t, ts, http.MethodGet, "/status", http.NoBody)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+}
+
+func TestRouter_WebSocketLimits(t *testing.T) {
+	ts := httptest.NewServer(router.NewMux().
+		SetStorage(storage.NewMemStorage()).
+		SetConnectionQuota(ratelimit.NewQuota(0, 1)).
+		SetMessageRate(1, 2).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	defer conn.Close()
+
+	// second connection of the same client is over quota
+	_, resp, err = websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	// snapshot on connect
+	_, _, err = conn.ReadMessage()
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("ping")))
+	}
+
+	for i := 0; i < 2; i++ {
+		_, _, err = conn.ReadMessage()
+		require.NoError(t, err)
+	}
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), err)
+
+	// slot is released after close
+	require.Eventually(t, func() bool {
+		c, resp, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			return false
+		}
+
+		require.NoError(t, resp.Body.Close())
+
+		return c.Close() == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRouter_Swagger(t *testing.T) {
+	admin := httptest.NewServer(router.NewMux().
+		SetMiddlewares().
+		SetAdminHandlers().Router)
+	defer admin.Close()
+
+	_, body := testRequest(t, admin, http.MethodGet, "/swagger/index.html", http.NoBody)
+	// url is escaped inside swagger UI script
+	assert.Contains(t, body, strings.ReplaceAll(admin.URL+"/swagger/doc.json", "/", `\/`))
+}
+
+func TestRouter_WebSocketEncoding(t *testing.T) {
+	store := storage.NewMemStorage()
+	store.Set(storage.Data{Symbol: "BTCUSDT", Bid: "97000.01", Ask: "97000.02"})
+
+	ts := httptest.NewServer(router.NewMux().
+		SetStorage(store).
+		SetCompression(true).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	tests := []struct {
+		name        string
+		query       string
+		protocols   []string
+		wantProto   string
+		messageType int
+		compress    bool
+	}{
+		{name: "default json", messageType: websocket.TextMessage},
+		{name: "query", query: "?encoding=bbo", messageType: websocket.BinaryMessage},
+		{name: "subprotocol", protocols: []string{"avro", "protobuf", "msgpack"}, wantProto: "protobuf",
+			messageType: websocket.BinaryMessage},
+		{name: "unsupported subprotocol", protocols: []string{"avro"}, messageType: websocket.TextMessage},
+		{name: "compression", query: "?encoding=json", compress: true, messageType: websocket.TextMessage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := websocket.Dialer{Subprotocols: tt.protocols, EnableCompression: tt.compress}
+
+			conn, resp, err := dialer.Dial(url+tt.query, nil)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			defer conn.Close()
+
+			assert.Equal(t, tt.wantProto, conn.Subprotocol())
+			assert.Equal(t, tt.compress, strings.Contains(resp.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate"))
+
+			messageType, data, err := conn.ReadMessage()
+			require.NoError(t, err)
+			assert.Equal(t, tt.messageType, messageType)
+			assert.NotEmpty(t, data)
+		})
+	}
+
+	_, resp, err := websocket.DefaultDialer.Dial(url+"?encoding=xml", nil)
+	require.Error(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+type deltaFrame struct {
+	Type   string          `json:"type"`
+	Error  string          `json:"error"`
+	Quotes []*storage.Data `json:"quotes"`
+	Seq    uint64          `json:"seq"`
+}
+
+func TestRouter_WebSocketDelta(t *testing.T) {
+	store := storage.NewMemStorage()
+	store.Set(storage.Data{Symbol: "BTCUSDT", Bid: "1", Ask: "2"})
+
+	h := hub.New(store)
+
+	ts := httptest.NewServer(router.NewMux().
+		SetStorage(store).
+		SetHub(h).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	conn, resp, err := websocket.DefaultDialer.Dial(url+"?mode=delta", nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	defer conn.Close()
+
+	read := func() deltaFrame {
+		var f deltaFrame
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		require.NoError(t, conn.ReadJSON(&f))
+
+		return f
+	}
+
+	assert.Equal(t, deltaFrame{Type: wire.FrameSnapshot, Quotes: []*storage.Data{{Symbol: "BTCUSDT", Bid: "1", Ask: "2"}}}, read())
+
+	require.Eventually(t, func() bool { return h.Len() == 1 }, time.Second, 10*time.Millisecond)
+
+	h.Set(storage.Data{Symbol: "ETHUSDT", Bid: "3", Ask: "4"})
+	h.Set(storage.Data{Symbol: "BTCUSDT", Bid: "5", Ask: "6"})
+
+	assert.Equal(t, deltaFrame{Type: wire.FrameDelta, Seq: 1, Quotes: []*storage.Data{{Symbol: "ETHUSDT", Bid: "3", Ask: "4"}}}, read())
+	assert.Equal(t, deltaFrame{Type: wire.FrameDelta, Seq: 2, Quotes: []*storage.Data{{Symbol: "BTCUSDT", Bid: "5", Ask: "6"}}}, read())
+
+	require.NoError(t, conn.WriteJSON(wire.Command{Op: wire.OpResync}))
+
+	snapshot := read()
+	assert.Equal(t, wire.FrameSnapshot, snapshot.Type)
+	assert.Equal(t, uint64(2), snapshot.Seq)
+	assert.Len(t, snapshot.Quotes, 2)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"op":"dance"}`)))
+	assert.Equal(t, deltaFrame{Type: "error", Error: `unknown op "dance"`}, read())
+
+	h.Set(storage.Data{Symbol: "ETHUSDT", Bid: "7", Ask: "8"})
+	assert.Equal(t, uint64(3), read().Seq)
+
+	require.NoError(t, conn.Close())
+	require.Eventually(t, func() bool { return h.Len() == 0 }, time.Second, 10*time.Millisecond)
+
+	_, resp, err = websocket.DefaultDialer.Dial(url+"?mode=stream", nil)
+	require.Error(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestRouter_WebSocketConflation(t *testing.T) {
+	store := storage.NewMemStorage()
+	h := hub.New(store)
+
+	ts := httptest.NewServer(router.NewMux().
+		SetStorage(store).
+		SetHub(h).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	for _, query := range []string{"?mode=delta&conflate=1ms", "?mode=delta&conflate=soon", "?conflate=250ms"} {
+		_, resp, err := websocket.DefaultDialer.Dial(url+query, nil)
+		require.Error(t, err, query)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+
+	conn, resp, err := websocket.DefaultDialer.Dial(url+"?mode=delta&conflate=100ms", nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	defer conn.Close()
+
+	read := func() deltaFrame {
+		var f deltaFrame
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		require.NoError(t, conn.ReadJSON(&f))
+
+		return f
+	}
+
+	assert.Equal(t, wire.FrameSnapshot, read().Type)
+	require.Eventually(t, func() bool { return h.Len() == 1 }, time.Second, 10*time.Millisecond)
+
+	h.Set(storage.Data{Symbol: "BTCUSDT", Bid: "1", Ask: "2"})
+	h.Set(storage.Data{Symbol: "ETHUSDT", Bid: "3", Ask: "4"})
+	h.Set(storage.Data{Symbol: "BTCUSDT", Bid: "5", Ask: "6"})
+	h.Set(storage.Data{Symbol: "BTCUSDT", Bid: "7", Ask: "8"})
+
+	// the ticker can split updates into two frames, but never sends them one by one
+	quotes := make(map[string]storage.Data)
+	frames := 0
+
+	for f := read(); ; f = read() {
+		frames++
+
+		assert.Equal(t, wire.FrameDelta, f.Type)
+
+		for _, q := range f.Quotes {
+			quotes[q.Symbol] = *q
+		}
+
+		if f.Seq == 4 {
+			break
+		}
+	}
+
+	assert.LessOrEqual(t, frames, 2)
+	assert.Equal(t, map[string]storage.Data{
+		"BTCUSDT": {Symbol: "BTCUSDT", Bid: "7", Ask: "8"},
+		"ETHUSDT": {Symbol: "ETHUSDT", Bid: "3", Ask: "4"},
+	}, quotes)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"op":"conflate","interval":"1ms"}`)))
+	assert.Equal(t, "error", read().Type)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"op":"conflate","interval":"0"}`)))
+
+	// commands are processed in order, error reply means conflation is disabled
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"op":"ping"}`)))
+	assert.Equal(t, "error", read().Type)
+
+	h.Set(storage.Data{Symbol: "BTCUSDT", Bid: "11", Ask: "12"})
+	h.Set(storage.Data{Symbol: "ETHUSDT", Bid: "13", Ask: "14"})
+
+	first, second := read(), read()
+	assert.Equal(t, []*storage.Data{{Symbol: "BTCUSDT", Bid: "11", Ask: "12"}}, first.Quotes)
+	assert.Equal(t, []*storage.Data{{Symbol: "ETHUSDT", Bid: "13", Ask: "14"}}, second.Quotes)
+	assert.Equal(t, first.Seq+1, second.Seq)
+}
+
+type event struct {
+	ID    string
+	Name  string
+	Data  string
+	Lines []string
+}
+
+// readEvent reads the next event skipping retry field, comments are collected in Lines.
+func readEvent(t *testing.T, r *bufio.Reader) event {
+	t.Helper()
+
+	var e event
+
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "" && e.Name != "":
+			return e
+		case strings.HasPrefix(line, "id: "):
+			e.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.Name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.Data = strings.TrimPrefix(line, "data: ")
+		case line != "":
+			e.Lines = append(e.Lines, line)
+		}
+	}
+}
+
+// dialEvents checks that events mode of /ws rejects the encoding and opens it with query,
+// connection is closed with the test.
+func dialEvents(t *testing.T, ts *httptest.Server, mode, encoding, query string) *websocket.Conn {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws?mode=" + mode
+
+	_, resp, err := websocket.DefaultDialer.Dial(url+"&encoding="+encoding, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.NoError(t, resp.Body.Close())
+
+	conn, resp, err := websocket.DefaultDialer.Dial(url+query, nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// readMessage reads the next JSON message of events mode.
+func readMessage(t *testing.T, conn *websocket.Conn) map[string]any {
+	t.Helper()
+
+	var msg map[string]any
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	require.NoError(t, conn.ReadJSON(&msg))
+
+	return msg
+}
+
+func TestRouter_Stream(t *testing.T) {
+	store := storage.NewMemStorage()
+	store.Set(storage.Data{Symbol: "BTCUSDT", Bid: "1", Ask: "2"})
+	store.Set(storage.Data{Symbol: "ETHUSDT", Bid: "3", Ask: "4"})
+
+	h := hub.New(store)
+
+	ts := httptest.NewServer(router.NewMux().
+		SetStorage(store).
+		SetHub(h).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	open := func(query, lastEventID string) (*bufio.Reader, func()) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/v1/stream"+query, http.NoBody)
+		require.NoError(t, err)
+
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		return bufio.NewReader(resp.Body), func() {
+			cancel()
+			resp.Body.Close()
+		}
+	}
+
+	r, closeStream := open("?symbols=btcusdt", "")
+
+	snapshot := readEvent(t, r)
+	assert.Equal(t, "snapshot", snapshot.Name)
+	assert.JSONEq(t, `[{"symbol":"BTCUSDT","bid":"1","ask":"2"}]`, snapshot.Data)
+	assert.True(t, strings.HasSuffix(snapshot.ID, "-0"), snapshot.ID)
+
+	require.Eventually(t, func() bool { return h.Len() == 1 }, time.Second, 10*time.Millisecond)
+
+	h.Set(storage.Data{Symbol: "ETHUSDT", Bid: "5", Ask: "6"})
+	h.Set(storage.Data{Symbol: "BTCUSDT", Bid: "7", Ask: "8"})
+
+	quote := readEvent(t, r)
+	assert.Equal(t, "quote", quote.Name)
+	assert.JSONEq(t, `{"symbol":"BTCUSDT","bid":"7","ask":"8"}`, quote.Data)
+	assert.True(t, strings.HasSuffix(quote.ID, "-2"), quote.ID)
+
+	closeStream()
+	require.Eventually(t, func() bool { return h.Len() == 0 }, time.Second, 10*time.Millisecond)
+
+	h.Set(storage.Data{Symbol: "BTCUSDT", Bid: "9", Ask: "10"})
+	h.Set(storage.Data{Symbol: "ETHUSDT", Bid: "11", Ask: "12"})
+
+	// missed quotes are replayed instead of snapshot
+	r, closeStream = open("?symbols=BTCUSDT,ETHUSDT", quote.ID)
+
+	missed := readEvent(t, r)
+	assert.Equal(t, "quote", missed.Name)
+	assert.JSONEq(t, `{"symbol":"BTCUSDT","bid":"9","ask":"10"}`, missed.Data)
+
+	missed = readEvent(t, r)
+	assert.JSONEq(t, `{"symbol":"ETHUSDT","bid":"11","ask":"12"}`, missed.Data)
+	assert.True(t, strings.HasSuffix(missed.ID, "-4"), missed.ID)
+
+	closeStream()
+
+	// ids of another process are not resumed
+	r, closeStream = open("", "lz1-3")
+	snapshot = readEvent(t, r)
+	assert.Equal(t, "snapshot", snapshot.Name)
+	assert.JSONEq(t, `[{"symbol":"BTCUSDT","bid":"9","ask":"10"},{"symbol":"ETHUSDT","bid":"11","ask":"12"}]`, snapshot.Data)
+
+	closeStream()
+
+	resp, _ := testRequest(t, ts, http.MethodGet, "/api/v1/stream?symbols=BTC-USDT", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestRouter_StreamHeartbeat(t *testing.T) {
+	ts := httptest.NewServer(router.NewMux().
+		SetHub(hub.New(storage.NewMemStorage())).
+		SetStreamHeartbeat(10 * time.Millisecond).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	client := &http.Client{Timeout: time.Second}
+
+	resp, err := client.Get(ts.URL + "/api/v1/stream")
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+	assert.Equal(t, "snapshot", readEvent(t, r).Name)
+
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+
+		if line == ": ping\n" {
+			break
+		}
+	}
+
+	// without hub streaming is not available
+	ts = httptest.NewServer(router.NewMux().SetMiddlewares().SetHandlers().Router)
+	defer ts.Close()
+
+	resp, _ = testRequest(t, ts, http.MethodGet, "/api/v1/stream", nil)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestRouter_Alerts(t *testing.T) {
+	keys := filepath.Join(t.TempDir(), "keys.yaml")
+	require.NoError(t, os.WriteFile(keys, []byte(`
+keys:
+  - id: reader
+    key: read-key
+    scopes: [quotes:read]
+  - id: alerts
+    key: alerts-key
+    scopes: [alerts:manage]
+`), 0o600))
+
+	a, err := auth.New(auth.Options{KeysFile: keys})
+	require.NoError(t, err)
+
+	engine := alert.NewEngine().SetMaxRules(2)
+	require.NoError(t, engine.Set(alert.Rule{ID: "ops", Condition: "bid > 1"}))
+
+	ts := httptest.NewServer(router.NewMux().
+		SetAuthenticator(a).
+		SetAlerts(engine).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	do := func(method, path, key, body string) (int, string) {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+
+		req.Header.Set("X-API-Key", key)
+
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(data)
+	}
+
+	status, _ := do(http.MethodGet, "/api/v1/alerts", "read-key", "")
+	assert.Equal(t, http.StatusForbidden, status)
+
+	status, body := do(http.MethodPost, "/api/v1/alerts", "alerts-key",
+		`{"id":"btc","symbol":"btcusdt","condition":"spread_bps > 5","for":"10s"}`)
+	require.Equal(t, http.StatusCreated, status, body)
+	assert.JSONEq(t, `{"id":"btc","symbol":"BTCUSDT","condition":"spread_bps > 5","for":"10s"}`, body)
+
+	status, body = do(http.MethodPost, "/api/v1/alerts", "alerts-key", `{"id":"eth","condition":"bid crosses"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, "must look like <metric> <operator> <threshold>")
+
+	status, _ = do(http.MethodPost, "/api/v1/alerts", "alerts-key", `{"id":"eth","when":"now"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, body = do(http.MethodPost, "/api/v1/alerts", "alerts-key",
+		`{"id":"eth","condition":"bid > 1","webhook":"http://localhost:8081/admin/reload"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, `webhook host \"localhost\" is not allowed`)
+
+	status, _ = do(http.MethodPost, "/api/v1/alerts", "alerts-key", `{"id":"eth","condition":"bid > 1"}`)
+	assert.Equal(t, http.StatusConflict, status)
+
+	// configured rules are neither replaced nor deleted
+	status, body = do(http.MethodPost, "/api/v1/alerts", "alerts-key", `{"id":"ops","condition":"bid > 2"}`)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Contains(t, body, "rule is configured")
+
+	status, _ = do(http.MethodDelete, "/api/v1/alerts/ops", "alerts-key", "")
+	assert.Equal(t, http.StatusConflict, status)
+
+	status, body = do(http.MethodGet, "/api/v1/alerts", "alerts-key", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `[
+		{"id":"btc","symbol":"BTCUSDT","condition":"spread_bps > 5","for":"10s"},
+		{"id":"ops","condition":"bid > 1"}
+	]`, body)
+
+	status, body = do(http.MethodGet, "/api/v1/alerts/btc", "alerts-key", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `"condition":"spread_bps > 5"`)
+
+	status, _ = do(http.MethodDelete, "/api/v1/alerts/btc", "alerts-key", "")
+	assert.Equal(t, http.StatusNoContent, status)
+
+	status, _ = do(http.MethodDelete, "/api/v1/alerts/btc", "alerts-key", "")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = do(http.MethodGet, "/api/v1/alerts/btc", "alerts-key", "")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// without engine or authentication the API is not mounted
+	plain := httptest.NewServer(router.NewMux().SetMiddlewares().SetHandlers().Router)
+	defer plain.Close()
+
+	resp, _ := testRequest(t, plain, http.MethodGet, "/api/v1/alerts", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	anonymous := httptest.NewServer(router.NewMux().SetAlerts(alert.NewEngine()).SetMiddlewares().SetHandlers().Router)
+	defer anonymous.Close()
+
+	resp, _ = testRequest(t, anonymous, http.MethodPost, "/api/v1/alerts", strings.NewReader(`{"id":"a","condition":"bid > 1"}`))
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestRouter_Trades(t *testing.T) {
+	tp := tape.New()
+	require.NoError(t, tp.Add("BTCUSDT", &marketdata.Trade{ID: 1, Price: "100", Quantity: "2", Time: time.Now().UnixMilli()}))
+	require.NoError(t, tp.Add("BTCUSDT", &marketdata.Trade{ID: 2, Price: "130", Quantity: "1", BuyerMaker: true}))
+
+	ts := httptest.NewServer(router.NewMux().
+		SetStorage(storage.NewMemStorage()).
+		SetTape(tp).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	resp, body := testRequest(t, ts, http.MethodGet, "/api/v1/trades/btcusdt?limit=1", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var trades []tape.Trade
+
+	require.NoError(t, json.Unmarshal([]byte(body), &trades))
+	require.Len(t, trades, 1)
+	assert.Equal(t, "130", trades[0].Price)
+	assert.Equal(t, tape.SideSell, trades[0].Side)
+
+	resp, body = testRequest(t, ts, http.MethodGet, "/api/v1/trades/BTCUSDT/stats", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `"window":"1m","vwap":110,"volume":3,"quote_volume":330,"buy_volume":2,"sell_volume":1,`+
+		`"trades":2,"buy_trades":1,"sell_trades":1`)
+
+	for path, status := range map[string]int{
+		"/api/v1/trades/ETHUSDT":           http.StatusNotFound,
+		"/api/v1/trades/ETHUSDT/stats":     http.StatusNotFound,
+		"/api/v1/trades/BTCUSDT?limit=-1":  http.StatusBadRequest,
+		"/api/v1/trades/BTCUSDT?limit=all": http.StatusBadRequest,
+	} {
+		resp, _ = testRequest(t, ts, http.MethodGet, path, nil)
+		assert.Equal(t, status, resp.StatusCode, path)
+	}
+
+	resp, _ = testRequest(t, ts, http.MethodGet, "/ws?mode=trades&symbols=BTC-USDT", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	conn := dialEvents(t, ts, "trades", "msgpack", "&symbols=btcusdt")
+
+	// statistics of traded symbols are sent first
+	msg := readMessage(t, conn)
+	assert.Equal(t, "stats", msg["type"])
+	assert.Equal(t, "BTCUSDT", msg["symbol"])
+
+	require.NoError(t, tp.Add("ETHUSDT", &marketdata.Trade{ID: 3, Price: "10", Quantity: "1"}))
+	require.NoError(t, tp.Add("BTCUSDT", &marketdata.Trade{ID: 4, Price: "120", Quantity: "1"}))
+
+	msg = readMessage(t, conn)
+	assert.Equal(t, "trade", msg["type"])
+	assert.Equal(t, "BTCUSDT", msg["symbol"])
+	assert.Equal(t, "buy", msg["trade"].(map[string]any)["side"])
+	assert.InDelta(t, 4, msg["trade"].(map[string]any)["id"], 0)
+
+	msg = readMessage(t, conn)
+	assert.Equal(t, "stats", msg["type"])
+	assert.Len(t, msg["windows"], 3)
+}
+
+func TestRouter_Book(t *testing.T) {
+	books := orderbook.New()
+	require.NoError(t, books.Apply("BTCUSDT", &marketdata.Depth{
+		Bids:          []marketdata.Level{{Price: "100", Quantity: "3"}, {Price: "99", Quantity: "1"}},
+		Asks:          []marketdata.Level{{Price: "101", Quantity: "1"}},
+		FirstUpdateID: 1,
+		FinalUpdateID: 2,
+	}))
+
+	ts := httptest.NewServer(router.NewMux().
+		SetStorage(storage.NewMemStorage()).
+		SetBooks(books).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	resp, body := testRequest(t, ts, http.MethodGet, "/api/v1/book/btcusdt?levels=1", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `"symbol":"BTCUSDT","bids":[{"price":"100","quantity":"3"}],`+
+		`"asks":[{"price":"101","quantity":"1"}],"update_id":2,"synced":false`)
+	assert.Contains(t, body, `"best_bid":100,"best_ask":101,"mid":100.5,"spread":1,"microprice":100.75,"imbalance":0.6`)
+
+	for path, status := range map[string]int{
+		"/api/v1/book/ETHUSDT":            http.StatusNotFound,
+		"/api/v1/book/BTCUSDT?levels=0":   http.StatusBadRequest,
+		"/api/v1/book/BTCUSDT?levels=top": http.StatusBadRequest,
+	} {
+		resp, _ = testRequest(t, ts, http.MethodGet, path, nil)
+		assert.Equal(t, status, resp.StatusCode, path)
+	}
+
+	conn := dialEvents(t, ts, "book", "protobuf", "&symbols=BTCUSDT")
+
+	// analytics of existing books are sent first
+	msg := readMessage(t, conn)
+	assert.Equal(t, "book", msg["type"])
+	assert.InDelta(t, 2, msg["update_id"], 0)
+
+	require.NoError(t, books.Apply("ETHUSDT", &marketdata.Depth{FirstUpdateID: 1, FinalUpdateID: 1}))
+	require.NoError(t, books.Apply("BTCUSDT", &marketdata.Depth{
+		Asks:          []marketdata.Level{{Price: "101", Quantity: "0"}, {Price: "102", Quantity: "2"}},
+		FirstUpdateID: 3,
+		FinalUpdateID: 3,
+	}))
+
+	msg = readMessage(t, conn)
+	assert.Equal(t, "BTCUSDT", msg["symbol"])
+	assert.InDelta(t, 3, msg["update_id"], 0)
+	assert.InDelta(t, 102, msg["analytics"].(map[string]any)["best_ask"], 0)
+}
+
+func TestRouter_Arbitrage(t *testing.T) {
+	monitor := arbitrage.New().SetFee(0).SetThreshold(0)
+	require.NoError(t, monitor.Set(nil, []arbitrage.Pair{{ID: "btc", Symbols: []string{"BTCUSDT", "BTCUSDC"}}}))
+
+	monitor.Observe(&storage.Data{Symbol: "BTCUSDT", Bid: "100", Ask: "101"})
+	monitor.Observe(&storage.Data{Symbol: "BTCUSDC", Bid: "102", Ask: "103"})
+
+	ts := httptest.NewServer(router.NewMux().
+		SetStorage(storage.NewMemStorage()).
+		SetArbitrage(monitor).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	resp, body := testRequest(t, ts, http.MethodGet, "/api/v1/arbitrage", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `"route":"btc","kind":"pair","direction":"forward","status":"open",`+
+		`"legs":[{"symbol":"BTCUSDT","side":"buy","price":101},{"symbol":"BTCUSDC","side":"sell","price":102}]`)
+
+	conn := dialEvents(t, ts, "arbitrage", "msgpack", "")
+
+	// open opportunities are sent first
+	msg := readMessage(t, conn)
+	assert.Equal(t, "arbitrage", msg["type"])
+	assert.Equal(t, "open", msg["status"])
+
+	monitor.Observe(&storage.Data{Symbol: "BTCUSDC", Bid: "100.5", Ask: "100.6"})
+
+	msg = readMessage(t, conn)
+	assert.Equal(t, "btc", msg["route"])
+	assert.Equal(t, "closed", msg["status"])
+}
+
+func TestRouter_Staleness(t *testing.T) {
+	now := time.Unix(1700000000, 0).UTC()
+
+	tracker := staleness.New().SetThreshold(time.Minute).SetClock(func() time.Time { return now })
+	tracker.Observe(&storage.Data{Symbol: "BTCUSDT"})
+	tracker.Observe(&storage.Data{Symbol: "ETHUSDT"})
+
+	now = now.Add(time.Minute)
+	tracker.Observe(&storage.Data{Symbol: "ETHUSDT"})
+	require.Equal(t, []string{"BTCUSDT"}, tracker.Check())
+
+	ts := httptest.NewServer(router.NewMux().
+		SetStorage(storage.NewMemStorage()).
+		SetStaleness(tracker).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	resp, body := testRequest(t, ts, http.MethodGet, "/api/v1/staleness", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `[
+		{"updated":"2023-11-14T22:13:20Z","symbol":"BTCUSDT","age_seconds":60,"threshold_seconds":60,"stale":true},
+		{"updated":"2023-11-14T22:14:20Z","symbol":"ETHUSDT","age_seconds":0,"threshold_seconds":60,"stale":false}
+	]`, body)
+
+	conn := dialEvents(t, ts, "staleness", "protobuf", "")
+
+	// stale symbols are sent first
+	msg := readMessage(t, conn)
+	assert.Equal(t, "staleness", msg["type"])
+	assert.Equal(t, "BTCUSDT", msg["symbol"])
+	assert.Equal(t, "stale", msg["status"])
+
+	tracker.Observe(&storage.Data{Symbol: "BTCUSDT"})
+
+	msg = readMessage(t, conn)
+	assert.Equal(t, "BTCUSDT", msg["symbol"])
+	assert.Equal(t, "recovered", msg["status"])
+}
+
+func TestRouter_WithoutSources(t *testing.T) {
+	ts := httptest.NewServer(router.NewMux().SetStorage(storage.NewMemStorage()).SetMiddlewares().SetHandlers().Router)
+	defer ts.Close()
+
+	tests := []struct {
+		name string
+		path string
+		mode string
+	}{
+		{name: "without tape trades are not available", path: "/api/v1/trades/BTCUSDT", mode: "trades"},
+		{name: "without books analytics are not available", path: "/api/v1/book/BTCUSDT", mode: "book"},
+		{name: "without monitor opportunities are not available", path: "/api/v1/arbitrage", mode: "arbitrage"},
+		{name: "without tracker staleness is not available", path: "/api/v1/staleness", mode: "staleness"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := testRequest(t, ts, http.MethodGet, tt.path, nil)
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+			resp, _ = testRequest(t, ts, http.MethodGet, "/ws?mode="+tt.mode, nil)
+			assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		})
+	}
+}
+
+func TestRouter_Latency(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+
+	tracker := latency.New().SetClock(func() time.Time { return now })
+	tracker.Observe("trade", now.UnixMilli()-12, now.UnixMilli()-14, now)
+
+	admin := httptest.NewServer(router.NewMux().
+		SetLatency(tracker).
+		SetMiddlewares().
+		SetAdminHandlers().Router)
+	defer admin.Close()
+
+	resp, body := testRequest(t, admin, http.MethodGet, "/latency", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"skew_ms":12,"window_seconds":60,"streams":[{"stream":"trade","messages":1,
+		"event":{"count":1,"last_ms":12,"min_ms":12,"mean_ms":12,"p50_ms":12,"p90_ms":12,"p99_ms":12,"max_ms":12},
+		"transaction":{"count":1,"last_ms":14,"min_ms":14,"mean_ms":14,"p50_ms":14,"p90_ms":14,"p99_ms":14,"max_ms":14}}]}`,
+		body)
+
+	// latency is served on the admin listener only
+	ts := httptest.NewServer(router.NewMux().
+		SetStorage(storage.NewMemStorage()).
+		SetLatency(tracker).
+		SetMiddlewares().
+		SetHandlers().Router)
+	defer ts.Close()
+
+	resp, _ = testRequest(t, ts, http.MethodGet, "/latency", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -12,6 +12,7 @@ import (
 
 type HTTPServer struct {
 	Router *router.Mux
+	TLS    *Certificates
 	Host   string
 	Port   int
 }
@@ -35,6 +36,12 @@ func (s *HTTPServer) SetRouter(r *router.Mux) *HTTPServer {
 	return s
 }
 
+// SetTLS enables TLS, nil serves plain HTTP.
+func (s *HTTPServer) SetTLS(c *Certificates) *HTTPServer {
+	s.TLS = c
+	return s
+}
+
 func (s *HTTPServer) GetHost() string {
 	return s.Host // it can be ""
 }
@@ -56,5 +63,12 @@ func (s *HTTPServer) ListenAndServe() error {
 		ReadHeaderTimeout: defaultTimeout * time.Second,
 	}
 
+	if s.TLS != nil {
+		server.TLSConfig = s.TLS.Config()
+
+		// certificates are provided by TLSConfig
+		return server.ListenAndServeTLS("", "")
+	}
+
 	return server.ListenAndServe()
 }
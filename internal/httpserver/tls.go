@@ -0,0 +1,118 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/helpers"
+)
+
+// TLSOptions describes server certificate and optional verification of client certificates.
+type TLSOptions struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	ClientAuth   tls.ClientAuthType
+}
+
+// Certificates holds server certificate and client CA pool. Files can be replaced at runtime,
+// new handshakes use reloaded certificates while established connections are kept.
+type Certificates struct {
+	cert atomic.Pointer[tls.Certificate]
+	pool atomic.Pointer[x509.CertPool]
+	opts TLSOptions
+}
+
+// NewCertificates loads certificate, key and client CA files.
+func NewCertificates(opts TLSOptions) (*Certificates, error) {
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, NewError(errors.New("tls certificate and key files are required"))
+	}
+
+	if opts.ClientAuth >= tls.VerifyClientCertIfGiven && opts.ClientCAFile == "" {
+		return nil, NewError(errors.New("client certificates verification requires client ca file"))
+	}
+
+	c := &Certificates{opts: opts}
+
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Reload re-reads certificate files. Current certificates are kept if files are invalid.
+func (c *Certificates) Reload() error {
+	cert, err := tls.LoadX509KeyPair(c.opts.CertFile, c.opts.KeyFile)
+	if err != nil {
+		return NewError(fmt.Errorf("tls certificate: %w", err))
+	}
+
+	var pool *x509.CertPool
+
+	if c.opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(c.opts.ClientCAFile)
+		if err != nil {
+			return NewError(fmt.Errorf("tls client ca: %w", err))
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return NewError(fmt.Errorf("tls client ca: no certificates found in %s", c.opts.ClientCAFile))
+		}
+	}
+
+	c.cert.Store(&cert)
+	c.pool.Store(pool)
+
+	return nil
+}
+
+// Watch reloads certificates when files change until ctx is done.
+func (c *Certificates) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	if c == nil || interval <= 0 {
+		return
+	}
+
+	helpers.WatchFiles(ctx, interval, func() {
+		if err := c.Reload(); err != nil {
+			onError(err)
+		}
+	}, c.opts.CertFile, c.opts.KeyFile, c.opts.ClientCAFile)
+}
+
+// Certificate returns current server certificate.
+func (c *Certificates) Certificate() *tls.Certificate {
+	return c.cert.Load()
+}
+
+// Config returns server TLS config resolving certificates on every handshake.
+func (c *Certificates) Config() *tls.Config {
+	// websocket upgrade requires http/1.1
+	return c.ConfigWithProtos("http/1.1")
+}
+
+// ConfigWithProtos returns server TLS config negotiating the given application
+// protocols, e.g. "h2" for gRPC.
+func (c *Certificates) ConfigWithProtos(protos ...string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: protos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*c.cert.Load()},
+				ClientAuth:   c.opts.ClientAuth,
+				ClientCAs:    c.pool.Load(),
+				NextProtos:   protos,
+			}, nil
+		},
+	}
+}
@@ -0,0 +1,212 @@
+package httpserver_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/httpserver"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns PEM encoded certificate and key signed by ca.
+func (ca *testCA) issue(t *testing.T, cn string, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+// serve starts TLS server answering with client certificate common name.
+func serve(t *testing.T, certs *httpserver.Certificates) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &http.Server{
+		ReadHeaderTimeout: time.Second,
+		TLSConfig:         certs.Config(),
+		Handler: http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.VerifiedChains) > 0 {
+				_, _ = rw.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+			}
+		}),
+	}
+
+	go func() {
+		_ = srv.ServeTLS(ln, "", "")
+	}()
+
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	return "https://" + ln.Addr().String()
+}
+
+func client(ca *testCA, certs ...tls.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	return &http.Client{
+		Timeout: time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				RootCAs:      pool,
+				Certificates: certs,
+			},
+		},
+	}
+}
+
+func get(t *testing.T, c *http.Client, url string) (string, *x509.Certificate, error) {
+	t.Helper()
+
+	resp, err := c.Get(url) //nolint:noctx // explanation: test request
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return string(body), resp.TLS.PeerCertificates[0], nil
+}
+
+func TestCertificates_MutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.pem")
+
+	certPEM, keyPEM := ca.issue(t, "server", 2, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	writeFile(t, caFile, ca.pem)
+
+	certs, err := httpserver.NewCertificates(httpserver.TLSOptions{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	require.NoError(t, err)
+
+	url := serve(t, certs)
+
+	// client without certificate is rejected
+	_, _, err = get(t, client(ca), url)
+	require.Error(t, err)
+
+	clientPEM, clientKey := ca.issue(t, "pricing.internal", 3, x509.ExtKeyUsageClientAuth)
+	clientCert, err := tls.X509KeyPair(clientPEM, clientKey)
+	require.NoError(t, err)
+
+	body, peer, err := get(t, client(ca, clientCert), url)
+	require.NoError(t, err)
+	assert.Equal(t, "pricing.internal", body)
+	assert.Equal(t, int64(2), peer.SerialNumber.Int64())
+
+	// rotated certificate is served to new connections
+	certPEM, keyPEM = ca.issue(t, "server", 4, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	require.NoError(t, certs.Reload())
+
+	_, peer, err = get(t, client(ca, clientCert), url)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), peer.SerialNumber.Int64())
+
+	// broken files keep current certificate
+	writeFile(t, keyFile, []byte("broken"))
+	require.Error(t, certs.Reload())
+
+	_, peer, err = get(t, client(ca, clientCert), url)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), peer.SerialNumber.Int64())
+}
+
+func TestNewCertificates_Errors(t *testing.T) {
+	_, err := httpserver.NewCertificates(httpserver.TLSOptions{CertFile: "cert.pem"})
+	require.ErrorContains(t, err, "tls certificate and key files are required")
+
+	_, err = httpserver.NewCertificates(httpserver.TLSOptions{
+		CertFile: "cert.pem", KeyFile: "key.pem", ClientAuth: tls.RequireAndVerifyClientCert,
+	})
+	require.ErrorContains(t, err, "requires client ca file")
+
+	_, err = httpserver.NewCertificates(httpserver.TLSOptions{CertFile: "/nonexistent/cert.pem", KeyFile: "/nonexistent/key.pem"})
+	require.ErrorContains(t, err, "tls certificate")
+}
@@ -0,0 +1,269 @@
+// Package hub fans out quote updates written to storage to streaming clients.
+package hub
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+const (
+	// DefaultBuffer is the number of updates queued per subscriber before they are dropped.
+	DefaultBuffer = 1024
+	// DefaultHistory is the number of the latest updates kept to resume subscriptions.
+	DefaultHistory = 1024
+)
+
+// Update is a quote change numbered by subscriber sequence and by hub-wide id.
+type Update struct {
+	Data storage.Data
+	Seq  uint64
+	ID   uint64
+}
+
+// Start is the state of a subscriber resumed after the last seen update id.
+type Start struct {
+	// Snapshot of stored quotes, it is set when missed updates are no longer in history.
+	Snapshot []*storage.Data
+	// Missed updates published after the last seen id.
+	Missed []Update
+	// ID of the last update included in Snapshot or Missed.
+	ID      uint64
+	Resumed bool
+}
+
+// Subscriber receives updates published after its snapshot. Sequence numbers
+// increase by one per update, a gap means updates were dropped because subscriber
+// did not keep up and it has to resync.
+type Subscriber struct {
+	updates chan Update
+	seq     uint64
+	dropped uint64
+}
+
+// Updates returns channel of updates, it is closed on Unsubscribe.
+func (s *Subscriber) Updates() <-chan Update {
+	return s.updates
+}
+
+var _ storage.Storage = (*Hub)(nil)
+
+// Hub writes quotes to storage and publishes them to subscribers. The latest
+// updates are kept in a ring to resume subscriptions of reconnecting clients.
+type Hub struct {
+	store   storage.Storage
+	subs    map[*Subscriber]struct{}
+	history []Update
+	id      uint64
+	since   uint64
+	mx      sync.Mutex
+}
+
+func New(store storage.Storage) *Hub {
+	return &Hub{
+		store:   store,
+		subs:    make(map[*Subscriber]struct{}),
+		history: make([]Update, DefaultHistory),
+	}
+}
+
+// SetHistory sets number of the latest updates kept to resume subscriptions, zero disables resuming.
+func (h *Hub) SetHistory(size int) *Hub {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	h.history = make([]Update, max(size, 0))
+	// updates published before are not in the new ring
+	h.since = h.id
+
+	return h
+}
+
+// Set stores quote and publishes it to every subscriber without blocking.
+func (h *Hub) Set(data storage.Data) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	h.store.Set(data)
+
+	h.id++
+
+	if len(h.history) > 0 {
+		h.history[h.id%uint64(len(h.history))] = Update{Data: data, ID: h.id}
+	}
+
+	for s := range h.subs {
+		s.seq++
+
+		select {
+		case s.updates <- Update{Data: data, Seq: s.seq, ID: h.id}:
+		default:
+			s.dropped++
+		}
+	}
+}
+
+// Get returns stored quote.
+func (h *Hub) Get(symbol string) *storage.Data {
+	return h.store.Get(symbol)
+}
+
+// GetAll returns every stored quote.
+func (h *Hub) GetAll() []*storage.Data {
+	return h.store.GetAll()
+}
+
+// Subscribe registers subscriber with buffer of updates and returns snapshot of
+// stored quotes with the sequence number it corresponds to.
+func (h *Hub) Subscribe(buffer int) (sub *Subscriber, snapshot []*storage.Data, seq uint64) {
+	if buffer < 1 {
+		buffer = DefaultBuffer
+	}
+
+	sub = &Subscriber{updates: make(chan Update, buffer)}
+
+	snapshot = h.lockedSnapshot()
+	defer h.mx.Unlock()
+
+	h.subs[sub] = struct{}{}
+
+	return sub, snapshot, sub.seq
+}
+
+// SubscribeFrom registers subscriber with buffer of updates and returns updates
+// published after lastID if they are still in history, otherwise snapshot of stored
+// quotes. Zero lastID means nothing was seen. Updates sent to subscriber follow Start.ID.
+func (h *Hub) SubscribeFrom(buffer int, lastID uint64) (*Subscriber, Start) {
+	if buffer < 1 {
+		buffer = DefaultBuffer
+	}
+
+	sub := &Subscriber{updates: make(chan Update, buffer)}
+
+	h.mx.Lock()
+
+	size := uint64(len(h.history))
+
+	if lastID == 0 || lastID > h.id || lastID < h.since || h.id-lastID > size {
+		h.mx.Unlock()
+
+		snapshot := h.lockedSnapshot()
+		defer h.mx.Unlock()
+
+		h.subs[sub] = struct{}{}
+
+		return sub, Start{Snapshot: snapshot, ID: h.id}
+	}
+
+	defer h.mx.Unlock()
+
+	h.subs[sub] = struct{}{}
+
+	missed := make([]Update, 0, h.id-lastID)
+
+	for id := lastID + 1; id <= h.id; id++ {
+		missed = append(missed, h.history[id%size])
+	}
+
+	return sub, Start{Missed: missed, ID: h.id, Resumed: true}
+}
+
+// Resync returns fresh snapshot with the sequence number it corresponds to,
+// queued updates with lower or equal numbers must be skipped.
+func (h *Hub) Resync(sub *Subscriber) (snapshot []*storage.Data, seq uint64) {
+	snapshot = h.lockedSnapshot()
+	defer h.mx.Unlock()
+
+	return snapshot, sub.seq
+}
+
+// lockedSnapshot returns stored quotes as of the last published update with h.mx locked.
+// They are copied without holding h.mx, so readers do not block Set, and quotes of symbols
+// updated meanwhile are read again.
+func (h *Hub) lockedSnapshot() []*storage.Data {
+	h.mx.Lock()
+	from := h.id
+	h.mx.Unlock()
+
+	snapshot := h.store.GetAll()
+
+	h.mx.Lock()
+
+	if h.id == from {
+		return snapshot
+	}
+
+	size := uint64(len(h.history))
+	if from < h.since || h.id-from > size {
+		// updates published while copying are not in history
+		return h.store.GetAll()
+	}
+
+	changed := make(map[string]bool)
+	for id := from + 1; id <= h.id; id++ {
+		changed[h.history[id%size].Data.Symbol] = true
+	}
+
+	// snapshot may be shared by storage, it is not modified
+	res := make([]*storage.Data, 0, len(snapshot)+len(changed))
+
+	for _, data := range snapshot {
+		if !changed[data.Symbol] {
+			res = append(res, data)
+			continue
+		}
+
+		delete(changed, data.Symbol)
+
+		if data = h.store.Get(data.Symbol); data != nil {
+			res = append(res, data)
+		}
+	}
+
+	if len(changed) == 0 {
+		return res
+	}
+
+	for symbol := range changed {
+		if data := h.store.Get(symbol); data != nil {
+			res = append(res, data)
+		}
+	}
+
+	slices.SortFunc(res, func(a, b *storage.Data) int {
+		return strings.Compare(a.Symbol, b.Symbol)
+	})
+
+	return res
+}
+
+// Unsubscribe stops publishing to subscriber and closes its updates channel.
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+
+	delete(h.subs, sub)
+	close(sub.updates)
+}
+
+// Dropped returns number of updates dropped for subscriber.
+func (h *Hub) Dropped(sub *Subscriber) uint64 {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	return sub.dropped
+}
+
+// Len returns number of subscribers.
+func (h *Hub) Len() int {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	return len(h.subs)
+}
@@ -0,0 +1,182 @@
+package hub_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/hub"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+func TestHub(t *testing.T) {
+	store := storage.NewMemStorage()
+	store.Set(storage.Data{Symbol: "BTCUSDT", Bid: "1", Ask: "2"})
+
+	h := hub.New(store)
+
+	sub, snapshot, seq := h.Subscribe(2)
+	assert.Equal(t, uint64(0), seq)
+	assert.Equal(t, []*storage.Data{{Symbol: "BTCUSDT", Bid: "1", Ask: "2"}}, snapshot)
+	assert.Equal(t, 1, h.Len())
+
+	h.Set(storage.Data{Symbol: "ETHUSDT", Bid: "3", Ask: "4"})
+	h.Set(storage.Data{Symbol: "BTCUSDT", Bid: "5", Ask: "6"})
+
+	// buffer is full, update is dropped but numbered
+	h.Set(storage.Data{Symbol: "BTCUSDT", Bid: "7", Ask: "8"})
+	assert.Equal(t, uint64(1), h.Dropped(sub))
+
+	assert.Equal(t, hub.Update{Seq: 1, ID: 1, Data: storage.Data{Symbol: "ETHUSDT", Bid: "3", Ask: "4"}}, <-sub.Updates())
+	assert.Equal(t, hub.Update{Seq: 2, ID: 2, Data: storage.Data{Symbol: "BTCUSDT", Bid: "5", Ask: "6"}}, <-sub.Updates())
+
+	// every update is written to storage
+	assert.Equal(t, &storage.Data{Symbol: "BTCUSDT", Bid: "7", Ask: "8"}, h.Get("BTCUSDT"))
+	assert.Len(t, h.GetAll(), 2)
+
+	h.Set(storage.Data{Symbol: "ETHUSDT", Bid: "9", Ask: "10"})
+	assert.Equal(t, uint64(4), (<-sub.Updates()).Seq, "gap after dropped update")
+
+	snapshot, seq = h.Resync(sub)
+	assert.Equal(t, uint64(4), seq)
+	assert.Equal(t, h.GetAll(), snapshot)
+
+	h.Unsubscribe(sub)
+	h.Unsubscribe(sub)
+
+	_, ok := <-sub.Updates()
+	assert.False(t, ok)
+	assert.Equal(t, 0, h.Len())
+
+	// unsubscribed clients get nothing
+	require.NotPanics(t, func() {
+		h.Set(storage.Data{Symbol: "BTCUSDT"})
+	})
+}
+
+// racingStorage writes quotes through hub while snapshot is copied.
+type racingStorage struct {
+	storage.Storage
+	hub    *hub.Hub
+	writes []storage.Data
+}
+
+func (s *racingStorage) GetAll() []*storage.Data {
+	all := s.Storage.GetAll()
+
+	// it would deadlock if GetAll was called with hub locked
+	for _, data := range s.writes {
+		s.hub.Set(data)
+	}
+
+	s.writes = nil
+
+	return all
+}
+
+func TestHub_SnapshotDoesNotBlockWriters(t *testing.T) {
+	store := &racingStorage{Storage: storage.NewSnapshotStorage()}
+	store.Set(storage.Data{Symbol: "BTCUSDT", Bid: "1"})
+	store.Set(storage.Data{Symbol: "ETHUSDT", Bid: "2"})
+
+	h := hub.New(store)
+	store.hub = h
+	store.writes = []storage.Data{{Symbol: "ETHUSDT", Bid: "3"}, {Symbol: "BNBUSDT", Bid: "4"}}
+
+	// updates written while copying are in snapshot and not queued
+	sub, snapshot, seq := h.Subscribe(8)
+	assert.Equal(t, uint64(0), seq)
+	assert.Equal(t, []*storage.Data{
+		{Symbol: "BNBUSDT", Bid: "4"},
+		{Symbol: "BTCUSDT", Bid: "1"},
+		{Symbol: "ETHUSDT", Bid: "3"},
+	}, snapshot)
+	assert.Empty(t, sub.Updates())
+
+	// without history snapshot is copied again
+	h.SetHistory(0)
+	store.writes = []storage.Data{{Symbol: "BTCUSDT", Bid: "5"}}
+
+	_, start := h.SubscribeFrom(8, 0)
+	assert.Equal(t, uint64(3), start.ID)
+	assert.Equal(t, &storage.Data{Symbol: "BTCUSDT", Bid: "5"}, start.Snapshot[1])
+
+	// the first subscriber got the update with its sequence
+	store.writes = []storage.Data{{Symbol: "BTCUSDT", Bid: "6"}}
+
+	snapshot, seq = h.Resync(sub)
+	assert.Equal(t, uint64(2), seq)
+	assert.Equal(t, &storage.Data{Symbol: "BTCUSDT", Bid: "6"}, snapshot[1])
+}
+
+func TestHub_SubscribersHaveOwnSequence(t *testing.T) {
+	h := hub.New(storage.NewMemStorage())
+
+	first, _, _ := h.Subscribe(0)
+	h.Set(storage.Data{Symbol: "BTCUSDT"})
+
+	second, _, seq := h.Subscribe(0)
+	assert.Equal(t, uint64(0), seq)
+
+	h.Set(storage.Data{Symbol: "ETHUSDT"})
+
+	assert.Equal(t, uint64(1), (<-first.Updates()).Seq)
+	assert.Equal(t, uint64(2), (<-first.Updates()).Seq)
+	assert.Equal(t, uint64(1), (<-second.Updates()).Seq)
+}
+
+func TestHub_SubscribeFrom(t *testing.T) {
+	h := hub.New(storage.NewMemStorage()).SetHistory(3)
+
+	for _, symbol := range []string{"A", "B", "C", "D", "E"} {
+		h.Set(storage.Data{Symbol: symbol})
+	}
+
+	tests := []struct {
+		name     string
+		want     []string
+		lastID   uint64
+		snapshot bool
+	}{
+		{name: "nothing seen", lastID: 0, snapshot: true},
+		{name: "older than history", lastID: 1, snapshot: true},
+		{name: "oldest kept", lastID: 2, want: []string{"C", "D", "E"}},
+		{name: "up to date", lastID: 5, want: []string{}},
+		{name: "from another run", lastID: 10, snapshot: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, start := h.SubscribeFrom(0, tt.lastID)
+			defer h.Unsubscribe(sub)
+
+			assert.Equal(t, uint64(5), start.ID)
+			assert.Equal(t, !tt.snapshot, start.Resumed)
+
+			if tt.snapshot {
+				assert.Len(t, start.Snapshot, 5)
+				assert.Empty(t, start.Missed)
+
+				return
+			}
+
+			symbols := make([]string, 0, len(start.Missed))
+			for _, u := range start.Missed {
+				symbols = append(symbols, u.Data.Symbol)
+			}
+
+			assert.Equal(t, tt.want, symbols)
+			assert.Nil(t, start.Snapshot)
+		})
+	}
+
+	sub, _ := h.SubscribeFrom(0, 5)
+	h.Set(storage.Data{Symbol: "F"})
+	assert.Equal(t, uint64(6), (<-sub.Updates()).ID)
+
+	// history is not carried over
+	h.SetHistory(10)
+
+	_, start := h.SubscribeFrom(0, 5)
+	assert.False(t, start.Resumed)
+}
@@ -0,0 +1,291 @@
+// Package ingest decodes upstream messages on a pool of workers partitioned by symbol and
+// hands normalized updates in batches to a single apply stage, updates of every symbol keep
+// their order.
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/log"
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+	"github.com/ole-larsen/binance-subscriber/internal/poller"
+)
+
+// Stages of the pipeline.
+const (
+	StageRead   = "read"
+	StageDecode = "decode"
+	StageApply  = "apply"
+)
+
+const (
+	// DefaultWorkers is the number of decoder workers.
+	DefaultWorkers = 4
+	// DefaultQueue is the number of messages queued per worker and of batches queued for the apply stage.
+	DefaultQueue = 1024
+	// DefaultBatchSize is the maximum number of updates handed to the apply stage at once.
+	DefaultBatchSize = 64
+)
+
+// Message is a normalized update with the time its raw message was read and its stream type,
+// e.g. depth@100ms.
+type Message struct {
+	Received time.Time
+	Update   *marketdata.Update
+	Stream   string
+}
+
+// Pipeline reads raw messages, decodes them on workers chosen by symbol and queues batches of
+// decoded ones for the apply stage. Malformed messages are counted and skipped. Full queues
+// either drop the oldest entry or block the previous stage, which backpressures the upstream
+// connection.
+type Pipeline struct {
+	logger  *log.Logger
+	workers int
+	queue   int
+	batch   int
+	block   bool
+}
+
+// envelope is a raw combined stream message queued for a worker.
+type envelope struct {
+	received time.Time
+	msg      poller.Message
+}
+
+func New() *Pipeline {
+	return &Pipeline{
+		workers: DefaultWorkers,
+		queue:   DefaultQueue,
+		batch:   DefaultBatchSize,
+	}
+}
+
+// SetWorkers sets number of decoder workers.
+func (p *Pipeline) SetWorkers(workers int) *Pipeline {
+	p.workers = max(workers, 1)
+	return p
+}
+
+// SetQueue sets capacity of every worker queue and of the apply queue in batches.
+func (p *Pipeline) SetQueue(queue int) *Pipeline {
+	p.queue = max(queue, 1)
+	return p
+}
+
+// SetBatchSize sets maximum number of updates in a batch.
+func (p *Pipeline) SetBatchSize(size int) *Pipeline {
+	p.batch = max(size, 1)
+	return p
+}
+
+// SetBlock makes full queues block the previous stage instead of dropping the oldest entry.
+func (p *Pipeline) SetBlock(block bool) *Pipeline {
+	p.block = block
+	return p
+}
+
+// SetLogger sets logger of malformed messages.
+func (p *Pipeline) SetLogger(l *log.Logger) *Pipeline {
+	p.logger = l
+	return p
+}
+
+// Run starts reader and workers consuming raw messages of in. The returned channel of batches
+// is closed once in is closed or ctx is done and every worker has stopped.
+func (p *Pipeline) Run(ctx context.Context, in <-chan []byte) <-chan []Message {
+	out := make(chan []Message, p.queue)
+	queues := make([]chan envelope, p.workers)
+
+	var wg sync.WaitGroup
+
+	for i := range queues {
+		queues[i] = make(chan envelope, p.queue)
+
+		wg.Add(1)
+
+		go func(queue <-chan envelope) {
+			defer wg.Done()
+			p.decode(ctx, queue, out)
+		}(queues[i])
+	}
+
+	go func() {
+		defer func() {
+			for _, queue := range queues {
+				close(queue)
+			}
+
+			wg.Wait()
+			close(out)
+		}()
+
+		p.read(ctx, in, queues)
+	}()
+
+	return out
+}
+
+// read decodes envelopes of raw messages and queues them for the worker of their symbol.
+// Subscription responses are skipped.
+func (p *Pipeline) read(ctx context.Context, in <-chan []byte, queues []chan envelope) {
+	for {
+		var (
+			raw []byte
+			ok  bool
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case raw, ok = <-in:
+			if !ok {
+				return
+			}
+		}
+
+		e := envelope{received: time.Now()}
+
+		if err := json.Unmarshal(raw, &e.msg); err != nil {
+			p.malformed(StageRead, err, raw)
+			continue
+		}
+
+		if e.msg.Stream == "" {
+			continue
+		}
+
+		if kind := poller.StreamType(e.msg.Stream); kind != "" {
+			metrics.UpstreamMessages.WithLabelValues(kind).Inc()
+		}
+
+		symbol, _, _ := strings.Cut(e.msg.Stream, "@")
+
+		if !offer(ctx, p.block, StageDecode, queues[partition(symbol, len(queues))], e, one) {
+			return
+		}
+	}
+}
+
+// decode normalizes queued messages and hands them to the apply stage in batches of those
+// already queued, it never waits to fill a batch.
+func (p *Pipeline) decode(ctx context.Context, queue <-chan envelope, out chan []Message) {
+	for e := range queue {
+		batch := make([]Message, 0, p.batch)
+		batch = p.normalize(batch, &e)
+
+		closed := false
+
+	fill:
+		for len(batch) < p.batch {
+			select {
+			case next, ok := <-queue:
+				if !ok {
+					closed = true
+					break fill
+				}
+
+				batch = p.normalize(batch, &next)
+			default:
+				break fill
+			}
+		}
+
+		if len(batch) > 0 && !offer(ctx, p.block, StageApply, out, batch, size) {
+			return
+		}
+
+		if closed {
+			return
+		}
+	}
+}
+
+// normalize appends update of message to batch, unsupported streams are skipped.
+func (p *Pipeline) normalize(batch []Message, e *envelope) []Message {
+	update, err := marketdata.Normalize(&e.msg)
+	if err != nil {
+		p.malformed(StageDecode, err, e.msg.Data)
+		return batch
+	}
+
+	if update == nil {
+		return batch
+	}
+
+	return append(batch, Message{Received: e.received, Update: update, Stream: poller.StreamType(e.msg.Stream)})
+}
+
+// Malformed counts and logs message skipped by stage.
+func (p *Pipeline) Malformed(stage string, err error) {
+	p.malformed(stage, err, nil)
+}
+
+func (p *Pipeline) malformed(stage string, err error, raw []byte) {
+	metrics.MalformedMessages.WithLabelValues(stage).Inc()
+
+	if p.logger == nil {
+		return
+	}
+
+	const maxRaw = 256
+
+	if len(raw) > maxRaw {
+		raw = raw[:maxRaw]
+	}
+
+	p.logger.Warnw("malformed upstream message",
+		"stage", stage,
+		"error", err.Error(),
+		"message", string(raw),
+	)
+}
+
+// offer queues v, a full queue blocks until there is free space or drops its oldest entry,
+// n returns number of messages of an entry. It returns false when ctx is done.
+func offer[T any](ctx context.Context, block bool, stage string, queue chan T, v T, n func(T) int) bool {
+	if block {
+		select {
+		case queue <- v:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		select {
+		case queue <- v:
+			return true
+		default:
+		}
+
+		select {
+		case oldest := <-queue:
+			metrics.IngestDropped.WithLabelValues(stage).Add(float64(n(oldest)))
+		default:
+		}
+	}
+}
+
+func one(envelope) int {
+	return 1
+}
+
+func size(batch []Message) int {
+	return len(batch)
+}
+
+// partition returns worker of symbol.
+func partition(symbol string, n int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(strings.ToUpper(symbol)))
+
+	return int(h.Sum32() % uint32(n)) //nolint:gosec // explanation: n is a positive number of workers
+}
@@ -0,0 +1,149 @@
+package ingest_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/ingest"
+)
+
+func bookTicker(symbol string, id int) []byte {
+	return []byte(fmt.Sprintf(`{"stream":"%s@bookTicker","data":{"u":%d,"s":"%s","b":"1","B":"1","a":"2","A":"1"}}`,
+		symbol, id, symbol))
+}
+
+// collect reads batches until out is closed.
+func collect(t *testing.T, out <-chan []ingest.Message) [][]ingest.Message {
+	t.Helper()
+
+	var batches [][]ingest.Message
+
+	for {
+		select {
+		case batch, ok := <-out:
+			if !ok {
+				return batches
+			}
+
+			batches = append(batches, batch)
+		case <-time.After(5 * time.Second):
+			t.Fatal("pipeline is not closed")
+		}
+	}
+}
+
+// updates returns update ids of every symbol in order of batches.
+func updates(batches [][]ingest.Message) map[string][]int64 {
+	ids := make(map[string][]int64)
+
+	for _, batch := range batches {
+		for _, msg := range batch {
+			ids[msg.Update.Symbol] = append(ids[msg.Update.Symbol], msg.Update.BBO.UpdateID)
+		}
+	}
+
+	return ids
+}
+
+func TestPipeline_Run(t *testing.T) {
+	symbols := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "XRPUSDT", "BNBUSDT"}
+	in := make(chan []byte, 1024)
+
+	for id := 1; id <= 100; id++ {
+		for _, symbol := range symbols {
+			in <- bookTicker(symbol, id)
+		}
+	}
+
+	// malformed messages and subscription responses are skipped
+	in <- []byte(`not json`)
+	in <- []byte(`{"result":null,"id":"abc"}`)
+	in <- []byte(`{"stream":"btcusdt@bookTicker","data":"quote"}`)
+	in <- []byte(`{"stream":"btcusdt@kline_1m","data":{}}`)
+	close(in)
+
+	out := ingest.New().SetWorkers(3).SetBatchSize(16).SetBlock(true).Run(context.Background(), in)
+	batches := collect(t, out)
+
+	want := make([]int64, 100)
+	for i := range want {
+		want[i] = int64(i + 1)
+	}
+
+	ids := updates(batches)
+	require.Len(t, ids, len(symbols))
+
+	for _, symbol := range symbols {
+		assert.Equal(t, want, ids[symbol], symbol)
+	}
+
+	for _, batch := range batches {
+		assert.NotEmpty(t, batch)
+		assert.LessOrEqual(t, len(batch), 16)
+
+		for _, msg := range batch {
+			assert.Equal(t, "bookTicker", msg.Stream)
+			assert.False(t, msg.Received.IsZero())
+		}
+	}
+}
+
+func TestPipeline_Overflow(t *testing.T) {
+	tests := []struct {
+		name  string
+		block bool
+	}{
+		{name: "block", block: true},
+		{name: "drop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := make(chan []byte, 100)
+			for id := 1; id <= 100; id++ {
+				in <- bookTicker("BTCUSDT", id)
+			}
+
+			close(in)
+
+			out := ingest.New().SetWorkers(1).SetQueue(2).SetBatchSize(4).SetBlock(tt.block).Run(context.Background(), in)
+
+			// the apply stage is slower than upstream
+			time.Sleep(100 * time.Millisecond)
+
+			ids := updates(collect(t, out))["BTCUSDT"]
+			require.NotEmpty(t, ids)
+
+			if tt.block {
+				assert.Len(t, ids, 100)
+			} else {
+				assert.Less(t, len(ids), 100)
+			}
+
+			// the latest updates are kept in order
+			assert.IsIncreasing(t, ids)
+			assert.Equal(t, int64(100), ids[len(ids)-1])
+		})
+	}
+}
+
+func TestPipeline_RunCancel(t *testing.T) {
+	in := make(chan []byte)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	out := ingest.New().Run(ctx, in)
+
+	in <- bookTicker("BTCUSDT", 1)
+
+	batches := [][]ingest.Message{<-out}
+
+	cancel()
+
+	batches = append(batches, collect(t, out)...)
+	assert.Equal(t, map[string][]int64{"BTCUSDT": {1}}, updates(batches))
+}
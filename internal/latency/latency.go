@@ -0,0 +1,282 @@
+// Package latency measures delay of upstream messages from exchange event and transaction
+// times to local receive and estimates skew of the local clock against the exchange.
+package latency
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+)
+
+// Exchange times delays are measured from.
+const (
+	TimeEvent       = "event"
+	TimeTransaction = "transaction"
+)
+
+const (
+	// DefaultWindow is the window of statistics and skew estimate.
+	DefaultWindow = time.Minute
+	// DefaultSamples is the number of the latest delays kept per stream type and time.
+	DefaultSamples = 1024
+)
+
+// Stats summarizes delays in milliseconds of samples within the window.
+type Stats struct {
+	Count int     `json:"count"`
+	Last  float64 `json:"last_ms"`
+	Min   float64 `json:"min_ms"`
+	Mean  float64 `json:"mean_ms"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P99   float64 `json:"p99_ms"`
+	Max   float64 `json:"max_ms"`
+}
+
+// Stream is latency of a stream type, e.g. bookTicker or depth@100ms. Event and Transaction
+// are nil without samples within the window, Messages counts every measured message.
+type Stream struct {
+	Event       *Stats `json:"event,omitempty"`
+	Transaction *Stats `json:"transaction,omitempty"`
+	Stream      string `json:"stream"`
+	Messages    uint64 `json:"messages"`
+}
+
+// Report is latency of every stream type sorted by stream. Skew estimates how far the local
+// clock is ahead of the exchange clock in milliseconds: the fastest message within the window
+// has close to zero network delay, so its event delay is the difference of clocks. Skew is
+// nil without samples.
+type Report struct {
+	Skew    *float64 `json:"skew_ms"`
+	Streams []Stream `json:"streams"`
+	Window  float64  `json:"window_seconds"`
+}
+
+// Tracker keeps bounded rings of the latest delays of every stream type and per second
+// minimums of event delays covering the window.
+type Tracker struct {
+	streams map[string]*stream
+	skew    []bucket
+	now     func() time.Time
+	window  time.Duration
+	samples int
+	mx      sync.Mutex
+}
+
+// stream is the state of a stream type.
+type stream struct {
+	event       ring
+	transaction ring
+	messages    uint64
+}
+
+// ring is a bounded ring of delays, last is the index of the latest one.
+type ring struct {
+	samples []sample
+	last    int
+}
+
+type sample struct {
+	at    time.Time
+	delay float64
+}
+
+// bucket is the minimum event delay of a second.
+type bucket struct {
+	second int64
+	min    float64
+	ok     bool
+}
+
+func New() *Tracker {
+	t := &Tracker{
+		streams: make(map[string]*stream),
+		now:     time.Now,
+		samples: DefaultSamples,
+	}
+
+	return t.SetWindow(DefaultWindow)
+}
+
+// SetWindow sets window of statistics and skew estimate, it is rounded up to seconds.
+// Skew estimate is reset.
+func (t *Tracker) SetWindow(window time.Duration) *Tracker {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.window = max(window, time.Second)
+	t.skew = make([]bucket, int(math.Ceil(t.window.Seconds())))
+
+	return t
+}
+
+// SetSamples sets number of the latest delays kept per stream type and time.
+func (t *Tracker) SetSamples(samples int) *Tracker {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.samples = max(samples, 1)
+
+	return t
+}
+
+// SetClock replaces time.Now.
+func (t *Tracker) SetClock(now func() time.Time) *Tracker {
+	t.now = now
+	return t
+}
+
+// Observe records delays of message of stream type received at received from its event and
+// transaction times in milliseconds. Zero times are unknown and skipped.
+func (t *Tracker) Observe(kind string, eventTime, transactionTime int64, received time.Time) {
+	if t == nil || kind == "" || (eventTime == 0 && transactionTime == 0) {
+		return
+	}
+
+	ms := float64(received.UnixMicro()) / float64(time.Millisecond/time.Microsecond)
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	s, ok := t.streams[kind]
+	if !ok {
+		s = &stream{}
+		t.streams[kind] = s
+	}
+
+	s.messages++
+
+	if eventTime != 0 {
+		delay := ms - float64(eventTime)
+
+		s.event.add(sample{at: received, delay: delay}, t.samples)
+		t.observeSkew(received, delay)
+
+		metrics.UpstreamDelay.WithLabelValues(kind, TimeEvent).Observe(delay / 1000)
+	}
+
+	if transactionTime != 0 {
+		delay := ms - float64(transactionTime)
+
+		s.transaction.add(sample{at: received, delay: delay}, t.samples)
+
+		metrics.UpstreamDelay.WithLabelValues(kind, TimeTransaction).Observe(delay / 1000)
+	}
+
+	if skew, ok := t.estimate(received); ok {
+		metrics.ClockSkew.Set(skew / 1000)
+	}
+}
+
+// Report returns latency of every stream type over the window.
+func (t *Tracker) Report() *Report {
+	now := t.now()
+	from := now.Add(-t.window)
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	report := &Report{
+		Streams: make([]Stream, 0, len(t.streams)),
+		Window:  t.window.Seconds(),
+	}
+
+	if skew, ok := t.estimate(now); ok {
+		report.Skew = &skew
+	}
+
+	for kind, s := range t.streams {
+		report.Streams = append(report.Streams, Stream{
+			Event:       s.event.stats(from),
+			Transaction: s.transaction.stats(from),
+			Stream:      kind,
+			Messages:    s.messages,
+		})
+	}
+
+	sort.Slice(report.Streams, func(i, j int) bool { return report.Streams[i].Stream < report.Streams[j].Stream })
+
+	return report
+}
+
+// observeSkew records event delay in bucket of its second.
+func (t *Tracker) observeSkew(received time.Time, delay float64) {
+	second := received.Unix()
+	b := &t.skew[second%int64(len(t.skew))]
+
+	if !b.ok || b.second != second {
+		*b = bucket{second: second, min: delay, ok: true}
+		return
+	}
+
+	b.min = min(b.min, delay)
+}
+
+// estimate returns the minimum event delay of buckets within the window ending at now.
+func (t *Tracker) estimate(now time.Time) (float64, bool) {
+	from := now.Unix() - int64(len(t.skew))
+	skew, ok := 0.0, false
+
+	for _, b := range t.skew {
+		if !b.ok || b.second <= from || b.second > now.Unix() {
+			continue
+		}
+
+		if !ok || b.min < skew {
+			skew, ok = b.min, true
+		}
+	}
+
+	return skew, ok
+}
+
+func (r *ring) add(s sample, size int) {
+	if len(r.samples) < size {
+		r.samples = append(r.samples, s)
+		r.last = len(r.samples) - 1
+
+		return
+	}
+
+	r.last = (r.last + 1) % len(r.samples)
+	r.samples[r.last] = s
+}
+
+// stats summarizes delays of samples received after from, nil if there are none.
+func (r *ring) stats(from time.Time) *Stats {
+	delays := make([]float64, 0, len(r.samples))
+	sum := 0.0
+
+	for _, s := range r.samples {
+		if s.at.After(from) {
+			delays = append(delays, s.delay)
+			sum += s.delay
+		}
+	}
+
+	if len(delays) == 0 {
+		return nil
+	}
+
+	sort.Float64s(delays)
+
+	return &Stats{
+		Count: len(delays),
+		Last:  r.samples[r.last].delay,
+		Min:   delays[0],
+		Mean:  sum / float64(len(delays)),
+		P50:   quantile(delays, 0.5),
+		P90:   quantile(delays, 0.9),
+		P99:   quantile(delays, 0.99),
+		Max:   delays[len(delays)-1],
+	}
+}
+
+// quantile returns nearest rank quantile q of sorted delays.
+func quantile(sorted []float64, q float64) float64 {
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
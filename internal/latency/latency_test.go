@@ -0,0 +1,82 @@
+package latency_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/latency"
+)
+
+func TestTracker(t *testing.T) {
+	start := time.UnixMilli(1700000000000).UTC()
+	now := start
+
+	tr := latency.New().SetWindow(10 * time.Second).SetClock(func() time.Time { return now })
+
+	assert.Equal(t, &latency.Report{Streams: []latency.Stream{}, Window: 10}, tr.Report())
+
+	// event delays of 5, 15, 25 ... 95 ms, trades also measured from transaction time
+	for i := range 10 {
+		received := start.Add(time.Duration(i*100) * time.Millisecond)
+		event := received.UnixMilli() - int64(5+i*10)
+
+		tr.Observe("trade", event, event-2, received)
+	}
+
+	tr.Observe("depth@100ms", start.UnixMilli()-50, 0, start)
+	tr.Observe("bookTicker", 0, 0, start)
+	tr.Observe("", start.UnixMilli(), 0, start)
+
+	now = start.Add(time.Second)
+	report := tr.Report()
+
+	require.NotNil(t, report.Skew)
+	assert.InDelta(t, 5, *report.Skew, 1e-9)
+	require.Len(t, report.Streams, 2)
+
+	depth := report.Streams[0]
+	assert.Equal(t, "depth@100ms", depth.Stream)
+	assert.Equal(t, uint64(1), depth.Messages)
+	assert.Nil(t, depth.Transaction)
+	assert.Equal(t, &latency.Stats{Count: 1, Last: 50, Min: 50, Mean: 50, P50: 50, P90: 50, P99: 50, Max: 50}, depth.Event)
+
+	trade := report.Streams[1]
+	assert.Equal(t, "trade", trade.Stream)
+	assert.Equal(t, uint64(10), trade.Messages)
+	assert.Equal(t, &latency.Stats{Count: 10, Last: 95, Min: 5, Mean: 50, P50: 45, P90: 85, P99: 95, Max: 95}, trade.Event)
+	assert.InDelta(t, 52, trade.Transaction.Mean, 1e-9)
+
+	// samples and skew estimate expire with the window
+	now = start.Add(11 * time.Second)
+	tr.Observe("trade", now.UnixMilli()-30, 0, now)
+
+	report = tr.Report()
+	require.NotNil(t, report.Skew)
+	assert.InDelta(t, 30, *report.Skew, 1e-9)
+	assert.Nil(t, report.Streams[0].Event)
+	assert.Equal(t, 1, report.Streams[1].Event.Count)
+	assert.Nil(t, report.Streams[1].Transaction)
+
+	var nilTracker *latency.Tracker
+	nilTracker.Observe("trade", 1, 1, now)
+}
+
+func TestTracker_Samples(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+
+	tr := latency.New().SetSamples(3).SetClock(func() time.Time { return now })
+
+	// local clock behind exchange clock gives negative delays
+	for _, delay := range []int64{-10, 20, 30, 40} {
+		tr.Observe("bookTicker", now.UnixMilli()-delay, 0, now)
+	}
+
+	report := tr.Report()
+	require.NotNil(t, report.Skew)
+	assert.InDelta(t, -10, *report.Skew, 1e-9)
+	assert.Equal(t, &latency.Stats{Count: 3, Last: 40, Min: 20, Mean: 30, P50: 30, P90: 40, P99: 40, Max: 40},
+		report.Streams[0].Event)
+}
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"fmt"
 	"sync"
 
 	"go.uber.org/zap"
@@ -14,6 +15,7 @@ type Logger struct {
 // Variables for the singleton logger instance and synchronization.
 var (
 	logger *Logger
+	level  zap.AtomicLevel
 	Once   sync.Once
 )
 
@@ -27,16 +29,17 @@ var DefaultBuildLogger BuildLoggerFunc = func(cfg zap.Config) (*zap.Logger, erro
 
 // NewLogger initializes and returns a singleton Logger instance with the specified log level.
 // The function panics if the provided log level is invalid.
-func NewLogger(level string, buildLogger BuildLoggerFunc) *Logger {
+func NewLogger(levelName string, buildLogger BuildLoggerFunc) *Logger {
 	Once.Do(func() {
 		// Parse the provided log level
-		lvl, err := zap.ParseAtomicLevel(level)
+		lvl, err := zap.ParseAtomicLevel(levelName)
 		if err != nil {
 			panic(err)
 		}
 
 		// Create a new zap logger configuration
 		cfg := zap.NewProductionConfig()
+		level = lvl
 		cfg.Level = lvl
 		cfg.Encoding = "console"
 		cfg.OutputPaths = []string{"stdout"}
@@ -54,3 +57,12 @@ func NewLogger(level string, buildLogger BuildLoggerFunc) *Logger {
 
 	return logger
 }
+
+// SetLevel changes the level of the singleton logger at runtime.
+func SetLevel(lvl string) error {
+	if logger == nil {
+		return fmt.Errorf("logger is not initialized")
+	}
+
+	return level.UnmarshalText([]byte(lvl))
+}
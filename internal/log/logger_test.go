@@ -179,3 +179,16 @@ func TestLoggerBuildError(t *testing.T) {
 	log.Once = sync.Once{} // Reset the once variable
 	_ = log.NewLogger("info", buildErrorFunc)
 }
+
+func TestSetLevel(t *testing.T) {
+	log.Once = sync.Once{} // Reset the once variable
+
+	logger := log.NewLogger("info", log.DefaultBuildLogger)
+	require.False(t, logger.Desugar().Core().Enabled(zap.DebugLevel))
+
+	require.NoError(t, log.SetLevel("debug"))
+	require.True(t, logger.Desugar().Core().Enabled(zap.DebugLevel))
+
+	require.Error(t, log.SetLevel("loud"))
+	require.NoError(t, log.SetLevel("info"))
+}
@@ -0,0 +1,186 @@
+// Package marketdata normalizes exchange stream messages into updates published to sinks.
+package marketdata
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/ole-larsen/binance-subscriber/internal/poller"
+)
+
+// Update types.
+const (
+	TypeBBO   = "bbo"
+	TypeTrade = "trade"
+	TypeDepth = "depth"
+)
+
+// Level is a price level of order book.
+type Level struct {
+	Price    string `json:"price"`
+	Quantity string `json:"quantity"`
+}
+
+// BBO is the best bid and offer, Time is transaction time in milliseconds, zero if unknown.
+type BBO struct {
+	Bid         string `json:"bid"`
+	BidQuantity string `json:"bid_quantity"`
+	Ask         string `json:"ask"`
+	AskQuantity string `json:"ask_quantity"`
+	UpdateID    int64  `json:"update_id"`
+	Time        int64  `json:"time,omitempty"`
+}
+
+// Trade is an executed trade, ID of aggregated trade is the aggregate trade id.
+type Trade struct {
+	Price      string `json:"price"`
+	Quantity   string `json:"quantity"`
+	ID         int64  `json:"id"`
+	Time       int64  `json:"time"`
+	BuyerMaker bool   `json:"buyer_maker"`
+}
+
+// Depth is an order book delta, zero quantity removes price level. Time is transaction time
+// in milliseconds, zero if unknown.
+type Depth struct {
+	Bids          []Level `json:"bids"`
+	Asks          []Level `json:"asks"`
+	FirstUpdateID int64   `json:"first_update_id"`
+	FinalUpdateID int64   `json:"final_update_id"`
+	Time          int64   `json:"time,omitempty"`
+}
+
+// Update is a normalized market data update, exactly one of BBO, Trade and Depth is set
+// according to Type. Time is exchange event time in milliseconds, zero if unknown.
+type Update struct {
+	BBO    *BBO   `json:"bbo,omitempty"`
+	Trade  *Trade `json:"trade,omitempty"`
+	Depth  *Depth `json:"depth,omitempty"`
+	Type   string `json:"type"`
+	Symbol string `json:"symbol"`
+	Time   int64  `json:"time,omitempty"`
+}
+
+// TransactionTime returns exchange transaction time of update in milliseconds, zero if unknown.
+func (u *Update) TransactionTime() int64 {
+	switch {
+	case u.BBO != nil:
+		return u.BBO.Time
+	case u.Trade != nil:
+		return u.Trade.Time
+	case u.Depth != nil:
+		return u.Depth.Time
+	}
+
+	return 0
+}
+
+// Normalize decodes combined stream message. Nil update is returned for unsupported
+// streams and messages without symbol, e.g. partial book depth.
+func Normalize(msg *poller.Message) (*Update, error) {
+	kind := poller.StreamType(msg.Stream)
+
+	switch {
+	case kind == "bookTicker":
+		var ticker poller.BookTicker
+
+		if err := json.Unmarshal(msg.Data, &ticker); err != nil {
+			return nil, err
+		}
+
+		return symbolUpdate(&Update{
+			Type:   TypeBBO,
+			Symbol: ticker.Symbol,
+			Time:   ticker.EventTime,
+			BBO: &BBO{
+				Bid:         ticker.Bid,
+				BidQuantity: ticker.BidQty,
+				Ask:         ticker.Ask,
+				AskQuantity: ticker.AskQty,
+				UpdateID:    ticker.UpdateID,
+				Time:        ticker.TransactionTime,
+			},
+		}), nil
+	case kind == "trade":
+		var trade poller.Trade
+
+		if err := json.Unmarshal(msg.Data, &trade); err != nil {
+			return nil, err
+		}
+
+		return symbolUpdate(&Update{
+			Type:   TypeTrade,
+			Symbol: trade.Symbol,
+			Time:   trade.EventTime,
+			Trade: &Trade{
+				Price:      trade.Price,
+				Quantity:   trade.Quantity,
+				ID:         trade.ID,
+				Time:       trade.TradeTime,
+				BuyerMaker: trade.BuyerMaker,
+			},
+		}), nil
+	case kind == "aggTrade":
+		var trade poller.AggTrade
+
+		if err := json.Unmarshal(msg.Data, &trade); err != nil {
+			return nil, err
+		}
+
+		return symbolUpdate(&Update{
+			Type:   TypeTrade,
+			Symbol: trade.Symbol,
+			Time:   trade.EventTime,
+			Trade: &Trade{
+				Price:      trade.Price,
+				Quantity:   trade.Quantity,
+				ID:         trade.ID,
+				Time:       trade.TradeTime,
+				BuyerMaker: trade.BuyerMaker,
+			},
+		}), nil
+	case strings.HasPrefix(kind, "depth"):
+		var depth poller.DepthUpdate
+
+		if err := json.Unmarshal(msg.Data, &depth); err != nil {
+			return nil, err
+		}
+
+		return symbolUpdate(&Update{
+			Type:   TypeDepth,
+			Symbol: depth.Symbol,
+			Time:   depth.EventTime,
+			Depth: &Depth{
+				Bids:          levels(depth.Bids),
+				Asks:          levels(depth.Asks),
+				FirstUpdateID: depth.FirstUpdateID,
+				FinalUpdateID: depth.FinalUpdateID,
+				Time:          depth.Time,
+			},
+		}), nil
+	}
+
+	return nil, nil //nolint:nilnil // explanation: unsupported stream is not an error
+}
+
+func symbolUpdate(u *Update) *Update {
+	if u.Symbol == "" {
+		return nil
+	}
+
+	return u
+}
+
+func levels(raw [][]string) []Level {
+	res := make([]Level, 0, len(raw))
+
+	for _, l := range raw {
+		if len(l) < 2 {
+			continue
+		}
+
+		res = append(res, Level{Price: l[0], Quantity: l[1]})
+	}
+
+	return res
+}
@@ -0,0 +1,158 @@
+package marketdata_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+	"github.com/ole-larsen/binance-subscriber/internal/poller"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		want    *marketdata.Update
+		name    string
+		stream  string
+		data    string
+		wantErr bool
+	}{
+		{
+			name:   "book ticker",
+			stream: "btcusdt@bookTicker",
+			data:   `{"u":400900217,"s":"BTCUSDT","b":"25.35","B":"31.21","a":"25.36","A":"40.66"}`,
+			want: &marketdata.Update{
+				Type:   marketdata.TypeBBO,
+				Symbol: "BTCUSDT",
+				BBO: &marketdata.BBO{
+					Bid: "25.35", BidQuantity: "31.21", Ask: "25.36", AskQuantity: "40.66", UpdateID: 400900217,
+				},
+			},
+		},
+		{
+			name:   "futures book ticker",
+			stream: "btcusdt@bookTicker",
+			data: `{"e":"bookTicker","u":400900217,"E":1568014460893,"T":1568014460891,"s":"BTCUSDT",` +
+				`"b":"25.35","B":"31.21","a":"25.36","A":"40.66"}`,
+			want: &marketdata.Update{
+				Type:   marketdata.TypeBBO,
+				Symbol: "BTCUSDT",
+				Time:   1568014460893,
+				BBO: &marketdata.BBO{
+					Bid: "25.35", BidQuantity: "31.21", Ask: "25.36", AskQuantity: "40.66", UpdateID: 400900217,
+					Time: 1568014460891,
+				},
+			},
+		},
+		{
+			name:   "trade",
+			stream: "btcusdt@trade",
+			data: `{"e":"trade","E":1672515782136,"s":"BTCUSDT","t":12345,"p":"0.001","q":"100",` +
+				`"T":1672515782134,"m":true,"M":true}`,
+			want: &marketdata.Update{
+				Type:   marketdata.TypeTrade,
+				Symbol: "BTCUSDT",
+				Time:   1672515782136,
+				Trade: &marketdata.Trade{
+					Price: "0.001", Quantity: "100", ID: 12345, Time: 1672515782134, BuyerMaker: true,
+				},
+			},
+		},
+		{
+			name:   "aggregate trade",
+			stream: "btcusdt@aggTrade",
+			data: `{"e":"aggTrade","E":1672515782136,"s":"BTCUSDT","a":12345,"p":"0.001","q":"100",` +
+				`"f":100,"l":105,"T":1672515782134,"m":false,"M":true}`,
+			want: &marketdata.Update{
+				Type:   marketdata.TypeTrade,
+				Symbol: "BTCUSDT",
+				Time:   1672515782136,
+				Trade: &marketdata.Trade{
+					Price: "0.001", Quantity: "100", ID: 12345, Time: 1672515782134,
+				},
+			},
+		},
+		{
+			name:   "depth",
+			stream: "btcusdt@depth@100ms",
+			data: `{"e":"depthUpdate","E":1672515782136,"s":"BTCUSDT","U":157,"u":160,` +
+				`"b":[["0.0024","10"]],"a":[["0.0026","100"],["0.0027"]]}`,
+			want: &marketdata.Update{
+				Type:   marketdata.TypeDepth,
+				Symbol: "BTCUSDT",
+				Time:   1672515782136,
+				Depth: &marketdata.Depth{
+					Bids:          []marketdata.Level{{Price: "0.0024", Quantity: "10"}},
+					Asks:          []marketdata.Level{{Price: "0.0026", Quantity: "100"}},
+					FirstUpdateID: 157,
+					FinalUpdateID: 160,
+				},
+			},
+		},
+		{
+			name:   "futures depth",
+			stream: "btcusdt@depth@100ms",
+			data: `{"e":"depthUpdate","E":1672515782136,"T":1672515782130,"s":"BTCUSDT","U":157,"u":160,` +
+				`"pu":149,"b":[],"a":[]}`,
+			want: &marketdata.Update{
+				Type:   marketdata.TypeDepth,
+				Symbol: "BTCUSDT",
+				Time:   1672515782136,
+				Depth: &marketdata.Depth{
+					Bids:          []marketdata.Level{},
+					Asks:          []marketdata.Level{},
+					FirstUpdateID: 157,
+					FinalUpdateID: 160,
+					Time:          1672515782130,
+				},
+			},
+		},
+		{
+			name:   "partial depth without symbol",
+			stream: "btcusdt@depth5",
+			data:   `{"lastUpdateId":160,"bids":[["0.0024","10"]],"asks":[["0.0026","100"]]}`,
+		},
+		{
+			name:   "unsupported stream",
+			stream: "btcusdt@kline_1m",
+			data:   `{"e":"kline","s":"BTCUSDT"}`,
+		},
+		{
+			name:    "invalid data",
+			stream:  "btcusdt@trade",
+			data:    `{"p":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update, err := marketdata.Normalize(&poller.Message{Stream: tt.stream, Data: []byte(tt.data)})
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, update)
+		})
+	}
+}
+
+func TestUpdate_TransactionTime(t *testing.T) {
+	tests := []struct {
+		name   string
+		update marketdata.Update
+		want   int64
+	}{
+		{name: "bbo", update: marketdata.Update{BBO: &marketdata.BBO{Time: 1}}, want: 1},
+		{name: "trade", update: marketdata.Update{Trade: &marketdata.Trade{Time: 2}}, want: 2},
+		{name: "depth", update: marketdata.Update{Depth: &marketdata.Depth{Time: 3}}, want: 3},
+		{name: "unknown", update: marketdata.Update{Time: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.update.TransactionTime())
+		})
+	}
+}
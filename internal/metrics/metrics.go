@@ -0,0 +1,165 @@
+// Package metrics exposes Prometheus metrics of the service on the admin listener.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "binance_subscriber"
+
+// Registry holds every metric of the service together with Go runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// WSClients is the number of connected /ws clients.
+	WSClients = promauto.With(Registry).NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ws_clients",
+		Help:      "Connected websocket clients.",
+	})
+
+	// StreamClients is the number of connected Server-Sent Events clients.
+	StreamClients = promauto.With(Registry).NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stream_clients",
+		Help:      "Connected Server-Sent Events clients.",
+	})
+
+	// RateLimited counts rejected requests, connections and closed websockets by reason.
+	RateLimited = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by rate limits.",
+	}, []string{"reason"})
+
+	// SkippedQuotes counts quotes left out of websocket frames by codec, e.g. symbols too long for bbo.
+	SkippedQuotes = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "skipped_quotes_total",
+		Help:      "Quotes the websocket codec could not encode.",
+	}, []string{"codec"})
+
+	// UpstreamMessages counts messages received from the exchange by stream type.
+	UpstreamMessages = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_messages_total",
+		Help:      "Messages received from upstream.",
+	}, []string{"stream"})
+
+	// UpstreamDelay observes delay of upstream messages from exchange time to local receive
+	// by stream type and time: event or transaction.
+	UpstreamDelay = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_delay_seconds",
+		Help:      "Delay from exchange event and transaction times to local receive.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	}, []string{"stream", "time"})
+
+	// ClockSkew is the estimated offset of the local clock from the exchange clock.
+	ClockSkew = promauto.With(Registry).NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "upstream_clock_skew_seconds",
+		Help:      "Estimated local clock offset from exchange clock, minimum event delay over the window.",
+	})
+
+	// MalformedMessages counts upstream messages skipped by ingest stage: read, decode or apply.
+	MalformedMessages = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "malformed_messages_total",
+		Help:      "Malformed upstream messages skipped by ingest.",
+	}, []string{"stage"})
+
+	// IngestDropped counts upstream messages dropped by full ingest queue of stage: decode or apply.
+	IngestDropped = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingest_dropped_total",
+		Help:      "Upstream messages dropped by full ingest queues.",
+	}, []string{"stage"})
+
+	// SinkMessages counts market data messages of sinks by result: published, failed or dropped.
+	SinkMessages = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sink_messages_total",
+		Help:      "Market data messages handled by sinks.",
+	}, []string{"sink", "result"})
+
+	// SinkQueue is the number of messages waiting for delivery by sink.
+	SinkQueue = promauto.With(Registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sink_queue_messages",
+		Help:      "Messages waiting for delivery.",
+	}, []string{"sink"})
+
+	// Alerts counts alerts by status: firing or resolved.
+	Alerts = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alerts_total",
+		Help:      "Alerts of price rules.",
+	}, []string{"status"})
+
+	// AlertNotifications counts alert notifications by result: delivered, failed, dropped or logged.
+	AlertNotifications = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alert_notifications_total",
+		Help:      "Alert notifications.",
+	}, []string{"result"})
+
+	// OrderBookSnapshots counts order book snapshots by result: fetched, failed or stale.
+	OrderBookSnapshots = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orderbook_snapshots_total",
+		Help:      "Order book snapshots.",
+	}, []string{"result"})
+
+	// OrderBookGaps counts depth updates not following the previous ones.
+	OrderBookGaps = promauto.With(Registry).NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orderbook_gaps_total",
+		Help:      "Gaps of order book depth updates.",
+	})
+
+	// ArbitrageOpportunities counts opened arbitrage opportunities by kind: triangle or pair.
+	ArbitrageOpportunities = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "arbitrage_opportunities_total",
+		Help:      "Opened arbitrage opportunities.",
+	}, []string{"kind"})
+
+	// StaleSymbols is the number of symbols that stopped updating.
+	StaleSymbols = promauto.With(Registry).NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stale_symbols",
+		Help:      "Symbols without updates for their staleness threshold.",
+	})
+
+	// StalenessEvents counts symbols going stale and recovering by status: stale or recovered.
+	StalenessEvents = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "staleness_events_total",
+		Help:      "Symbols that went stale or recovered.",
+	}, []string{"status"})
+
+	// Reloads counts config reloads by result.
+	Reloads = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_reloads_total",
+		Help:      "Config reloads.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves metrics in Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
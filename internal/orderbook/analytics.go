@@ -0,0 +1,165 @@
+package orderbook
+
+const bps = 10_000
+
+// Analytics are liquidity signals of a book. Imbalance is (bid - ask) / (bid + ask) volume
+// of the top levels, from -1 (asks only) to 1 (bids only). Microprice is mid weighted by
+// the opposite best level quantities.
+type Analytics struct {
+	Depth      []Band     `json:"depth"`
+	Slippage   []Slippage `json:"slippage"`
+	BestBid    float64    `json:"best_bid"`
+	BestAsk    float64    `json:"best_ask"`
+	Mid        float64    `json:"mid"`
+	Spread     float64    `json:"spread"`
+	Microprice float64    `json:"microprice"`
+	Imbalance  float64    `json:"imbalance"`
+}
+
+// Band is cumulative liquidity of levels within BPS basis points of mid.
+type Band struct {
+	BPS         float64 `json:"bps"`
+	BidQuantity float64 `json:"bid_quantity"`
+	AskQuantity float64 `json:"ask_quantity"`
+	BidNotional float64 `json:"bid_notional"`
+	AskNotional float64 `json:"ask_notional"`
+}
+
+// Slippage estimates market orders of Notional quote volume: average fill prices and their
+// distance from mid in basis points. Prices are zero when the side is not deep enough.
+type Slippage struct {
+	Notional  float64 `json:"notional"`
+	BuyPrice  float64 `json:"buy_price"`
+	BuyBPS    float64 `json:"buy_bps"`
+	SellPrice float64 `json:"sell_price"`
+	SellBPS   float64 `json:"sell_bps"`
+}
+
+// analyze computes analytics of book, nil is returned while any side is empty.
+func (b *Books) analyze(bk *book) *Analytics {
+	if len(bk.bids.levels) == 0 || len(bk.asks.levels) == 0 {
+		return nil
+	}
+
+	bid, ask := bk.bids.levels[0], bk.asks.levels[0]
+
+	a := &Analytics{
+		BestBid:  bid.price,
+		BestAsk:  ask.price,
+		Mid:      (bid.price + ask.price) / 2,
+		Spread:   ask.price - bid.price,
+		Depth:    make([]Band, 0, len(b.bands)),
+		Slippage: make([]Slippage, 0, len(b.notionals)),
+	}
+
+	a.Microprice = (bid.price*ask.quantity + ask.price*bid.quantity) / (bid.quantity + ask.quantity)
+
+	bidVolume, askVolume := bk.bids.volume(b.imbalanceLevels), bk.asks.volume(b.imbalanceLevels)
+	if bidVolume+askVolume > 0 {
+		a.Imbalance = (bidVolume - askVolume) / (bidVolume + askVolume)
+	}
+
+	bids, asks := bk.bids.bands(a.Mid, b.bands), bk.asks.bands(a.Mid, b.bands)
+
+	for i, width := range b.bands {
+		a.Depth = append(a.Depth, Band{
+			BPS:         width,
+			BidQuantity: bids[i].quantity,
+			AskQuantity: asks[i].quantity,
+			BidNotional: bids[i].notional,
+			AskNotional: asks[i].notional,
+		})
+	}
+
+	for _, notional := range b.notionals {
+		s := Slippage{Notional: notional}
+
+		if price, ok := bk.asks.fill(notional); ok {
+			s.BuyPrice = price
+			s.BuyBPS = (price - a.Mid) / a.Mid * bps
+		}
+
+		if price, ok := bk.bids.fill(notional); ok {
+			s.SellPrice = price
+			s.SellBPS = (a.Mid - price) / a.Mid * bps
+		}
+
+		a.Slippage = append(a.Slippage, s)
+	}
+
+	return a
+}
+
+// liquidity is cumulative quantity and quote volume.
+type liquidity struct {
+	quantity float64
+	notional float64
+}
+
+// volume sums quantities of n best levels.
+func (s *side) volume(n int) float64 {
+	var v float64
+
+	for i := 0; i < n && i < len(s.levels); i++ {
+		v += s.levels[i].quantity
+	}
+
+	return v
+}
+
+// bands sums liquidity within every width of sorted widths in basis points from mid.
+func (s *side) bands(mid float64, widths []float64) []liquidity {
+	res := make([]liquidity, len(widths))
+
+	var sum liquidity
+
+	i := 0
+
+	for _, l := range s.levels {
+		distance := (l.price - mid) / mid * bps
+		if s.desc {
+			distance = -distance
+		}
+
+		for i < len(widths) && distance > widths[i] {
+			res[i] = sum
+			i++
+		}
+
+		if i == len(widths) {
+			break
+		}
+
+		sum.quantity += l.quantity
+		sum.notional += l.price * l.quantity
+	}
+
+	for ; i < len(widths); i++ {
+		res[i] = sum
+	}
+
+	return res
+}
+
+// fill returns average price of market order of notional quote volume walking levels
+// from the best one, false if the side is not deep enough.
+func (s *side) fill(notional float64) (float64, bool) {
+	var quantity float64
+
+	remaining := notional
+
+	for _, l := range s.levels {
+		if available := l.price * l.quantity; available < remaining {
+			remaining -= available
+			quantity += l.quantity
+
+			continue
+		}
+
+		quantity += remaining / l.price
+
+		return notional / quantity, true
+	}
+
+	return 0, false
+}
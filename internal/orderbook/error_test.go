@@ -0,0 +1,22 @@
+package orderbook_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/orderbook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	require.NoError(t, orderbook.NewError(nil))
+
+	stdErr := errors.New("something went wrong")
+	err := orderbook.NewError(stdErr)
+
+	var orderbookErr *orderbook.Error
+	require.True(t, errors.As(err, &orderbookErr))
+	assert.Equal(t, "[orderbook]: something went wrong", orderbookErr.Error())
+	assert.True(t, errors.Is(err, stdErr))
+}
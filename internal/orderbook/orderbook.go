@@ -0,0 +1,457 @@
+// Package orderbook maintains local order books of depth streams and computes liquidity
+// signals of every book on each depth update.
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/ole-larsen/binance-subscriber/internal/fanout"
+	"github.com/ole-larsen/binance-subscriber/internal/log"
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+)
+
+const (
+	// DefaultMaxLevels is the number of price levels kept per book side.
+	DefaultMaxLevels = 1000
+	// DefaultImbalanceLevels is the number of top levels of volume imbalance.
+	DefaultImbalanceLevels = 5
+
+	// maxPending bounds depth updates buffered while book waits for snapshot.
+	maxPending = 1000
+	// maxRequests bounds symbols waiting for snapshot.
+	maxRequests = 1024
+)
+
+var (
+	// DefaultBands are distances from mid in basis points of cumulative liquidity.
+	DefaultBands = []float64{10, 25, 50, 100}
+	// DefaultNotionals are quote volumes of slippage estimates.
+	DefaultNotionals = []float64{10_000, 100_000}
+)
+
+// SnapshotFunc fetches order book snapshot of symbol, FinalUpdateID is its last update id.
+type SnapshotFunc func(ctx context.Context, symbol string) (*marketdata.Depth, error)
+
+// Snapshot is the top of a book with its analytics. Synced is false until the book is
+// synchronized with snapshot, books built from depth updates only are never synced.
+type Snapshot struct {
+	Analytics *Analytics         `json:"analytics,omitempty"`
+	Symbol    string             `json:"symbol"`
+	Bids      []marketdata.Level `json:"bids"`
+	Asks      []marketdata.Level `json:"asks"`
+	UpdateID  int64              `json:"update_id"`
+	Synced    bool               `json:"synced"`
+}
+
+// Event is analytics of a book published to subscribers after every applied update.
+type Event struct {
+	Analytics *Analytics `json:"analytics"`
+	Symbol    string     `json:"symbol"`
+	UpdateID  int64      `json:"update_id"`
+}
+
+// Subscriber receives events of its symbols published after Subscribe.
+type Subscriber = fanout.Subscriber[Event]
+
+// Books keeps order books of symbols. With snapshot function a book buffers depth updates
+// until snapshot is fetched by Run and is resynchronized on update id gaps, otherwise books
+// are built from depth updates only. Analytics of every update are published to subscribers.
+type Books struct {
+	*fanout.Fanout[Event]
+	books           map[string]*book
+	snapshot        SnapshotFunc
+	requests        chan string
+	logger          *log.Logger
+	bands           []float64
+	notionals       []float64
+	maxLevels       int
+	imbalanceLevels int
+	mx              sync.Mutex
+}
+
+// book is the state of a symbol.
+type book struct {
+	analytics *Analytics
+	bids      side
+	asks      side
+	pending   []update
+	updateID  int64
+	synced    bool
+	requested bool
+}
+
+// update is a parsed depth update.
+type update struct {
+	bids  []level
+	asks  []level
+	first int64
+	final int64
+}
+
+func New() *Books {
+	return &Books{
+		Fanout:          fanout.New[Event](),
+		books:           make(map[string]*book),
+		requests:        make(chan string, maxRequests),
+		bands:           DefaultBands,
+		notionals:       DefaultNotionals,
+		maxLevels:       DefaultMaxLevels,
+		imbalanceLevels: DefaultImbalanceLevels,
+	}
+}
+
+// SetSnapshot sets source of snapshots, nil builds books from depth updates only.
+func (b *Books) SetSnapshot(f SnapshotFunc) *Books {
+	b.snapshot = f
+	return b
+}
+
+// SetMaxLevels sets number of price levels kept per side, the farthest ones are forgotten.
+func (b *Books) SetMaxLevels(n int) *Books {
+	b.maxLevels = max(n, 1)
+	return b
+}
+
+// SetImbalanceLevels sets number of top levels of volume imbalance.
+func (b *Books) SetImbalanceLevels(n int) *Books {
+	b.imbalanceLevels = max(n, 1)
+	return b
+}
+
+// SetBands sets distances from mid in basis points of cumulative liquidity, they are sorted.
+func (b *Books) SetBands(bps []float64) *Books {
+	b.bands = sorted(bps)
+	return b
+}
+
+// SetNotionals sets quote volumes of slippage estimates, they are sorted.
+func (b *Books) SetNotionals(notionals []float64) *Books {
+	b.notionals = sorted(notionals)
+	return b
+}
+
+// SetLogger sets logger of failed snapshots.
+func (b *Books) SetLogger(l *log.Logger) *Books {
+	b.logger = l
+	return b
+}
+
+// Apply applies depth update of symbol, updates already included in the book are ignored.
+func (b *Books) Apply(symbol string, depth *marketdata.Depth) error {
+	if b == nil {
+		return nil
+	}
+
+	u, err := parse(depth)
+	if err != nil {
+		return NewError(fmt.Errorf("depth %s %d: %w", symbol, depth.FinalUpdateID, err))
+	}
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	bk, ok := b.books[symbol]
+	if !ok {
+		bk = &book{bids: side{desc: true}}
+		b.books[symbol] = bk
+	}
+
+	if b.snapshot == nil {
+		if u.final != 0 && u.final <= bk.updateID {
+			return nil
+		}
+
+		if u.first != 0 && bk.updateID != 0 && u.first > bk.updateID+1 {
+			metrics.OrderBookGaps.Inc()
+		}
+
+		b.apply(symbol, bk, &u)
+
+		return nil
+	}
+
+	if !bk.synced {
+		bk.queue(u)
+		b.request(symbol, bk)
+
+		return nil
+	}
+
+	if u.final <= bk.updateID {
+		return nil
+	}
+
+	if u.first > bk.updateID+1 {
+		// missed updates, the book is rebuilt from a fresh snapshot
+		metrics.OrderBookGaps.Inc()
+
+		bk.reset()
+		bk.queue(u)
+		b.request(symbol, bk)
+
+		return nil
+	}
+
+	b.apply(symbol, bk, &u)
+
+	return nil
+}
+
+// Run fetches snapshots of books waiting for them until ctx is done, failed snapshots
+// are requested again by the next depth update.
+func (b *Books) Run(ctx context.Context) {
+	if b == nil || b.snapshot == nil {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case symbol := <-b.requests:
+			depth, err := b.snapshot(ctx, symbol)
+			if err != nil {
+				metrics.OrderBookSnapshots.WithLabelValues("failed").Inc()
+
+				if b.logger != nil {
+					b.logger.Errorw("order book snapshot failed", "symbol", symbol, "error", err)
+				}
+
+				b.fail(symbol)
+
+				continue
+			}
+
+			if err = b.sync(symbol, depth); err != nil && b.logger != nil {
+				b.logger.Errorw("order book snapshot failed", "symbol", symbol, "error", err)
+			}
+		}
+	}
+}
+
+// Snapshot returns up to levels top levels of every side of symbol book, zero levels
+// returns every kept level. False is returned for symbol without depth updates.
+func (b *Books) Snapshot(symbol string, levels int) (*Snapshot, bool) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	bk, ok := b.books[symbol]
+	if !ok {
+		return nil, false
+	}
+
+	return &Snapshot{
+		Symbol:    symbol,
+		UpdateID:  bk.updateID,
+		Synced:    bk.synced,
+		Bids:      bk.bids.top(levels),
+		Asks:      bk.asks.top(levels),
+		Analytics: bk.analytics,
+	}, true
+}
+
+// Analytics returns the latest analytics of symbol book, nil while any side is empty.
+func (b *Books) Analytics(symbol string) (*Analytics, int64, bool) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	bk, ok := b.books[symbol]
+	if !ok {
+		return nil, 0, false
+	}
+
+	return bk.analytics, bk.updateID, true
+}
+
+// Symbols returns sorted symbols with books.
+func (b *Books) Symbols() []string {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	symbols := make([]string, 0, len(b.books))
+	for symbol := range b.books {
+		symbols = append(symbols, symbol)
+	}
+
+	sort.Strings(symbols)
+
+	return symbols
+}
+
+// sync replaces book of symbol with snapshot and applies buffered updates following it.
+func (b *Books) sync(symbol string, depth *marketdata.Depth) error {
+	snapshot, err := parse(depth)
+	if err != nil {
+		metrics.OrderBookSnapshots.WithLabelValues("failed").Inc()
+		b.fail(symbol)
+		return NewError(fmt.Errorf("snapshot %s %d: %w", symbol, depth.FinalUpdateID, err))
+	}
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	bk, ok := b.books[symbol]
+	if !ok {
+		return nil
+	}
+
+	bk.requested = false
+	bk.reset()
+
+	pending := bk.pending
+	bk.pending = nil
+
+	for len(pending) > 0 && pending[0].final <= snapshot.final {
+		pending = pending[1:]
+	}
+
+	if len(pending) > 0 && pending[0].first > snapshot.final+1 {
+		// snapshot is older than buffered updates
+		metrics.OrderBookSnapshots.WithLabelValues("stale").Inc()
+
+		bk.pending = pending
+		b.request(symbol, bk)
+
+		return nil
+	}
+
+	for _, l := range snapshot.bids {
+		bk.bids.set(l)
+	}
+
+	for _, l := range snapshot.asks {
+		bk.asks.set(l)
+	}
+
+	metrics.OrderBookSnapshots.WithLabelValues("fetched").Inc()
+
+	bk.updateID = snapshot.final
+	bk.synced = true
+
+	for i := range pending {
+		bk.bids.update(pending[i].bids)
+		bk.asks.update(pending[i].asks)
+		bk.updateID = pending[i].final
+	}
+
+	b.publish(symbol, bk)
+
+	return nil
+}
+
+// fail allows the next depth update of symbol to request snapshot again.
+func (b *Books) fail(symbol string) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	if bk, ok := b.books[symbol]; ok {
+		bk.requested = false
+	}
+}
+
+// request queues symbol for snapshot once, it is retried by the next update if the queue is full.
+func (b *Books) request(symbol string, bk *book) {
+	if bk.requested {
+		return
+	}
+
+	select {
+	case b.requests <- symbol:
+		bk.requested = true
+	default:
+	}
+}
+
+// apply applies update to book and publishes its analytics.
+func (b *Books) apply(symbol string, bk *book, u *update) {
+	bk.bids.update(u.bids)
+	bk.asks.update(u.asks)
+
+	if u.final != 0 {
+		bk.updateID = u.final
+	}
+
+	b.publish(symbol, bk)
+}
+
+// publish trims book, computes its analytics and sends them to subscribers without blocking.
+func (b *Books) publish(symbol string, bk *book) {
+	bk.bids.trim(b.maxLevels)
+	bk.asks.trim(b.maxLevels)
+	bk.analytics = b.analyze(bk)
+
+	b.Publish(symbol, Event{Symbol: symbol, UpdateID: bk.updateID, Analytics: bk.analytics})
+}
+
+// queue buffers update until snapshot, the oldest updates are dropped when buffer is full.
+func (bk *book) queue(u update) {
+	if len(bk.pending) == maxPending {
+		bk.pending = bk.pending[1:]
+	}
+
+	bk.pending = append(bk.pending, u)
+}
+
+// reset forgets levels and analytics until the book is synchronized again.
+func (bk *book) reset() {
+	bk.bids.levels = bk.bids.levels[:0]
+	bk.asks.levels = bk.asks.levels[:0]
+	bk.analytics = nil
+	bk.synced = false
+}
+
+// parse parses prices and quantities of depth levels.
+func parse(depth *marketdata.Depth) (update, error) {
+	u := update{first: depth.FirstUpdateID, final: depth.FinalUpdateID}
+
+	var err error
+
+	if u.bids, err = parseLevels(depth.Bids); err != nil {
+		return u, err
+	}
+
+	if u.asks, err = parseLevels(depth.Asks); err != nil {
+		return u, err
+	}
+
+	return u, nil
+}
+
+func parseLevels(raw []marketdata.Level) ([]level, error) {
+	levels := make([]level, 0, len(raw))
+
+	for _, l := range raw {
+		price, err := strconv.ParseFloat(l.Price, 64)
+		if err != nil {
+			return nil, fmt.Errorf("price %q: %w", l.Price, err)
+		}
+
+		quantity, err := strconv.ParseFloat(l.Quantity, 64)
+		if err != nil {
+			return nil, fmt.Errorf("quantity %q: %w", l.Quantity, err)
+		}
+
+		levels = append(levels, level{price: price, quantity: quantity, raw: l})
+	}
+
+	return levels, nil
+}
+
+func sorted(values []float64) []float64 {
+	res := make([]float64, 0, len(values))
+
+	for _, v := range values {
+		if v > 0 {
+			res = append(res, v)
+		}
+	}
+
+	sort.Float64s(res)
+
+	return res
+}
@@ -0,0 +1,249 @@
+package orderbook_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+	"github.com/ole-larsen/binance-subscriber/internal/orderbook"
+)
+
+func levels(pairs ...string) []marketdata.Level {
+	res := make([]marketdata.Level, 0, len(pairs)/2)
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		res = append(res, marketdata.Level{Price: pairs[i], Quantity: pairs[i+1]})
+	}
+
+	return res
+}
+
+func depth(first, final int64, bids, asks []marketdata.Level) *marketdata.Depth {
+	return &marketdata.Depth{Bids: bids, Asks: asks, FirstUpdateID: first, FinalUpdateID: final}
+}
+
+func TestBooks_Apply(t *testing.T) {
+	books := orderbook.New().SetMaxLevels(3)
+
+	_, ok := books.Snapshot("BTCUSDT", 0)
+	assert.False(t, ok)
+
+	require.NoError(t, books.Apply("BTCUSDT", depth(1, 2,
+		levels("100", "1", "98", "3", "99", "2", "97", "1"),
+		levels("101", "1")),
+	))
+
+	// levels beyond max levels are forgotten
+	snapshot, ok := books.Snapshot("BTCUSDT", 0)
+	require.True(t, ok)
+	assert.Equal(t, &orderbook.Snapshot{
+		Symbol:    "BTCUSDT",
+		UpdateID:  2,
+		Bids:      levels("100", "1", "99", "2", "98", "3"),
+		Asks:      levels("101", "1"),
+		Analytics: snapshot.Analytics,
+	}, snapshot)
+	require.NotNil(t, snapshot.Analytics)
+
+	// zero quantity removes level, update already applied is ignored
+	require.NoError(t, books.Apply("BTCUSDT", depth(3, 4, levels("100", "0", "99", "5"), levels("100.5", "2"))))
+	require.NoError(t, books.Apply("BTCUSDT", depth(1, 2, levels("100", "7"), nil)))
+
+	snapshot, _ = books.Snapshot("BTCUSDT", 1)
+	assert.Equal(t, levels("99", "5"), snapshot.Bids)
+	assert.Equal(t, levels("100.5", "2"), snapshot.Asks)
+	assert.Equal(t, int64(4), snapshot.UpdateID)
+
+	// books are built from updates without ids too
+	require.NoError(t, books.Apply("ETHUSDT", depth(0, 0, levels("3000", "1"), nil)))
+
+	analytics, _, ok := books.Analytics("ETHUSDT")
+	require.True(t, ok)
+	assert.Nil(t, analytics)
+	assert.Equal(t, []string{"BTCUSDT", "ETHUSDT"}, books.Symbols())
+
+	require.ErrorContains(t, books.Apply("BTCUSDT", depth(5, 6, levels("high", "1"), nil)), `price "high"`)
+	require.ErrorContains(t, books.Apply("BTCUSDT", depth(5, 6, nil, levels("1", ""))), `quantity ""`)
+}
+
+func TestBooks_Analytics(t *testing.T) {
+	books := orderbook.New().
+		SetImbalanceLevels(2).
+		SetBands([]float64{200, 100}).
+		SetNotionals([]float64{10000, 200})
+
+	require.NoError(t, books.Apply("BTCUSDT", depth(1, 1,
+		levels("100", "1", "99", "2", "98", "3"),
+		levels("101", "3", "102", "2", "103", "4")),
+	))
+
+	a, updateID, ok := books.Analytics("BTCUSDT")
+	require.True(t, ok)
+	require.NotNil(t, a)
+	assert.Equal(t, int64(1), updateID)
+
+	assert.InDelta(t, 100.0, a.BestBid, 1e-9)
+	assert.InDelta(t, 101.0, a.BestAsk, 1e-9)
+	assert.InDelta(t, 100.5, a.Mid, 1e-9)
+	assert.InDelta(t, 1.0, a.Spread, 1e-9)
+	// best ask quantity pulls microprice towards the bid
+	assert.InDelta(t, 100.25, a.Microprice, 1e-9)
+	// (1 + 2 - 3 - 2) / 8
+	assert.InDelta(t, -0.25, a.Imbalance, 1e-9)
+
+	assert.Equal(t, []orderbook.Band{
+		{BPS: 100, BidQuantity: 1, AskQuantity: 3, BidNotional: 100, AskNotional: 303},
+		{BPS: 200, BidQuantity: 3, AskQuantity: 5, BidNotional: 298, AskNotional: 507},
+	}, a.Depth)
+
+	require.Len(t, a.Slippage, 2)
+	assert.InDelta(t, 200.0, a.Slippage[0].Notional, 1e-9)
+	assert.InDelta(t, 101.0, a.Slippage[0].BuyPrice, 1e-9)
+	assert.InDelta(t, 0.5/100.5*10000, a.Slippage[0].BuyBPS, 1e-9)
+	// 100 at 100 and 100 at 99
+	assert.InDelta(t, 200/(1+100.0/99), a.Slippage[0].SellPrice, 1e-9)
+	assert.InDelta(t, (100.5-200/(1+100.0/99))/100.5*10000, a.Slippage[0].SellBPS, 1e-9)
+	// sides are not deep enough
+	assert.Equal(t, orderbook.Slippage{Notional: 10000}, a.Slippage[1])
+}
+
+// snapshots returns queued snapshots one by one and records requested symbols.
+type snapshots struct {
+	queue []*marketdata.Depth
+	calls []string
+	mx    sync.Mutex
+}
+
+func (s *snapshots) get(_ context.Context, symbol string) (*marketdata.Depth, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.calls = append(s.calls, symbol)
+
+	if len(s.queue) == 0 {
+		return nil, assert.AnError
+	}
+
+	d := s.queue[0]
+	s.queue = s.queue[1:]
+
+	return d, nil
+}
+
+func (s *snapshots) push(d *marketdata.Depth) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.queue = append(s.queue, d)
+}
+
+func (s *snapshots) count() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return len(s.calls)
+}
+
+func synced(t *testing.T, books *orderbook.Books, updateID int64) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		s, ok := books.Snapshot("BTCUSDT", 0)
+		return ok && s.Synced && s.UpdateID == updateID
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestBooks_Sync(t *testing.T) {
+	source := &snapshots{}
+	books := orderbook.New().SetSnapshot(source.get)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	// updates are buffered until snapshot
+	require.NoError(t, books.Apply("BTCUSDT", depth(5, 8, levels("99", "1"), nil)))
+	require.NoError(t, books.Apply("BTCUSDT", depth(9, 12, levels("100", "2"), levels("102", "0"))))
+
+	s, ok := books.Snapshot("BTCUSDT", 0)
+	require.True(t, ok)
+	assert.False(t, s.Synced)
+	assert.Empty(t, s.Bids)
+
+	source.push(depth(0, 10, levels("100", "1", "98", "1"), levels("101", "1", "102", "1")))
+
+	go books.Run(ctx)
+
+	// update 5-8 is included in snapshot, 9-12 is applied on top of it
+	synced(t, books, 12)
+
+	s, _ = books.Snapshot("BTCUSDT", 0)
+	assert.Equal(t, levels("100", "2", "98", "1"), s.Bids)
+	assert.Equal(t, levels("101", "1"), s.Asks)
+	require.NotNil(t, s.Analytics)
+
+	require.NoError(t, books.Apply("BTCUSDT", depth(13, 13, nil, levels("101", "3"))))
+
+	s, _ = books.Snapshot("BTCUSDT", 0)
+	assert.Equal(t, int64(13), s.UpdateID)
+	assert.Equal(t, levels("101", "3"), s.Asks)
+
+	// gap resets book until a fresh snapshot, stale snapshot is requested again
+	source.push(depth(0, 15, levels("90", "1"), levels("91", "1")))
+	source.push(depth(0, 21, levels("95", "1"), levels("96", "1")))
+
+	require.NoError(t, books.Apply("BTCUSDT", depth(20, 22, levels("94", "1"), nil)))
+
+	synced(t, books, 22)
+
+	s, _ = books.Snapshot("BTCUSDT", 0)
+	assert.Equal(t, levels("95", "1", "94", "1"), s.Bids)
+	assert.Equal(t, levels("96", "1"), s.Asks)
+	assert.Equal(t, 3, source.count())
+
+	// failed snapshot is requested again by the next update
+	require.NoError(t, books.Apply("BTCUSDT", depth(30, 30, nil, nil)))
+	require.Eventually(t, func() bool { return source.count() == 4 }, time.Second, 5*time.Millisecond)
+
+	source.push(depth(0, 31, levels("97", "1"), levels("98", "1")))
+
+	require.Eventually(t, func() bool {
+		_ = books.Apply("BTCUSDT", depth(32, 32, nil, nil))
+
+		s, _ := books.Snapshot("BTCUSDT", 0)
+
+		return s.Synced
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestBooks_Subscribe(t *testing.T) {
+	books := orderbook.New()
+
+	all := books.Subscribe(nil, 0)
+	eth := books.Subscribe([]string{"ETHUSDT"}, 1)
+
+	require.NoError(t, books.Apply("BTCUSDT", depth(1, 1, levels("100", "1"), levels("101", "1"))))
+	require.NoError(t, books.Apply("ETHUSDT", depth(1, 1, levels("3000", "1"), nil)))
+	require.NoError(t, books.Apply("ETHUSDT", depth(2, 2, nil, levels("3001", "1"))))
+
+	event := <-all.Events()
+	assert.Equal(t, "BTCUSDT", event.Symbol)
+	assert.Equal(t, int64(1), event.UpdateID)
+	require.NotNil(t, event.Analytics)
+	assert.InDelta(t, 100.5, event.Analytics.Mid, 1e-9)
+
+	// analytics are nil while a side is empty
+	event = <-eth.Events()
+	assert.Equal(t, orderbook.Event{Symbol: "ETHUSDT", UpdateID: 1}, event)
+	assert.Equal(t, uint64(1), books.Dropped(eth))
+
+	books.Unsubscribe(eth)
+	books.Unsubscribe(eth)
+
+	_, ok := <-eth.Events()
+	assert.False(t, ok)
+}
@@ -0,0 +1,74 @@
+package orderbook
+
+import (
+	"sort"
+
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+)
+
+// level is a parsed price level keeping its original strings.
+type level struct {
+	raw      marketdata.Level
+	price    float64
+	quantity float64
+}
+
+// side keeps price levels from the best one: descending bids and ascending asks.
+type side struct {
+	levels []level
+	desc   bool
+}
+
+// update sets quantities of levels.
+func (s *side) update(levels []level) {
+	for _, l := range levels {
+		s.set(l)
+	}
+}
+
+// set replaces quantity of price level, zero quantity removes it.
+func (s *side) set(l level) {
+	i := sort.Search(len(s.levels), func(i int) bool {
+		if s.desc {
+			return s.levels[i].price <= l.price
+		}
+
+		return s.levels[i].price >= l.price
+	})
+
+	found := i < len(s.levels) && s.levels[i].price == l.price
+
+	switch {
+	case l.quantity == 0 && found:
+		s.levels = append(s.levels[:i], s.levels[i+1:]...)
+	case l.quantity == 0:
+	case found:
+		s.levels[i] = l
+	default:
+		s.levels = append(s.levels, level{})
+		copy(s.levels[i+1:], s.levels[i:])
+		s.levels[i] = l
+	}
+}
+
+// trim forgets levels beyond the n best ones.
+func (s *side) trim(n int) {
+	if len(s.levels) > n {
+		s.levels = s.levels[:n]
+	}
+}
+
+// top returns up to n best levels, every level if n is zero.
+func (s *side) top(n int) []marketdata.Level {
+	if n <= 0 || n > len(s.levels) {
+		n = len(s.levels)
+	}
+
+	res := make([]marketdata.Level, 0, n)
+
+	for i := range n {
+		res = append(res, s.levels[i].raw)
+	}
+
+	return res
+}
@@ -0,0 +1,84 @@
+package orderbook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+)
+
+// MaxSnapshotLevels is the deepest snapshot served by /api/v3/depth.
+const MaxSnapshotLevels = 5000
+
+// depthSnapshot is a response of /api/v3/depth.
+//
+//nolint:tagliatelle // explanation: binance naming
+type depthSnapshot struct {
+	Bids         [][]string `json:"bids"`
+	Asks         [][]string `json:"asks"`
+	LastUpdateID int64      `json:"lastUpdateId"`
+}
+
+// NewSnapshotFunc returns SnapshotFunc requesting up to levels levels per side from
+// /api/v3/depth endpoint.
+func NewSnapshotFunc(client *http.Client, endpoint string, levels int) SnapshotFunc {
+	levels = min(max(levels, 1), MaxSnapshotLevels)
+
+	return func(ctx context.Context, symbol string) (*marketdata.Depth, error) {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, NewError(err)
+		}
+
+		q := u.Query()
+		q.Set("symbol", symbol)
+		q.Set("limit", strconv.Itoa(levels))
+		u.RawQuery = q.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+		if err != nil {
+			return nil, NewError(err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, NewError(err)
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, NewError(fmt.Errorf("%s: unexpected status %s", u, resp.Status))
+		}
+
+		var snapshot depthSnapshot
+
+		if err = json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+			return nil, NewError(fmt.Errorf("failed to decode %s: %w", u, err))
+		}
+
+		return &marketdata.Depth{
+			Bids:          levelsOf(snapshot.Bids),
+			Asks:          levelsOf(snapshot.Asks),
+			FinalUpdateID: snapshot.LastUpdateID,
+		}, nil
+	}
+}
+
+func levelsOf(raw [][]string) []marketdata.Level {
+	res := make([]marketdata.Level, 0, len(raw))
+
+	for _, l := range raw {
+		if len(l) < 2 {
+			continue
+		}
+
+		res = append(res, marketdata.Level{Price: l[0], Quantity: l[1]})
+	}
+
+	return res
+}
@@ -0,0 +1,41 @@
+package orderbook_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+	"github.com/ole-larsen/binance-subscriber/internal/orderbook"
+)
+
+func TestNewSnapshotFunc(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("symbol") != "BTCUSDT" {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		assert.Equal(t, "5000", r.URL.Query().Get("limit"))
+
+		_, _ = rw.Write([]byte(`{"lastUpdateId":1027024,"bids":[["4.00000000","431.00000000"]],"asks":[["4.00000200","12.00000000"],["5"]]}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	snapshot := orderbook.NewSnapshotFunc(ts.Client(), ts.URL+"/api/v3/depth", 10000)
+
+	depth, err := snapshot(context.Background(), "BTCUSDT")
+	require.NoError(t, err)
+	assert.Equal(t, &marketdata.Depth{
+		Bids:          []marketdata.Level{{Price: "4.00000000", Quantity: "431.00000000"}},
+		Asks:          []marketdata.Level{{Price: "4.00000200", Quantity: "12.00000000"}},
+		FinalUpdateID: 1027024,
+	}, depth)
+
+	_, err = snapshot(context.Background(), "ETHUSDT")
+	require.ErrorContains(t, err, "unexpected status 400")
+}
@@ -5,9 +5,17 @@ type DepthMessage struct {
 	Data   DepthUpdate `json:"data"`
 }
 
-// simplify response, remove unnecessary fields.
+// simplify response, remove unnecessary fields. Keys differing only by case are
+// declared to avoid case-insensitive matches of encoding/json.
+//
+//nolint:tagliatelle // explanation: binance naming
 type DepthUpdate struct {
-	Symbol string     `json:"s"`
-	Bids   [][]string `json:"b"`
-	Asks   [][]string `json:"a"`
+	EventType     string     `json:"e"`
+	Symbol        string     `json:"s"`
+	Bids          [][]string `json:"b"`
+	Asks          [][]string `json:"a"`
+	EventTime     int64      `json:"E"`
+	Time          int64      `json:"T"`
+	FirstUpdateID int64      `json:"U"`
+	FinalUpdateID int64      `json:"u"`
 }
@@ -0,0 +1,74 @@
+package poller
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DialerOpts describes transport settings for the upstream connection.
+type DialerOpts struct {
+	ProxyURL         string
+	CAFile           string
+	HandshakeTimeout time.Duration
+	Compression      bool
+}
+
+// NewDialer builds websocket dialer with proxy, custom CA bundle, handshake timeout
+// and compression negotiation. Proxy supports http and socks5 schemes, TLS connections to
+// https proxies are not supported by the websocket dialer.
+func NewDialer(opts DialerOpts) (*websocket.Dialer, error) {
+	dialer := &websocket.Dialer{
+		Proxy:             http.ProxyFromEnvironment,
+		HandshakeTimeout:  websocket.DefaultDialer.HandshakeTimeout,
+		EnableCompression: opts.Compression,
+	}
+
+	if opts.HandshakeTimeout > 0 {
+		dialer.HandshakeTimeout = opts.HandshakeTimeout
+	}
+
+	if opts.ProxyURL != "" {
+		proxy, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return nil, NewError(fmt.Errorf("invalid proxy url: %w", err))
+		}
+
+		switch proxy.Scheme {
+		case "http", "socks5":
+		default:
+			return nil, NewError(fmt.Errorf("unsupported proxy scheme %q", proxy.Scheme))
+		}
+
+		dialer.Proxy = http.ProxyURL(proxy)
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, NewError(fmt.Errorf("failed to read ca file: %w", err))
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, NewError(fmt.Errorf("no certificates found in %s", opts.CAFile))
+		}
+
+		dialer.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return dialer, nil
+}
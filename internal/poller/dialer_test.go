@@ -0,0 +1,141 @@
+package poller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ole-larsen/binance-subscriber/internal/poller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDialer(t *testing.T) {
+	dir := t.TempDir()
+
+	emptyCA := filepath.Join(dir, "empty.pem")
+	require.NoError(t, os.WriteFile(emptyCA, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name    string
+		errMsg  string
+		opts    poller.DialerOpts
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			opts: poller.DialerOpts{},
+		},
+		{
+			name: "http proxy, timeout and compression",
+			opts: poller.DialerOpts{
+				ProxyURL:         "http://127.0.0.1:3128",
+				HandshakeTimeout: time.Second,
+				Compression:      true,
+			},
+		},
+		{
+			name: "socks5 proxy",
+			opts: poller.DialerOpts{ProxyURL: "socks5://127.0.0.1:1080"},
+		},
+		{
+			name:    "unsupported proxy scheme",
+			opts:    poller.DialerOpts{ProxyURL: "ftp://127.0.0.1"},
+			wantErr: true,
+			errMsg:  "unsupported proxy scheme",
+		},
+		{
+			name:    "https proxy",
+			opts:    poller.DialerOpts{ProxyURL: "https://127.0.0.1:3128"},
+			wantErr: true,
+			errMsg:  `unsupported proxy scheme "https"`,
+		},
+		{
+			name:    "missing ca file",
+			opts:    poller.DialerOpts{CAFile: filepath.Join(dir, "missing.pem")},
+			wantErr: true,
+			errMsg:  "failed to read ca file",
+		},
+		{
+			name:    "ca file without certificates",
+			opts:    poller.DialerOpts{CAFile: emptyCA},
+			wantErr: true,
+			errMsg:  "no certificates found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := poller.NewDialer(tt.opts)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, d)
+			assert.Equal(t, tt.opts.Compression, d.EnableCompression)
+
+			if tt.opts.HandshakeTimeout > 0 {
+				assert.Equal(t, tt.opts.HandshakeTimeout, d.HandshakeTimeout)
+			}
+		})
+	}
+}
+
+func TestBinancePoller_ConnectWithBaseEndpoint(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(rw, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		_ = conn.WriteMessage(websocket.TextMessage, msg)
+	}))
+	defer ts.Close()
+
+	d, err := poller.NewDialer(poller.DialerOpts{HandshakeTimeout: time.Second})
+	require.NoError(t, err)
+
+	p := poller.NewBinancePoller(
+		poller.WithBaseEndpoint("ws"+strings.TrimPrefix(ts.URL, "http")),
+		poller.WithDialer(d),
+	)
+
+	require.NoError(t, p.Connect(context.Background()))
+
+	defer func() {
+		require.NoError(t, p.Close())
+	}()
+
+	require.NoError(t, p.Subscribe([]string{"btcusdt@depth"}))
+
+	go func() {
+		_ = p.Read()
+	}()
+
+	msg := <-p.GetMsg()
+	assert.Contains(t, string(msg), `"method":"SUBSCRIBE"`)
+}
+
+func TestNewBinancePoller_DefaultEndpoint(t *testing.T) {
+	p := poller.NewBinancePoller(poller.WithBaseEndpoint(""))
+	assert.Equal(t, "wss://stream.binance.com:9443/stream", p.BaseEndpoint)
+	assert.Nil(t, p.Dialer)
+}
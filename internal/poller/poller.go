@@ -3,6 +3,8 @@ package poller
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -24,19 +26,49 @@ type BinanceRequest struct {
 
 type BinancePoller struct {
 	Conn         *websocket.Conn
+	Dialer       *websocket.Dialer
 	msg          chan []byte
+	streams      map[string]struct{}
 	BaseEndpoint string
+	mx           sync.Mutex
 }
 
-func NewBinancePoller() *BinancePoller {
-	return &BinancePoller{
+func NewBinancePoller(opts ...func(*BinancePoller)) *BinancePoller {
+	c := &BinancePoller{
 		BaseEndpoint: "wss://stream.binance.com:9443/stream",
 		msg:          make(chan []byte),
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// WithBaseEndpoint overrides the upstream endpoint, empty value keeps the default one.
+func WithBaseEndpoint(endpoint string) func(*BinancePoller) {
+	return func(c *BinancePoller) {
+		if endpoint != "" {
+			c.BaseEndpoint = endpoint
+		}
+	}
+}
+
+// WithDialer sets the dialer used to connect to upstream.
+func WithDialer(d *websocket.Dialer) func(*BinancePoller) {
+	return func(c *BinancePoller) {
+		c.Dialer = d
+	}
 }
 
 func (c *BinancePoller) Connect(ctx context.Context) error {
-	conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.BaseEndpoint, nil)
+	dialer := c.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+
+	conn, _, err := dialer.DialContext(ctx, c.BaseEndpoint, nil)
 	if err != nil {
 		return NewError(err)
 	}
@@ -93,29 +125,59 @@ func (c *BinancePoller) Ping() {
 }
 
 func (c *BinancePoller) Subscribe(streams []string) error {
-	if c.Conn == nil {
-		return ErrConnectionNotInitialized
-	}
+	return c.request("SUBSCRIBE", streams)
+}
 
-	req := BinanceRequest{
-		Method: "SUBSCRIBE",
-		Params: streams,
-		ID:     helpers.RandStringBytes(defaultIDSize),
+func (c *BinancePoller) Unsubscribe(streams []string) error {
+	return c.request("UNSUBSCRIBE", streams)
+}
+
+// Streams returns sorted list of active subscriptions.
+func (c *BinancePoller) Streams() []string {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	streams := make([]string, 0, len(c.streams))
+	for stream := range c.streams {
+		streams = append(streams, stream)
 	}
 
-	return c.Conn.WriteJSON(req)
+	sort.Strings(streams)
+
+	return streams
 }
 
-func (c *BinancePoller) Unsubscribe(streams []string) error {
+// request sends (un)subscribe request and keeps track of active streams.
+// Writes are serialized because subscriptions can change at runtime.
+func (c *BinancePoller) request(method string, streams []string) error {
 	if c.Conn == nil {
 		return ErrConnectionNotInitialized
 	}
 
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
 	req := BinanceRequest{
-		Method: "UNSUBSCRIBE",
+		Method: method,
 		Params: streams,
 		ID:     helpers.RandStringBytes(defaultIDSize),
 	}
 
-	return c.Conn.WriteJSON(req)
+	if err := c.Conn.WriteJSON(req); err != nil {
+		return NewError(err)
+	}
+
+	if c.streams == nil {
+		c.streams = make(map[string]struct{})
+	}
+
+	for _, stream := range streams {
+		if method == "SUBSCRIBE" {
+			c.streams[stream] = struct{}{}
+		} else {
+			delete(c.streams, stream)
+		}
+	}
+
+	return nil
 }
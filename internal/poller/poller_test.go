@@ -0,0 +1,62 @@
+package poller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/ole-larsen/binance-subscriber/internal/poller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinancePoller_Streams(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	requests := make(chan poller.BinanceRequest, 10)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(rw, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			var req poller.BinanceRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+
+			requests <- req
+		}
+	}))
+	defer ts.Close()
+
+	p := poller.NewBinancePoller(poller.WithBaseEndpoint("ws" + strings.TrimPrefix(ts.URL, "http")))
+
+	require.ErrorIs(t, p.Subscribe([]string{"btcusdt@depth"}), poller.ErrConnectionNotInitialized)
+	assert.Empty(t, p.Streams())
+
+	require.NoError(t, p.Connect(context.Background()))
+
+	defer func() {
+		require.NoError(t, p.Close())
+	}()
+
+	require.NoError(t, p.Subscribe([]string{"ethusdt@depth", "btcusdt@depth"}))
+	assert.Equal(t, []string{"btcusdt@depth", "ethusdt@depth"}, p.Streams())
+
+	require.NoError(t, p.Unsubscribe([]string{"ethusdt@depth"}))
+	assert.Equal(t, []string{"btcusdt@depth"}, p.Streams())
+
+	req := <-requests
+	assert.Equal(t, "SUBSCRIBE", req.Method)
+	assert.Equal(t, []string{"ethusdt@depth", "btcusdt@depth"}, req.Params)
+
+	req = <-requests
+	assert.Equal(t, "UNSUBSCRIBE", req.Method)
+	assert.Equal(t, []string{"ethusdt@depth"}, req.Params)
+}
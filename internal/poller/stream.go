@@ -0,0 +1,69 @@
+package poller
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Message is a combined stream envelope, data is decoded according to the stream type.
+type Message struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// BookTicker is the best bid/ask update of <symbol>@bookTicker stream. Event and transaction
+// times are sent by futures streams only, keys differing only by case are declared to avoid
+// case-insensitive matches of encoding/json.
+//
+//nolint:tagliatelle // explanation: binance naming
+type BookTicker struct {
+	EventType       string `json:"e"`
+	Symbol          string `json:"s"`
+	Bid             string `json:"b"`
+	BidQty          string `json:"B"`
+	Ask             string `json:"a"`
+	AskQty          string `json:"A"`
+	EventTime       int64  `json:"E"`
+	TransactionTime int64  `json:"T"`
+	UpdateID        int64  `json:"u"`
+}
+
+// Trade is an update of <symbol>@trade stream. Keys differing only by case are
+// declared to avoid case-insensitive matches of encoding/json.
+//
+//nolint:tagliatelle // explanation: binance naming
+type Trade struct {
+	EventType  string `json:"e"`
+	Symbol     string `json:"s"`
+	Price      string `json:"p"`
+	Quantity   string `json:"q"`
+	EventTime  int64  `json:"E"`
+	TradeTime  int64  `json:"T"`
+	ID         int64  `json:"t"`
+	BuyerMaker bool   `json:"m"`
+	Ignore     bool   `json:"M"`
+}
+
+// AggTrade is an update of <symbol>@aggTrade stream, trades of one taker order at the same
+// price are aggregated from FirstTradeID to LastTradeID.
+//
+//nolint:tagliatelle // explanation: binance naming
+type AggTrade struct {
+	EventType    string `json:"e"`
+	Symbol       string `json:"s"`
+	Price        string `json:"p"`
+	Quantity     string `json:"q"`
+	EventTime    int64  `json:"E"`
+	TradeTime    int64  `json:"T"`
+	ID           int64  `json:"a"`
+	FirstTradeID int64  `json:"f"`
+	LastTradeID  int64  `json:"l"`
+	BuyerMaker   bool   `json:"m"`
+	Ignore       bool   `json:"M"`
+}
+
+// StreamType returns stream name without symbol, e.g. "depth@100ms" for "btcusdt@depth@100ms".
+func StreamType(stream string) string {
+	_, kind, _ := strings.Cut(stream, "@")
+	return kind
+}
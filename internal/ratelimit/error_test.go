@@ -0,0 +1,22 @@
+package ratelimit_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	require.NoError(t, ratelimit.NewError(nil))
+
+	stdErr := errors.New("something went wrong")
+	err := ratelimit.NewError(stdErr)
+
+	var ratelimitErr *ratelimit.Error
+	require.True(t, errors.As(err, &ratelimitErr))
+	assert.Equal(t, "[ratelimit]: something went wrong", ratelimitErr.Error())
+	assert.True(t, errors.Is(err, stdErr))
+}
@@ -0,0 +1,127 @@
+// Package ratelimit protects the API from misbehaving clients with per-client
+// token buckets and concurrent connection quotas.
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/ole-larsen/binance-subscriber/internal/auth"
+)
+
+// idleTTL is how long bucket of a silent client is kept.
+const idleTTL = 10 * time.Minute
+
+type bucket struct {
+	seen    time.Time
+	limiter *rate.Limiter
+}
+
+// Limiter keeps a token bucket per client key. Nil or zero rate limiter allows everything.
+type Limiter struct {
+	clients map[string]*bucket
+	swept   time.Time
+	limit   rate.Limit
+	burst   int
+	mx      sync.Mutex
+}
+
+// NewLimiter creates limiter allowing perSecond requests with burst per client.
+func NewLimiter(perSecond float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = max(1, int(perSecond))
+	}
+
+	return &Limiter{
+		clients: make(map[string]*bucket),
+		limit:   rate.Limit(perSecond),
+		burst:   burst,
+	}
+}
+
+// Allow takes a token from client bucket. When bucket is empty it reports
+// how long the client should wait before retrying.
+func (l *Limiter) Allow(key string, now time.Time) (ok bool, retryAfter time.Duration) {
+	if l == nil || l.limit <= 0 {
+		return true, 0
+	}
+
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.clients[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = b
+	}
+
+	b.seen = now
+
+	r := b.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// Len returns number of tracked clients.
+func (l *Limiter) Len() int {
+	if l == nil {
+		return 0
+	}
+
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	return len(l.clients)
+}
+
+// sweep drops buckets of clients idle for idleTTL, at most once per idleTTL.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < idleTTL {
+		return
+	}
+
+	l.swept = now
+
+	for key, b := range l.clients {
+		if now.Sub(b.seen) >= idleTTL {
+			delete(l.clients, key)
+		}
+	}
+}
+
+// ClientKey identifies client of the request: authenticated subject or remote IP
+// (set from X-Forwarded-For/X-Real-IP by middleware.RealIP) for anonymous requests.
+func ClientKey(r *http.Request) string {
+	return PeerKey(r.Context(), r.RemoteAddr)
+}
+
+// PeerKey identifies client by authenticated subject stored in ctx or by IP of the
+// remote address, it is used by non-HTTP transports.
+func PeerKey(ctx context.Context, remoteAddr string) string {
+	if id := auth.FromContext(ctx); id != nil && id != auth.Anonymous && id.Subject != "" {
+		return "key:" + id.Subject
+	}
+
+	return IPKey(remoteAddr)
+}
+
+// IPKey identifies client by IP of the remote address regardless of its credentials.
+func IPKey(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	return "ip:" + host
+}
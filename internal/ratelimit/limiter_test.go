@@ -0,0 +1,99 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/auth"
+	"github.com/ole-larsen/binance-subscriber/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	l := ratelimit.NewLimiter(2, 3)
+	now := time.Unix(1700000000, 0)
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("a", now)
+		require.True(t, ok, "burst request %d", i)
+	}
+
+	ok, retryAfter := l.Allow("a", now)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// other clients have own buckets
+	ok, _ = l.Allow("b", now)
+	assert.True(t, ok)
+
+	// rejected request does not consume tokens
+	ok, _ = l.Allow("a", now.Add(500*time.Millisecond))
+	assert.True(t, ok)
+
+	// idle clients are forgotten
+	assert.Equal(t, 2, l.Len())
+	ok, _ = l.Allow("c", now.Add(time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, 1, l.Len())
+}
+
+func TestLimiter_Disabled(t *testing.T) {
+	for _, l := range []*ratelimit.Limiter{nil, ratelimit.NewLimiter(0, 0)} {
+		for i := 0; i < 100; i++ {
+			ok, _ := l.Allow("a", time.Now())
+			require.True(t, ok)
+		}
+	}
+}
+
+func TestQuota_Acquire(t *testing.T) {
+	q := ratelimit.NewQuota(3, 2)
+
+	releaseA1, err := q.Acquire("a")
+	require.NoError(t, err)
+
+	_, err = q.Acquire("a")
+	require.NoError(t, err)
+
+	_, err = q.Acquire("a")
+	require.ErrorIs(t, err, ratelimit.ErrTooManyClientConnections)
+
+	_, err = q.Acquire("b")
+	require.NoError(t, err)
+
+	_, err = q.Acquire("c")
+	require.ErrorIs(t, err, ratelimit.ErrTooManyConnections)
+
+	// release is idempotent
+	releaseA1()
+	releaseA1()
+
+	total, client := q.Len("a")
+	assert.Equal(t, 2, total)
+	assert.Equal(t, 1, client)
+
+	_, err = q.Acquire("c")
+	require.NoError(t, err)
+
+	var nilQuota *ratelimit.Quota
+
+	release, err := nilQuota.Acquire("a")
+	require.NoError(t, err)
+	release()
+}
+
+func TestClientKey(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/ws", http.NoBody)
+	r.RemoteAddr = "10.0.0.1:51234"
+
+	assert.Equal(t, "ip:10.0.0.1", ratelimit.ClientKey(r))
+
+	r = r.WithContext(auth.WithIdentity(r.Context(), auth.Anonymous))
+	assert.Equal(t, "ip:10.0.0.1", ratelimit.ClientKey(r))
+
+	r = r.WithContext(auth.WithIdentity(r.Context(), &auth.Identity{Subject: "dashboard"}))
+	assert.Equal(t, "key:dashboard", ratelimit.ClientKey(r))
+}
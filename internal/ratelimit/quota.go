@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	ErrTooManyConnections       = errors.New("too many connections")
+	ErrTooManyClientConnections = errors.New("too many connections per client")
+)
+
+// Quota limits concurrent connections globally and per client key, zero disables a limit.
+type Quota struct {
+	clients      map[string]int
+	total        int
+	maxTotal     int
+	maxPerClient int
+	mx           sync.Mutex
+}
+
+func NewQuota(maxTotal, maxPerClient int) *Quota {
+	return &Quota{
+		clients:      make(map[string]int),
+		maxTotal:     maxTotal,
+		maxPerClient: maxPerClient,
+	}
+}
+
+// Acquire takes a connection slot of the client, release must be called when connection is closed.
+// Nil quota allows everything.
+func (q *Quota) Acquire(key string) (release func(), err error) {
+	if q == nil {
+		return func() {}, nil
+	}
+
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	if q.maxTotal > 0 && q.total >= q.maxTotal {
+		return nil, NewError(ErrTooManyConnections)
+	}
+
+	if q.maxPerClient > 0 && q.clients[key] >= q.maxPerClient {
+		return nil, NewError(ErrTooManyClientConnections)
+	}
+
+	q.total++
+	q.clients[key]++
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			q.release(key)
+		})
+	}, nil
+}
+
+// Len returns number of held connections in total and of the client.
+func (q *Quota) Len(key string) (total, client int) {
+	if q == nil {
+		return 0, 0
+	}
+
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	return q.total, q.clients[key]
+}
+
+func (q *Quota) release(key string) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	q.total--
+
+	if q.clients[key]--; q.clients[key] <= 0 {
+		delete(q.clients, key)
+	}
+}
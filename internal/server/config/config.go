@@ -1,73 +1,734 @@
 // Package config to configure server. The part of server package.
 // Copyright 2024 The Oleg Nazarov. All rights reserved.
+//
+// Settings are layered: defaults < config file < environment < command line flags.
 package config
 
 import (
 	"flag"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/alert"
+	"github.com/ole-larsen/binance-subscriber/internal/arbitrage"
+	"github.com/ole-larsen/binance-subscriber/internal/ingest"
+	"github.com/ole-larsen/binance-subscriber/internal/latency"
+	"github.com/ole-larsen/binance-subscriber/internal/orderbook"
+	"github.com/ole-larsen/binance-subscriber/internal/staleness"
+	"github.com/ole-larsen/binance-subscriber/internal/synthetic"
+	"github.com/ole-larsen/binance-subscriber/internal/tape"
+)
+
+const (
+	DefaultAddress                  = "localhost:8080"
+	DefaultAdminAddress             = "localhost:8081"
+	DefaultGRPCAddress              = "localhost:9090"
+	DefaultInstruments              = "btcusdt@depth"
+	DefaultUpstreamURL              = "wss://stream.binance.com:9443/stream"
+	DefaultUpstreamHandshakeTimeout = 45 * time.Second
+	DefaultExchangeInfoURL          = "https://api.binance.com/api/v3/exchangeInfo"
+	DefaultExchangeTickerURL        = "https://api.binance.com/api/v3/ticker/24hr"
+	DefaultExpandInterval           = time.Hour
+	DefaultAuthReloadInterval       = 10 * time.Second
+	DefaultTLSReloadInterval        = 10 * time.Second
+	DefaultRequestsPerSecond        = 10
+	DefaultRequestsBurst            = 20
+	DefaultIPRequestsPerSecond      = 50
+	DefaultIPRequestsBurst          = 100
+	DefaultMaxConnections           = 1000
+	DefaultMaxConnectionsPerClient  = 10
+	DefaultMessagesPerSecond        = 5
+	DefaultMessagesBurst            = 10
+	DefaultStreamReplay             = 1024
+	DefaultStreamHeartbeat          = 15 * time.Second
+	DefaultSinkBuffer               = 10000
+	DefaultSinkBatchSize            = 100
+	DefaultSinkFlushInterval        = 100 * time.Millisecond
+	DefaultSinkOverflow             = "drop"
+	DefaultSinkPrefix               = "binance"
+	DefaultRedisChannel             = "quotes"
+	DefaultTradesTapeSize           = tape.DefaultSize
+	DefaultBookSnapshotURL          = "https://api.binance.com/api/v3/depth"
+	DefaultBookMaxLevels            = orderbook.DefaultMaxLevels
+	DefaultBookImbalanceLevels      = orderbook.DefaultImbalanceLevels
+	DefaultArbitrageFeeBPS          = arbitrage.DefaultFeeBPS
+	DefaultArbitrageThresholdBPS    = arbitrage.DefaultThresholdBPS
+	DefaultAlertRetries             = alert.DefaultRetries
+	DefaultAlertCheckInterval       = alert.DefaultCheckInterval
+	DefaultAlertMaxRules            = alert.DefaultMaxRules
+	DefaultStalenessCheckInterval   = staleness.DefaultCheckInterval
+	DefaultLatencyWindow            = latency.DefaultWindow
+	DefaultLatencySamples           = latency.DefaultSamples
+	DefaultIngestWorkers            = ingest.DefaultWorkers
+	DefaultIngestQueue              = ingest.DefaultQueue
+	DefaultIngestBatchSize          = ingest.DefaultBatchSize
+	DefaultIngestOverflow           = "block"
+	DefaultLogLevel                 = "info"
+	DefaultStorageBackend           = StorageBackendSnapshot
+)
+
+// Storage backends, snapshot never blocks writers by readers of every quote.
+const (
+	StorageBackendMemory   = "memory"
+	StorageBackendSnapshot = "snapshot"
 )
 
 type Config struct {
 	Host        string
+	Admin       Admin
+	GRPC        GRPC
+	TLS         TLS
+	Upstream    Upstream
+	Exchange    Exchange
+	Auth        Auth
+	Limits      Limits
+	WebSocket   WebSocket
+	Stream      Stream
+	Sinks       Sinks
+	Trades      Trades
+	Book        Book
+	Arbitrage   Arbitrage
+	Alerts      Alerts
+	Staleness   Staleness
+	Latency     Latency
+	Ingest      Ingest
+	Log         Log
+	Storage     Storage
 	Instruments []string
+	Synthetics  []synthetic.Instrument
 	Port        int
 }
 
+// Admin describes listener of admin endpoints, pprof, swagger and metrics.
+// Empty address disables it.
+type Admin struct {
+	Address string `yaml:"address"`
+}
+
+// GRPC describes listener of the gRPC API, empty address disables it.
+// It uses TLS settings of the public listener.
+type GRPC struct {
+	Address string `yaml:"address"`
+}
+
+// TLS enables wss:// and https:// on the public listener when certificate and key are set.
+// Client certificates are verified against ClientCAFile: "optional" verifies presented ones,
+// "require" rejects clients without a certificate, it is the default when ClientCAFile is set.
+// Files are re-read every ReloadInterval if changed.
+type TLS struct {
+	CertFile       string        `yaml:"cert_file"`
+	KeyFile        string        `yaml:"key_file"`
+	ClientCAFile   string        `yaml:"client_ca_file"`
+	ClientAuth     string        `yaml:"client_auth"`
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+// Enabled reports whether TLS is configured.
+func (t *TLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// Upstream describes how the poller connects to the exchange websocket.
+type Upstream struct {
+	// BaseURL is the combined stream endpoint, e.g. testnet or a local simulator.
+	BaseURL string `yaml:"base_url"`
+	// ProxyURL is an optional http:// or socks5:// proxy.
+	ProxyURL string `yaml:"proxy_url"`
+	// CAFile is an optional PEM bundle appended to the system roots.
+	CAFile           string        `yaml:"ca_file"`
+	HandshakeTimeout time.Duration `yaml:"handshake_timeout"`
+	// Compression enables permessage-deflate negotiation.
+	Compression bool `yaml:"compression"`
+}
+
+// Exchange describes where exchangeInfo snapshot used to validate instruments comes from.
+// File takes precedence over URL, validation is disabled when both are empty.
+//
+// Instrument patterns like "*usdt@bookTicker" or "quote=USDT,top=50" are expanded
+// against the snapshot (and 24h tickers for top) every ExpandInterval, 0 disables re-expansion.
+type Exchange struct {
+	InfoURL        string        `yaml:"info_url"`
+	InfoFile       string        `yaml:"info_file"`
+	TickerURL      string        `yaml:"ticker_url"`
+	ExpandInterval time.Duration `yaml:"expand_interval"`
+}
+
+// Auth describes accepted credentials, authentication is disabled when none is set.
+// Keys and JWKS files are re-read every ReloadInterval if changed to rotate keys.
+type Auth struct {
+	KeysFile  string `yaml:"keys_file"`
+	JWTSecret string `yaml:"jwt_secret"`
+	JWKSFile  string `yaml:"jwks_file"`
+	Issuer    string `yaml:"issuer"`
+	Audience  string `yaml:"audience"`
+	// AllowedOrigins of websocket clients, "*" allows any, empty list allows same origin only.
+	AllowedOrigins []string      `yaml:"allowed_origins,omitempty"`
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+// Limits protects the API from misbehaving clients, zero disables a limit.
+// Clients are identified by API key or by IP when authentication is disabled.
+type Limits struct {
+	// RequestsPerSecond and RequestsBurst set token bucket of REST and /ws upgrade requests.
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	RequestsBurst     int     `yaml:"requests_burst"`
+	// IPRequestsPerSecond and IPRequestsBurst set token bucket per IP checked before authentication,
+	// it throttles requests with invalid credentials.
+	IPRequestsPerSecond float64 `yaml:"ip_requests_per_second"`
+	IPRequestsBurst     int     `yaml:"ip_requests_burst"`
+	// MaxConnections limits concurrent /ws connections globally and per client.
+	MaxConnections          int `yaml:"max_connections"`
+	MaxConnectionsPerClient int `yaml:"max_connections_per_client"`
+	// MessagesPerSecond and MessagesBurst limit inbound messages of a /ws connection.
+	MessagesPerSecond float64 `yaml:"messages_per_second"`
+	MessagesBurst     int     `yaml:"messages_burst"`
+}
+
+// WebSocket contains /ws protocol settings.
+type WebSocket struct {
+	// Compression enables permessage-deflate negotiation with clients.
+	Compression bool `yaml:"compression"`
+}
+
+// Stream contains /api/v1/stream Server-Sent Events settings.
+type Stream struct {
+	// Replay is the number of the latest updates kept to resume streams by Last-Event-ID, 0 disables resuming.
+	Replay int `yaml:"replay"`
+	// Heartbeat is the interval of keep-alive comments, 0 disables them.
+	Heartbeat time.Duration `yaml:"heartbeat"`
+}
+
+// Sinks publish normalized market data (bbo, trade and depth updates) to message buses
+// and stored quotes to Redis, a sink is enabled when its address is set. Every sink queues up to Buffer messages and
+// publishes them in batches of BatchSize at least every FlushInterval. Messages that fail
+// to deliver stay queued, Overflow selects what happens when the queue is full: "drop"
+// discards the oldest message, "block" stalls ingestion until there is free space.
+type Sinks struct {
+	Buffer        int           `yaml:"buffer"`
+	BatchSize     int           `yaml:"batch_size"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	Overflow      string        `yaml:"overflow"`
+	NATS          NATSSink      `yaml:"nats"`
+	Kafka         KafkaSink     `yaml:"kafka"`
+	Redis         RedisSink     `yaml:"redis"`
+}
+
+// NATSSink publishes to <subject_prefix>.<type>.<symbol> subjects.
+type NATSSink struct {
+	URL           string `yaml:"url"`
+	SubjectPrefix string `yaml:"subject_prefix"`
+}
+
+// KafkaSink publishes to <topic_prefix>.<type> topics keyed by symbol.
+type KafkaSink struct {
+	TopicPrefix string   `yaml:"topic_prefix"`
+	Brokers     []string `yaml:"brokers,omitempty"`
+}
+
+// RedisSink mirrors stored quotes into quote:<symbol> hashes and publishes them on Channel.
+type RedisSink struct {
+	URL     string `yaml:"url"`
+	Channel string `yaml:"channel"`
+}
+
+// Trades keeps TapeSize latest trades of every symbol subscribed by @trade or @aggTrade
+// streams and rolling volume and VWAP statistics over Windows.
+type Trades struct {
+	TapeSize int             `yaml:"tape_size"`
+	Windows  []time.Duration `yaml:"windows,omitempty"`
+}
+
+// Book maintains local order books of @depth streams synchronized with SnapshotURL, empty
+// URL builds them from depth updates only. MaxLevels are kept per side. Imbalance is computed
+// over ImbalanceLevels top levels, cumulative liquidity within DepthBPS basis points of mid
+// and slippage of market orders of Notionals quote volume.
+type Book struct {
+	SnapshotURL     string    `yaml:"snapshot_url"`
+	MaxLevels       int       `yaml:"max_levels"`
+	ImbalanceLevels int       `yaml:"imbalance_levels"`
+	DepthBPS        []float64 `yaml:"depth_bps,omitempty"`
+	Notionals       []float64 `yaml:"notionals,omitempty"`
+}
+
+// Arbitrage monitors edges of Triangles and Pairs on every stored quote after FeeBPS of every
+// leg and emits opportunities with edge above ThresholdBPS.
+type Arbitrage struct {
+	FeeBPS       float64              `yaml:"fee_bps"`
+	ThresholdBPS float64              `yaml:"threshold_bps"`
+	Triangles    []arbitrage.Triangle `yaml:"triangles,omitempty"`
+	Pairs        []arbitrage.Pair     `yaml:"pairs,omitempty"`
+}
+
+// Alerts evaluates Rules on every stored quote and posts firing and resolved alerts to rule webhooks
+// signed with WebhookSecret, alerts of rules without webhook are logged. Failed deliveries are
+// retried up to Retries times. Age rules are checked every CheckInterval. Rules added by the API
+// post unsigned alerts to WebhookHosts only, there are at most MaxRules rules.
+type Alerts struct {
+	WebhookSecret string        `yaml:"webhook_secret"`
+	WebhookHosts  []string      `yaml:"webhook_hosts,omitempty"`
+	Retries       int           `yaml:"retries"`
+	MaxRules      int           `yaml:"max_rules"`
+	CheckInterval time.Duration `yaml:"check_interval"`
+	Rules         []alert.Rule  `yaml:"rules,omitempty"`
+}
+
+// Staleness marks symbols without updates for Threshold stale, Thresholds override it per
+// symbol and 0 disables tracking. Symbols are checked every CheckInterval. Suppress hides
+// stale quotes from REST and websocket snapshots until their symbols are updated again.
+type Staleness struct {
+	Thresholds    map[string]time.Duration `yaml:"thresholds,omitempty"`
+	Threshold     time.Duration            `yaml:"threshold"`
+	CheckInterval time.Duration            `yaml:"check_interval"`
+	Suppress      bool                     `yaml:"suppress"`
+}
+
+// Latency measures delay of upstream messages from exchange event and transaction times,
+// statistics of the latest Samples delays per stream type and clock skew are estimated
+// over Window.
+type Latency struct {
+	Window  time.Duration `yaml:"window"`
+	Samples int           `yaml:"samples"`
+}
+
+// Ingest decodes upstream messages on Workers partitioned by symbol, every worker queues up
+// to Queue messages and hands updates to the apply stage in batches of up to BatchSize.
+// Overflow selects what happens when a queue is full: "block" backpressures the upstream
+// connection, "drop" discards the oldest message.
+type Ingest struct {
+	Workers   int    `yaml:"workers"`
+	Queue     int    `yaml:"queue"`
+	BatchSize int    `yaml:"batch_size"`
+	Overflow  string `yaml:"overflow"`
+}
+
+// Log contains logger settings.
+type Log struct {
+	Level string `yaml:"level"`
+}
+
+// Storage selects the quotes storage backend: memory guarded by a mutex or copy-on-write snapshot.
+type Storage struct {
+	Backend string `yaml:"backend"`
+}
+
 type Opts struct {
-	APtr *string
-	IPtr *string
+	APtr                *string
+	AdminPtr            *string
+	GRPCPtr             *string
+	TLSCertPtr          *string
+	TLSKeyPtr           *string
+	TLSClientCAPtr      *string
+	TLSClientAuthPtr    *string
+	IPtr                *string
+	UpstreamPtr         *string
+	ProxyPtr            *string
+	CAPtr               *string
+	HandshakeTimeoutPtr *string
+	CompressionPtr      *string
+	ConfigPtr           *string
+	LogLevelPtr         *string
+	StoragePtr          *string
+	ExchangeInfoURLPtr  *string
+	ExchangeInfoFilePtr *string
+	TickerURLPtr        *string
+	BookSnapshotURLPtr  *string
+	ExpandIntervalPtr   *string
+	AuthKeysPtr         *string
+	AuthJWKSPtr         *string
+	AllowedOriginsPtr   *string
+	RateLimitPtr        *string
+	MaxConnsPtr         *string
+	MaxConnsClientPtr   *string
+	MessageRatePtr      *string
+	WSCompressionPtr    *string
+	NATSURLPtr          *string
+	KafkaBrokersPtr     *string
+	RedisURLPtr         *string
+	PrintConfigPtr      *bool
 }
 
 var (
-	config = &Config{}
-	once   sync.Once
+	config    = &Config{}
+	configErr error
+	loader    *Loader
+	once      sync.Once
 )
 
 // GetConfig rewrites using singleton pattern.
-func GetConfig() *Config {
+func GetConfig() (*Config, error) {
 	once.Do(func() {
-		f := parseFlags()
-		config = InitConfig(
-			WithAddress(os.Getenv("ADDRESS"), f.APtr),
-			WithInstruments(os.Getenv("INSTRUMENTS"), f.IPtr),
-		)
+		loader = NewLoader(flag.CommandLine, os.Getenv)
+
+		flag.Parse()
+
+		config, configErr = loader.Load()
 	})
 
-	return config
+	return config, configErr
+}
+
+// Load re-reads config file and environment on top of the command line flags
+// parsed by GetConfig. It is used to reload settings at runtime.
+func Load() (*Config, error) {
+	if _, err := GetConfig(); err != nil {
+		return nil, err
+	}
+
+	return loader.Load()
+}
+
+// PrintRequested reports whether -print-config flag was passed.
+func PrintRequested() bool {
+	return loader != nil && loader.opts.PrintConfigPtr != nil && *loader.opts.PrintConfigPtr
+}
+
+// Default returns configuration with built-in defaults.
+func Default() *Config {
+	return &Config{
+		Port:        8080,
+		Instruments: []string{DefaultInstruments},
+		Admin: Admin{
+			Address: DefaultAdminAddress,
+		},
+		GRPC: GRPC{
+			Address: DefaultGRPCAddress,
+		},
+		TLS: TLS{
+			ReloadInterval: DefaultTLSReloadInterval,
+		},
+		Upstream: Upstream{
+			BaseURL:          DefaultUpstreamURL,
+			HandshakeTimeout: DefaultUpstreamHandshakeTimeout,
+		},
+		Exchange: Exchange{
+			InfoURL:        DefaultExchangeInfoURL,
+			TickerURL:      DefaultExchangeTickerURL,
+			ExpandInterval: DefaultExpandInterval,
+		},
+		Auth: Auth{
+			ReloadInterval: DefaultAuthReloadInterval,
+		},
+		Limits: Limits{
+			RequestsPerSecond:       DefaultRequestsPerSecond,
+			RequestsBurst:           DefaultRequestsBurst,
+			IPRequestsPerSecond:     DefaultIPRequestsPerSecond,
+			IPRequestsBurst:         DefaultIPRequestsBurst,
+			MaxConnections:          DefaultMaxConnections,
+			MaxConnectionsPerClient: DefaultMaxConnectionsPerClient,
+			MessagesPerSecond:       DefaultMessagesPerSecond,
+			MessagesBurst:           DefaultMessagesBurst,
+		},
+		Stream: Stream{
+			Replay:    DefaultStreamReplay,
+			Heartbeat: DefaultStreamHeartbeat,
+		},
+		Sinks: Sinks{
+			Buffer:        DefaultSinkBuffer,
+			BatchSize:     DefaultSinkBatchSize,
+			FlushInterval: DefaultSinkFlushInterval,
+			Overflow:      DefaultSinkOverflow,
+			NATS:          NATSSink{SubjectPrefix: DefaultSinkPrefix},
+			Kafka:         KafkaSink{TopicPrefix: DefaultSinkPrefix},
+			Redis:         RedisSink{Channel: DefaultRedisChannel},
+		},
+		Trades: Trades{
+			TapeSize: DefaultTradesTapeSize,
+			Windows:  slices.Clone(tape.DefaultWindows),
+		},
+		Book: Book{
+			SnapshotURL:     DefaultBookSnapshotURL,
+			MaxLevels:       DefaultBookMaxLevels,
+			ImbalanceLevels: DefaultBookImbalanceLevels,
+			DepthBPS:        slices.Clone(orderbook.DefaultBands),
+			Notionals:       slices.Clone(orderbook.DefaultNotionals),
+		},
+		Arbitrage: Arbitrage{
+			FeeBPS:       DefaultArbitrageFeeBPS,
+			ThresholdBPS: DefaultArbitrageThresholdBPS,
+		},
+		Alerts: Alerts{
+			Retries:       DefaultAlertRetries,
+			MaxRules:      DefaultAlertMaxRules,
+			CheckInterval: DefaultAlertCheckInterval,
+		},
+		Staleness: Staleness{
+			CheckInterval: DefaultStalenessCheckInterval,
+		},
+		Latency: Latency{
+			Window:  DefaultLatencyWindow,
+			Samples: DefaultLatencySamples,
+		},
+		Ingest: Ingest{
+			Workers:   DefaultIngestWorkers,
+			Queue:     DefaultIngestQueue,
+			BatchSize: DefaultIngestBatchSize,
+			Overflow:  DefaultIngestOverflow,
+		},
+		Log: Log{
+			Level: DefaultLogLevel,
+		},
+		Storage: Storage{
+			Backend: DefaultStorageBackend,
+		},
+	}
 }
 
-func (c *Config) Reload(opts ...func(*Config)) {
+func (c *Config) Reload(opts ...func(*Config) error) error {
 	for _, opt := range opts {
-		opt(c)
+		if err := opt(c); err != nil {
+			return NewError(err)
+		}
 	}
+
+	return nil
 }
 
-func InitConfig(opts ...func(*Config)) *Config {
+func InitConfig(opts ...func(*Config) error) (*Config, error) {
 	c := &Config{}
-	c.Reload(opts...)
 
-	return c
+	if err := c.Reload(opts...); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Loader builds configuration from every layer.
+type Loader struct {
+	flags  *flag.FlagSet
+	getenv func(string) string
+	opts   Opts
+}
+
+// NewLoader registers flags on the given flag set. Flags must be parsed before Load.
+func NewLoader(fs *flag.FlagSet, getenv func(string) string) *Loader {
+	return &Loader{
+		flags:  fs,
+		getenv: getenv,
+		opts:   parseFlags(fs),
+	}
+}
+
+// Load applies defaults, config file, environment and explicitly set flags, then validates the result.
+func (l *Loader) Load() (*Config, error) {
+	set := make(map[string]bool)
+
+	l.flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	flagValue := func(name string, ptr *string) *string {
+		if set[name] {
+			return ptr
+		}
+
+		return nil
+	}
+
+	path := l.getenv("CONFIG_FILE")
+	if set["config"] {
+		path = *l.opts.ConfigPtr
+	}
+
+	c := Default()
+
+	if path != "" {
+		if err := c.Reload(WithFile(path)); err != nil {
+			return nil, err
+		}
+	}
+
+	// environment variables
+	if err := c.Reload(
+		WithAddress(l.getenv("ADDRESS"), nil),
+		WithAdminAddress(l.getenv("ADMIN_ADDRESS"), nil),
+		WithGRPCAddress(l.getenv("GRPC_ADDRESS"), nil),
+		WithTLSCert(l.getenv("TLS_CERT_FILE"), nil),
+		WithTLSKey(l.getenv("TLS_KEY_FILE"), nil),
+		WithTLSClientCA(l.getenv("TLS_CLIENT_CA_FILE"), nil),
+		WithTLSClientAuth(l.getenv("TLS_CLIENT_AUTH"), nil),
+		WithInstruments(l.getenv("INSTRUMENTS"), nil),
+		WithUpstreamURL(l.getenv("UPSTREAM_URL"), nil),
+		WithUpstreamProxy(l.getenv("UPSTREAM_PROXY"), nil),
+		WithUpstreamCA(l.getenv("UPSTREAM_CA_FILE"), nil),
+		WithUpstreamHandshakeTimeout(l.getenv("UPSTREAM_HANDSHAKE_TIMEOUT"), nil),
+		WithUpstreamCompression(l.getenv("UPSTREAM_COMPRESSION"), nil),
+		WithLogLevel(l.getenv("LOG_LEVEL"), nil),
+		WithStorageBackend(l.getenv("STORAGE_BACKEND"), nil),
+		WithExchangeInfoURL(l.getenv("EXCHANGE_INFO_URL"), nil),
+		WithExchangeInfoFile(l.getenv("EXCHANGE_INFO_FILE"), nil),
+		WithExchangeTickerURL(l.getenv("EXCHANGE_TICKER_URL"), nil),
+		WithExpandInterval(l.getenv("EXPAND_INTERVAL"), nil),
+		WithAuthKeysFile(l.getenv("AUTH_KEYS_FILE"), nil),
+		WithAuthJWTSecret(l.getenv("AUTH_JWT_SECRET")),
+		WithAuthJWKSFile(l.getenv("AUTH_JWKS_FILE"), nil),
+		WithAllowedOrigins(l.getenv("ALLOWED_ORIGINS"), nil),
+		WithRateLimit(l.getenv("RATE_LIMIT"), nil),
+		WithRateBurst(l.getenv("RATE_LIMIT_BURST")),
+		WithIPRateLimit(l.getenv("RATE_LIMIT_IP")),
+		WithIPRateBurst(l.getenv("RATE_LIMIT_IP_BURST")),
+		WithMaxConnections(l.getenv("WS_MAX_CONNECTIONS"), nil),
+		WithMaxConnectionsPerClient(l.getenv("WS_MAX_CONNECTIONS_PER_CLIENT"), nil),
+		WithMessageRate(l.getenv("WS_MESSAGE_RATE"), nil),
+		WithMessageBurst(l.getenv("WS_MESSAGE_BURST")),
+		WithWSCompression(l.getenv("WS_COMPRESSION"), nil),
+		WithStreamReplay(l.getenv("STREAM_REPLAY")),
+		WithStreamHeartbeat(l.getenv("STREAM_HEARTBEAT")),
+		WithSinkBuffer(l.getenv("SINK_BUFFER")),
+		WithSinkBatchSize(l.getenv("SINK_BATCH_SIZE")),
+		WithSinkFlushInterval(l.getenv("SINK_FLUSH_INTERVAL")),
+		WithSinkOverflow(l.getenv("SINK_OVERFLOW")),
+		WithNATSURL(l.getenv("NATS_URL"), nil),
+		WithNATSSubjectPrefix(l.getenv("NATS_SUBJECT_PREFIX")),
+		WithKafkaBrokers(l.getenv("KAFKA_BROKERS"), nil),
+		WithKafkaTopicPrefix(l.getenv("KAFKA_TOPIC_PREFIX")),
+		WithRedisURL(l.getenv("REDIS_URL"), nil),
+		WithRedisChannel(l.getenv("REDIS_CHANNEL")),
+		WithTradesTapeSize(l.getenv("TRADES_TAPE_SIZE")),
+		WithTradesWindows(l.getenv("TRADES_WINDOWS")),
+		WithBookSnapshotURL(l.getenv("BOOK_SNAPSHOT_URL"), nil),
+		WithBookMaxLevels(l.getenv("BOOK_MAX_LEVELS")),
+		WithBookImbalanceLevels(l.getenv("BOOK_IMBALANCE_LEVELS")),
+		WithBookDepthBPS(l.getenv("BOOK_DEPTH_BPS")),
+		WithBookNotionals(l.getenv("BOOK_NOTIONALS")),
+		WithArbitrageFeeBPS(l.getenv("ARBITRAGE_FEE_BPS")),
+		WithArbitrageThresholdBPS(l.getenv("ARBITRAGE_THRESHOLD_BPS")),
+		WithAlertWebhookSecret(l.getenv("ALERT_WEBHOOK_SECRET")),
+		WithAlertWebhookHosts(l.getenv("ALERT_WEBHOOK_HOSTS")),
+		WithAlertRetries(l.getenv("ALERT_RETRIES")),
+		WithAlertMaxRules(l.getenv("ALERT_MAX_RULES")),
+		WithAlertCheckInterval(l.getenv("ALERT_CHECK_INTERVAL")),
+		WithStalenessThreshold(l.getenv("STALENESS_THRESHOLD")),
+		WithStalenessCheckInterval(l.getenv("STALENESS_CHECK_INTERVAL")),
+		WithStalenessSuppress(l.getenv("STALENESS_SUPPRESS")),
+		WithLatencyWindow(l.getenv("LATENCY_WINDOW")),
+		WithLatencySamples(l.getenv("LATENCY_SAMPLES")),
+		WithIngestWorkers(l.getenv("INGEST_WORKERS")),
+		WithIngestQueue(l.getenv("INGEST_QUEUE")),
+		WithIngestBatchSize(l.getenv("INGEST_BATCH_SIZE")),
+		WithIngestOverflow(l.getenv("INGEST_OVERFLOW")),
+	); err != nil {
+		return nil, err
+	}
+
+	// command line flags
+	if err := c.Reload(
+		WithAddress("", flagValue("a", l.opts.APtr)),
+		WithAdminAddress("", flagValue("admin-address", l.opts.AdminPtr)),
+		WithGRPCAddress("", flagValue("grpc-address", l.opts.GRPCPtr)),
+		WithTLSCert("", flagValue("tls-cert", l.opts.TLSCertPtr)),
+		WithTLSKey("", flagValue("tls-key", l.opts.TLSKeyPtr)),
+		WithTLSClientCA("", flagValue("tls-client-ca", l.opts.TLSClientCAPtr)),
+		WithTLSClientAuth("", flagValue("tls-client-auth", l.opts.TLSClientAuthPtr)),
+		WithInstruments("", flagValue("i", l.opts.IPtr)),
+		WithUpstreamURL("", flagValue("upstream", l.opts.UpstreamPtr)),
+		WithUpstreamProxy("", flagValue("proxy", l.opts.ProxyPtr)),
+		WithUpstreamCA("", flagValue("ca", l.opts.CAPtr)),
+		WithUpstreamHandshakeTimeout("", flagValue("handshake-timeout", l.opts.HandshakeTimeoutPtr)),
+		WithUpstreamCompression("", flagValue("compression", l.opts.CompressionPtr)),
+		WithLogLevel("", flagValue("log-level", l.opts.LogLevelPtr)),
+		WithStorageBackend("", flagValue("storage", l.opts.StoragePtr)),
+		WithExchangeInfoURL("", flagValue("exchange-info-url", l.opts.ExchangeInfoURLPtr)),
+		WithExchangeInfoFile("", flagValue("exchange-info-file", l.opts.ExchangeInfoFilePtr)),
+		WithExchangeTickerURL("", flagValue("exchange-ticker-url", l.opts.TickerURLPtr)),
+		WithExpandInterval("", flagValue("expand-interval", l.opts.ExpandIntervalPtr)),
+		WithAuthKeysFile("", flagValue("auth-keys", l.opts.AuthKeysPtr)),
+		WithAuthJWKSFile("", flagValue("auth-jwks", l.opts.AuthJWKSPtr)),
+		WithAllowedOrigins("", flagValue("allowed-origins", l.opts.AllowedOriginsPtr)),
+		WithRateLimit("", flagValue("rate-limit", l.opts.RateLimitPtr)),
+		WithMaxConnections("", flagValue("ws-max-conns", l.opts.MaxConnsPtr)),
+		WithMaxConnectionsPerClient("", flagValue("ws-max-conns-per-client", l.opts.MaxConnsClientPtr)),
+		WithMessageRate("", flagValue("ws-message-rate", l.opts.MessageRatePtr)),
+		WithWSCompression("", flagValue("ws-compression", l.opts.WSCompressionPtr)),
+		WithNATSURL("", flagValue("nats-url", l.opts.NATSURLPtr)),
+		WithKafkaBrokers("", flagValue("kafka-brokers", l.opts.KafkaBrokersPtr)),
+		WithRedisURL("", flagValue("redis-url", l.opts.RedisURLPtr)),
+		WithBookSnapshotURL("", flagValue("book-snapshot-url", l.opts.BookSnapshotURLPtr)),
+	); err != nil {
+		return nil, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
 }
 
-func parseFlags() Opts {
-	flags := Opts{
-		APtr: flag.String("a", "localhost:8080", "HTTP-server endpoint (default localhost:8080)"),
-		IPtr: flag.String("i", "btcusdt@depth", "streams (default btcusdt@depth)"),
+func parseFlags(fs *flag.FlagSet) Opts {
+	return Opts{
+		APtr: fs.String("a", DefaultAddress, "HTTP-server endpoint (default "+DefaultAddress+")"),
+		AdminPtr: fs.String("admin-address", DefaultAdminAddress,
+			"admin HTTP-server endpoint for pprof, swagger, metrics and admin API, empty disables (default "+
+				DefaultAdminAddress+")"),
+		GRPCPtr: fs.String("grpc-address", DefaultGRPCAddress,
+			"gRPC server endpoint, empty disables (default "+DefaultGRPCAddress+")"),
+		TLSCertPtr:     fs.String("tls-cert", "", "PEM certificate to serve wss:// and https://"),
+		TLSKeyPtr:      fs.String("tls-key", "", "PEM private key of -tls-cert"),
+		TLSClientCAPtr: fs.String("tls-client-ca", "", "PEM CA bundle to verify client certificates (mTLS)"),
+		TLSClientAuthPtr: fs.String("tls-client-auth", "",
+			"client certificates: none, optional or require (default require with -tls-client-ca)"),
+		IPtr: fs.String("i", DefaultInstruments, "streams (default "+DefaultInstruments+")"),
+		UpstreamPtr: fs.String("upstream", DefaultUpstreamURL,
+			"upstream websocket endpoint (default "+DefaultUpstreamURL+")"),
+		ProxyPtr: fs.String("proxy", "", "upstream http or socks5 proxy url"),
+		CAPtr:    fs.String("ca", "", "PEM CA bundle used to verify the upstream certificate"),
+		HandshakeTimeoutPtr: fs.String("handshake-timeout", DefaultUpstreamHandshakeTimeout.String(),
+			"upstream websocket handshake timeout (default "+DefaultUpstreamHandshakeTimeout.String()+")"),
+		CompressionPtr: fs.String("compression", "false", "negotiate permessage-deflate with upstream (default false)"),
+		ConfigPtr:      fs.String("config", "", "path to YAML config file"),
+		LogLevelPtr:    fs.String("log-level", DefaultLogLevel, "log level (default "+DefaultLogLevel+")"),
+		StoragePtr: fs.String("storage", DefaultStorageBackend,
+			"storage backend: memory or snapshot (default "+DefaultStorageBackend+")"),
+		ExchangeInfoURLPtr: fs.String("exchange-info-url", DefaultExchangeInfoURL,
+			"exchangeInfo endpoint used to validate instruments (default "+DefaultExchangeInfoURL+")"),
+		ExchangeInfoFilePtr: fs.String("exchange-info-file", "", "local exchangeInfo snapshot, overrides -exchange-info-url"),
+		TickerURLPtr: fs.String("exchange-ticker-url", DefaultExchangeTickerURL,
+			"24h ticker endpoint used by top=N patterns (default "+DefaultExchangeTickerURL+")"),
+		BookSnapshotURLPtr: fs.String("book-snapshot-url", DefaultBookSnapshotURL,
+			"order book snapshots endpoint, empty builds books from depth updates only (default "+
+				DefaultBookSnapshotURL+")"),
+		ExpandIntervalPtr: fs.String("expand-interval", DefaultExpandInterval.String(),
+			"instrument patterns re-expansion interval, 0 disables (default "+DefaultExpandInterval.String()+")"),
+		AuthKeysPtr:       fs.String("auth-keys", "", "YAML file with API keys and scopes"),
+		AuthJWKSPtr:       fs.String("auth-jwks", "", "JWKS file with public keys to verify JWT"),
+		AllowedOriginsPtr: fs.String("allowed-origins", "", "comma separated websocket origins, * allows any"),
+		RateLimitPtr: fs.String("rate-limit", strconv.Itoa(DefaultRequestsPerSecond),
+			"requests per second per client, 0 disables (default "+strconv.Itoa(DefaultRequestsPerSecond)+")"),
+		MaxConnsPtr: fs.String("ws-max-conns", strconv.Itoa(DefaultMaxConnections),
+			"max concurrent websocket connections, 0 disables (default "+strconv.Itoa(DefaultMaxConnections)+")"),
+		MaxConnsClientPtr: fs.String("ws-max-conns-per-client", strconv.Itoa(DefaultMaxConnectionsPerClient),
+			"max concurrent websocket connections per client, 0 disables (default "+
+				strconv.Itoa(DefaultMaxConnectionsPerClient)+")"),
+		MessageRatePtr: fs.String("ws-message-rate", strconv.Itoa(DefaultMessagesPerSecond),
+			"inbound websocket messages per second per connection, 0 disables (default "+
+				strconv.Itoa(DefaultMessagesPerSecond)+")"),
+		NATSURLPtr:      fs.String("nats-url", "", "NATS server url to publish market data, empty disables"),
+		KafkaBrokersPtr: fs.String("kafka-brokers", "", "comma separated Kafka brokers to publish market data, empty disables"),
+		RedisURLPtr:     fs.String("redis-url", "", "Redis url to mirror quotes, empty disables"),
+		PrintConfigPtr:  fs.Bool("print-config", false, "print effective config and exit"),
 	}
+}
 
-	flag.Parse()
+// pick returns the explicit value or the pointed one if the value is empty.
+func pick(v string, ptr *string) string {
+	if v == "" && ptr != nil {
+		return *ptr
+	}
 
-	return flags
+	return v
 }
 
-func WithAddress(a string, aPtr *string) func(*Config) {
-	return func(c *Config) {
-		if a == "" && aPtr != nil {
-			a = *aPtr
+func WithAddress(a string, aPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		a = pick(a, aPtr)
+		if a == "" {
+			return nil
 		}
 
 		addr := strings.Split(a, ":")
@@ -75,35 +736,826 @@ func WithAddress(a string, aPtr *string) func(*Config) {
 		const reqLen = 2
 
 		if len(addr) != reqLen {
-			panic(fmt.Errorf("wrong a parameters"))
-		}
-
-		if addr[0] != "localhost" {
-			c.Host = addr[0]
+			return fmt.Errorf("address %q: expected host:port", a)
 		}
 
 		port, err := strconv.Atoi(addr[1])
 		if err != nil {
-			panic(fmt.Errorf("wrong a parameters"))
+			return fmt.Errorf("address %q: port %q is not a number", a, addr[1])
+		}
+
+		c.Host = addr[0]
+		if addr[0] == "localhost" {
+			c.Host = ""
 		}
 
 		c.Port = port
+
+		return nil
 	}
 }
 
-func WithInstruments(i string, iPtr *string) func(*Config) {
-	return func(c *Config) {
-		if i == "" && iPtr != nil {
-			i = *iPtr
+// WithAdminAddress sets admin listener address, explicitly passed empty flag disables it.
+func WithAdminAddress(a string, aPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if a != "" || aPtr != nil {
+			c.Admin.Address = pick(a, aPtr)
 		}
 
-		split := strings.Split(i, ",")
-		instruments := make([]string, len(split))
+		return nil
+	}
+}
+
+func WithTLSCert(f string, fPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if f = pick(f, fPtr); f != "" {
+			c.TLS.CertFile = f
+		}
+
+		return nil
+	}
+}
+
+func WithTLSKey(f string, fPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if f = pick(f, fPtr); f != "" {
+			c.TLS.KeyFile = f
+		}
+
+		return nil
+	}
+}
+
+func WithTLSClientCA(f string, fPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if f = pick(f, fPtr); f != "" {
+			c.TLS.ClientCAFile = f
+		}
+
+		return nil
+	}
+}
 
-		for i := range split {
-			instruments[i] = strings.TrimSpace(split[i])
+func WithTLSClientAuth(a string, aPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if a = pick(a, aPtr); a != "" {
+			c.TLS.ClientAuth = strings.ToLower(a)
 		}
 
-		c.Instruments = instruments
+		return nil
 	}
 }
+
+func WithInstruments(i string, iPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		i = pick(i, iPtr)
+		if i == "" {
+			return nil
+		}
+
+		c.Instruments = mergeSelectors(splitList(i))
+
+		return nil
+	}
+}
+
+func WithUpstreamURL(u string, uPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if u = pick(u, uPtr); u != "" {
+			c.Upstream.BaseURL = u
+		}
+
+		return nil
+	}
+}
+
+func WithUpstreamProxy(p string, pPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if p = pick(p, pPtr); p != "" {
+			c.Upstream.ProxyURL = p
+		}
+
+		return nil
+	}
+}
+
+func WithUpstreamCA(ca string, caPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if ca = pick(ca, caPtr); ca != "" {
+			c.Upstream.CAFile = ca
+		}
+
+		return nil
+	}
+}
+
+func WithUpstreamHandshakeTimeout(t string, tPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		t = pick(t, tPtr)
+		if t == "" {
+			return nil
+		}
+
+		timeout, err := time.ParseDuration(t)
+		if err != nil {
+			return fmt.Errorf("upstream handshake timeout %q: %w", t, err)
+		}
+
+		c.Upstream.HandshakeTimeout = timeout
+
+		return nil
+	}
+}
+
+func WithUpstreamCompression(b string, bPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		b = pick(b, bPtr)
+		if b == "" {
+			return nil
+		}
+
+		compression, err := strconv.ParseBool(b)
+		if err != nil {
+			return fmt.Errorf("upstream compression %q: expected true or false", b)
+		}
+
+		c.Upstream.Compression = compression
+
+		return nil
+	}
+}
+
+func WithLogLevel(l string, lPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if l = pick(l, lPtr); l != "" {
+			c.Log.Level = strings.ToLower(l)
+		}
+
+		return nil
+	}
+}
+
+func WithStorageBackend(b string, bPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if b = pick(b, bPtr); b != "" {
+			c.Storage.Backend = strings.ToLower(b)
+		}
+
+		return nil
+	}
+}
+
+// WithExchangeInfoURL sets exchangeInfo endpoint, explicitly passed empty flag disables validation.
+func WithExchangeInfoURL(u string, uPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if u != "" || uPtr != nil {
+			c.Exchange.InfoURL = pick(u, uPtr)
+		}
+
+		return nil
+	}
+}
+
+func WithExchangeInfoFile(f string, fPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if f = pick(f, fPtr); f != "" {
+			c.Exchange.InfoFile = f
+		}
+
+		return nil
+	}
+}
+
+func WithExchangeTickerURL(u string, uPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if u = pick(u, uPtr); u != "" {
+			c.Exchange.TickerURL = u
+		}
+
+		return nil
+	}
+}
+
+func WithExpandInterval(i string, iPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		i = pick(i, iPtr)
+		if i == "" {
+			return nil
+		}
+
+		interval, err := time.ParseDuration(i)
+		if err != nil {
+			return fmt.Errorf("expand interval %q: %w", i, err)
+		}
+
+		c.Exchange.ExpandInterval = interval
+
+		return nil
+	}
+}
+
+func WithAuthKeysFile(f string, fPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if f = pick(f, fPtr); f != "" {
+			c.Auth.KeysFile = f
+		}
+
+		return nil
+	}
+}
+
+// WithGRPCAddress sets gRPC listener address, explicitly passed empty flag disables it.
+func WithGRPCAddress(a string, aPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if a != "" || aPtr != nil {
+			c.GRPC.Address = pick(a, aPtr)
+		}
+
+		return nil
+	}
+}
+
+// WithAuthJWTSecret sets HMAC secret, it is not accepted from flags to keep it out of process list.
+func WithAuthJWTSecret(secret string) func(*Config) error {
+	return func(c *Config) error {
+		if secret != "" {
+			c.Auth.JWTSecret = secret
+		}
+
+		return nil
+	}
+}
+
+func WithAuthJWKSFile(f string, fPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if f = pick(f, fPtr); f != "" {
+			c.Auth.JWKSFile = f
+		}
+
+		return nil
+	}
+}
+
+func WithAllowedOrigins(o string, oPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if o = pick(o, oPtr); o != "" {
+			c.Auth.AllowedOrigins = splitList(o)
+		}
+
+		return nil
+	}
+}
+
+func WithRateLimit(r string, rPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		return parseFloat("rate limit", pick(r, rPtr), &c.Limits.RequestsPerSecond)
+	}
+}
+
+// WithRateBurst sets requests burst, it is accepted from file and environment only.
+func WithRateBurst(b string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("rate limit burst", b, &c.Limits.RequestsBurst)
+	}
+}
+
+// WithIPRateLimit sets requests per second per IP, it is accepted from file and environment only.
+func WithIPRateLimit(r string) func(*Config) error {
+	return func(c *Config) error {
+		return parseFloat("ip rate limit", r, &c.Limits.IPRequestsPerSecond)
+	}
+}
+
+// WithIPRateBurst sets requests burst per IP, it is accepted from file and environment only.
+func WithIPRateBurst(b string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("ip rate limit burst", b, &c.Limits.IPRequestsBurst)
+	}
+}
+
+func WithMaxConnections(m string, mPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("max connections", pick(m, mPtr), &c.Limits.MaxConnections)
+	}
+}
+
+func WithMaxConnectionsPerClient(m string, mPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("max connections per client", pick(m, mPtr), &c.Limits.MaxConnectionsPerClient)
+	}
+}
+
+func WithMessageRate(r string, rPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		return parseFloat("message rate", pick(r, rPtr), &c.Limits.MessagesPerSecond)
+	}
+}
+
+// WithMessageBurst sets inbound messages burst, it is accepted from file and environment only.
+func WithMessageBurst(b string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("message burst", b, &c.Limits.MessagesBurst)
+	}
+}
+
+func WithWSCompression(b string, bPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		b = pick(b, bPtr)
+		if b == "" {
+			return nil
+		}
+
+		compression, err := strconv.ParseBool(b)
+		if err != nil {
+			return fmt.Errorf("websocket compression %q: expected true or false", b)
+		}
+
+		c.WebSocket.Compression = compression
+
+		return nil
+	}
+}
+
+// WithStreamReplay sets event stream replay buffer size, it is accepted from file and environment only.
+func WithStreamReplay(r string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("stream replay", r, &c.Stream.Replay)
+	}
+}
+
+// WithStreamHeartbeat sets event stream heartbeat interval, it is accepted from file and environment only.
+func WithStreamHeartbeat(h string) func(*Config) error {
+	return func(c *Config) error {
+		if h == "" {
+			return nil
+		}
+
+		interval, err := time.ParseDuration(h)
+		if err != nil {
+			return fmt.Errorf("stream heartbeat %q: %w", h, err)
+		}
+
+		c.Stream.Heartbeat = interval
+
+		return nil
+	}
+}
+
+// WithSinkBuffer sets queue size of every sink, it is accepted from file and environment only.
+func WithSinkBuffer(b string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("sink buffer", b, &c.Sinks.Buffer)
+	}
+}
+
+// WithSinkBatchSize sets batch size of every sink, it is accepted from file and environment only.
+func WithSinkBatchSize(b string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("sink batch size", b, &c.Sinks.BatchSize)
+	}
+}
+
+// WithSinkFlushInterval sets flush interval of every sink, it is accepted from file and environment only.
+func WithSinkFlushInterval(i string) func(*Config) error {
+	return func(c *Config) error {
+		if i == "" {
+			return nil
+		}
+
+		interval, err := time.ParseDuration(i)
+		if err != nil {
+			return fmt.Errorf("sink flush interval %q: %w", i, err)
+		}
+
+		c.Sinks.FlushInterval = interval
+
+		return nil
+	}
+}
+
+// WithSinkOverflow sets full queue policy, it is accepted from file and environment only.
+func WithSinkOverflow(o string) func(*Config) error {
+	return func(c *Config) error {
+		if o != "" {
+			c.Sinks.Overflow = strings.ToLower(o)
+		}
+
+		return nil
+	}
+}
+
+func WithNATSURL(u string, uPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if u = pick(u, uPtr); u != "" {
+			c.Sinks.NATS.URL = u
+		}
+
+		return nil
+	}
+}
+
+// WithNATSSubjectPrefix sets NATS subjects prefix, it is accepted from file and environment only.
+func WithNATSSubjectPrefix(p string) func(*Config) error {
+	return func(c *Config) error {
+		if p != "" {
+			c.Sinks.NATS.SubjectPrefix = p
+		}
+
+		return nil
+	}
+}
+
+func WithKafkaBrokers(b string, bPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if b = pick(b, bPtr); b != "" {
+			c.Sinks.Kafka.Brokers = splitList(b)
+		}
+
+		return nil
+	}
+}
+
+// WithKafkaTopicPrefix sets Kafka topics prefix, it is accepted from file and environment only.
+func WithKafkaTopicPrefix(p string) func(*Config) error {
+	return func(c *Config) error {
+		if p != "" {
+			c.Sinks.Kafka.TopicPrefix = p
+		}
+
+		return nil
+	}
+}
+
+// WithRedisURL sets Redis url, it may contain password so prefer REDIS_URL over the flag.
+func WithRedisURL(u string, uPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if u = pick(u, uPtr); u != "" {
+			c.Sinks.Redis.URL = u
+		}
+
+		return nil
+	}
+}
+
+// WithRedisChannel sets Redis pub/sub channel, it is accepted from file and environment only.
+func WithRedisChannel(ch string) func(*Config) error {
+	return func(c *Config) error {
+		if ch != "" {
+			c.Sinks.Redis.Channel = ch
+		}
+
+		return nil
+	}
+}
+
+// WithTradesTapeSize sets number of trades kept per symbol, it is accepted from file and environment only.
+func WithTradesTapeSize(n string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("trades tape size", n, &c.Trades.TapeSize)
+	}
+}
+
+// WithTradesWindows sets comma separated windows of trades statistics like "1m,5m",
+// it is accepted from file and environment only.
+func WithTradesWindows(w string) func(*Config) error {
+	return func(c *Config) error {
+		if w == "" {
+			return nil
+		}
+
+		items := splitList(w)
+		windows := make([]time.Duration, 0, len(items))
+
+		for _, item := range items {
+			window, err := time.ParseDuration(item)
+			if err != nil {
+				return fmt.Errorf("trades window %q: %w", item, err)
+			}
+
+			windows = append(windows, window)
+		}
+
+		c.Trades.Windows = windows
+
+		return nil
+	}
+}
+
+// WithBookSnapshotURL sets order book snapshots endpoint, explicitly passed empty flag builds
+// books from depth updates only.
+func WithBookSnapshotURL(u string, uPtr *string) func(*Config) error {
+	return func(c *Config) error {
+		if u != "" || uPtr != nil {
+			c.Book.SnapshotURL = pick(u, uPtr)
+		}
+
+		return nil
+	}
+}
+
+// WithBookMaxLevels sets number of levels kept per book side, it is accepted from file and environment only.
+func WithBookMaxLevels(n string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("book max levels", n, &c.Book.MaxLevels)
+	}
+}
+
+// WithBookImbalanceLevels sets number of top levels of volume imbalance, it is accepted from file and
+// environment only.
+func WithBookImbalanceLevels(n string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("book imbalance levels", n, &c.Book.ImbalanceLevels)
+	}
+}
+
+// WithBookDepthBPS sets comma separated distances from mid in basis points of cumulative liquidity
+// like "10,25", it is accepted from file and environment only.
+func WithBookDepthBPS(bps string) func(*Config) error {
+	return func(c *Config) error {
+		return parseFloats("book depth bps", bps, &c.Book.DepthBPS)
+	}
+}
+
+// WithBookNotionals sets comma separated quote volumes of slippage estimates like "10000,100000",
+// it is accepted from file and environment only.
+func WithBookNotionals(notionals string) func(*Config) error {
+	return func(c *Config) error {
+		return parseFloats("book notional", notionals, &c.Book.Notionals)
+	}
+}
+
+// WithArbitrageFeeBPS sets fee of every arbitrage leg in basis points, it is accepted from file and
+// environment only.
+func WithArbitrageFeeBPS(fee string) func(*Config) error {
+	return func(c *Config) error {
+		return parseFloat("arbitrage fee bps", fee, &c.Arbitrage.FeeBPS)
+	}
+}
+
+// WithArbitrageThresholdBPS sets edge in basis points arbitrage opportunities are emitted above,
+// it is accepted from file and environment only.
+func WithArbitrageThresholdBPS(threshold string) func(*Config) error {
+	return func(c *Config) error {
+		return parseFloat("arbitrage threshold bps", threshold, &c.Arbitrage.ThresholdBPS)
+	}
+}
+
+// WithAlertWebhookSecret sets HMAC key of alert webhooks, it is accepted from file and environment only.
+func WithAlertWebhookSecret(secret string) func(*Config) error {
+	return func(c *Config) error {
+		if secret != "" {
+			c.Alerts.WebhookSecret = secret
+		}
+
+		return nil
+	}
+}
+
+// WithAlertWebhookHosts sets comma separated hosts webhooks of API rules may post to,
+// it is accepted from file and environment only.
+func WithAlertWebhookHosts(h string) func(*Config) error {
+	return func(c *Config) error {
+		if h != "" {
+			c.Alerts.WebhookHosts = splitList(h)
+		}
+
+		return nil
+	}
+}
+
+// WithAlertMaxRules limits the number of alert rules, it is accepted from file and environment only.
+func WithAlertMaxRules(n string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("alert max rules", n, &c.Alerts.MaxRules)
+	}
+}
+
+// WithAlertRetries sets retries of failed alert webhooks, it is accepted from file and environment only.
+func WithAlertRetries(r string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("alert retries", r, &c.Alerts.Retries)
+	}
+}
+
+// WithAlertCheckInterval sets interval of age rules checks, it is accepted from file and environment only.
+func WithAlertCheckInterval(i string) func(*Config) error {
+	return func(c *Config) error {
+		if i == "" {
+			return nil
+		}
+
+		interval, err := time.ParseDuration(i)
+		if err != nil {
+			return fmt.Errorf("alert check interval %q: %w", i, err)
+		}
+
+		c.Alerts.CheckInterval = interval
+
+		return nil
+	}
+}
+
+// WithStalenessThreshold sets how long symbols may go without updates before they are stale,
+// it is accepted from file and environment only.
+func WithStalenessThreshold(t string) func(*Config) error {
+	return func(c *Config) error {
+		if t == "" {
+			return nil
+		}
+
+		threshold, err := time.ParseDuration(t)
+		if err != nil {
+			return fmt.Errorf("staleness threshold %q: %w", t, err)
+		}
+
+		c.Staleness.Threshold = threshold
+
+		return nil
+	}
+}
+
+// WithStalenessCheckInterval sets how often symbols are checked for staleness, it is accepted
+// from file and environment only.
+func WithStalenessCheckInterval(i string) func(*Config) error {
+	return func(c *Config) error {
+		if i == "" {
+			return nil
+		}
+
+		interval, err := time.ParseDuration(i)
+		if err != nil {
+			return fmt.Errorf("staleness check interval %q: %w", i, err)
+		}
+
+		c.Staleness.CheckInterval = interval
+
+		return nil
+	}
+}
+
+// WithStalenessSuppress hides stale quotes from REST and websocket snapshots, it is accepted
+// from file and environment only.
+func WithStalenessSuppress(s string) func(*Config) error {
+	return func(c *Config) error {
+		if s == "" {
+			return nil
+		}
+
+		suppress, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("staleness suppress %q: expected true or false", s)
+		}
+
+		c.Staleness.Suppress = suppress
+
+		return nil
+	}
+}
+
+// WithLatencyWindow sets window of latency statistics and clock skew estimate, it is accepted
+// from file and environment only.
+func WithLatencyWindow(w string) func(*Config) error {
+	return func(c *Config) error {
+		if w == "" {
+			return nil
+		}
+
+		window, err := time.ParseDuration(w)
+		if err != nil {
+			return fmt.Errorf("latency window %q: %w", w, err)
+		}
+
+		c.Latency.Window = window
+
+		return nil
+	}
+}
+
+// WithLatencySamples sets number of the latest delays kept per stream type, it is accepted
+// from file and environment only.
+func WithLatencySamples(s string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("latency samples", s, &c.Latency.Samples)
+	}
+}
+
+// WithIngestWorkers sets number of decoder workers, it is accepted from file and environment only.
+func WithIngestWorkers(w string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("ingest workers", w, &c.Ingest.Workers)
+	}
+}
+
+// WithIngestQueue sets capacity of ingest queues, it is accepted from file and environment only.
+func WithIngestQueue(q string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("ingest queue", q, &c.Ingest.Queue)
+	}
+}
+
+// WithIngestBatchSize sets maximum number of updates applied at once, it is accepted from file
+// and environment only.
+func WithIngestBatchSize(s string) func(*Config) error {
+	return func(c *Config) error {
+		return parseInt("ingest batch size", s, &c.Ingest.BatchSize)
+	}
+}
+
+// WithIngestOverflow sets full queue policy of ingest, it is accepted from file and environment only.
+func WithIngestOverflow(o string) func(*Config) error {
+	return func(c *Config) error {
+		if o != "" {
+			c.Ingest.Overflow = strings.ToLower(o)
+		}
+
+		return nil
+	}
+}
+
+// parseInt stores non-empty value into dst.
+func parseInt(name, v string, dst *int) error {
+	if v == "" {
+		return nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("%s %q: expected integer", name, v)
+	}
+
+	*dst = i
+
+	return nil
+}
+
+// parseFloat stores non-empty value into dst.
+func parseFloat(name, v string, dst *float64) error {
+	if v == "" {
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return fmt.Errorf("%s %q: expected number", name, v)
+	}
+
+	*dst = f
+
+	return nil
+}
+
+// parseFloats stores non-empty comma separated numbers into dst.
+func parseFloats(name, v string, dst *[]float64) error {
+	if v == "" {
+		return nil
+	}
+
+	items := splitList(v)
+	res := make([]float64, 0, len(items))
+
+	for _, item := range items {
+		f, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			return fmt.Errorf("%s %q: expected number", name, item)
+		}
+
+		res = append(res, f)
+	}
+
+	*dst = res
+
+	return nil
+}
+
+// mergeSelectors joins comma separated key=value parts back into one selector,
+// e.g. ["quote=USDT", "top=50@bookTicker"] becomes ["quote=USDT,top=50@bookTicker"].
+func mergeSelectors(items []string) []string {
+	merged := make([]string, 0, len(items))
+
+	for _, item := range items {
+		last := len(merged) - 1
+		if last >= 0 && strings.Contains(item, "=") &&
+			strings.Contains(merged[last], "=") && !strings.Contains(merged[last], "@") {
+			merged[last] += "," + item
+			continue
+		}
+
+		merged = append(merged, item)
+	}
+
+	return merged
+}
+
+// splitList splits comma separated value, trims spaces and drops empty items.
+func splitList(s string) []string {
+	split := strings.Split(s, ",")
+	items := make([]string, 0, len(split))
+
+	for i := range split {
+		if item := strings.TrimSpace(split[i]); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/ole-larsen/binance-subscriber/internal/server/config"
 	"github.com/stretchr/testify/assert"
@@ -24,7 +25,8 @@ func Test_NewConfig(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := config.GetConfig()
+			cfg, err := config.GetConfig()
+			require.NoError(t, err)
 
 			configPtr := fmt.Sprintf("%p", cfg)
 
@@ -32,7 +34,9 @@ func Test_NewConfig(t *testing.T) {
 			assert.NotEqual(t, "", cfg.Port)
 
 			for i := 0; i < 10; i++ {
-				c := config.GetConfig()
+				c, e := config.GetConfig()
+				require.NoError(t, e)
+
 				cPtr := fmt.Sprintf("%p", c)
 				assert.Equal(t, configPtr, cPtr)
 			}
@@ -41,7 +45,8 @@ func Test_NewConfig(t *testing.T) {
 
 			assert.Empty(t, cfg)
 
-			cfg = config.InitConfig()
+			cfg, err = config.InitConfig()
+			require.NoError(t, err)
 
 			assert.Empty(t, cfg)
 		})
@@ -50,7 +55,7 @@ func Test_NewConfig(t *testing.T) {
 
 func Test_InitConfig(t *testing.T) {
 	type args struct {
-		opts []func(*config.Config)
+		opts []func(*config.Config) error
 	}
 
 	address := defaultAddress
@@ -62,7 +67,7 @@ func Test_InitConfig(t *testing.T) {
 		{
 			name: "test init config functional options with env variables",
 			args: args{
-				opts: []func(*config.Config){
+				opts: []func(*config.Config) error{
 					config.WithAddress(address, nil),
 				},
 			},
@@ -70,7 +75,7 @@ func Test_InitConfig(t *testing.T) {
 		{
 			name: "test init config functional options with parsed flags",
 			args: args{
-				opts: []func(*config.Config){
+				opts: []func(*config.Config) error{
 					config.WithAddress("", &address),
 				},
 			},
@@ -78,7 +83,8 @@ func Test_InitConfig(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := config.InitConfig(tt.args.opts...)
+			cfg, err := config.InitConfig(tt.args.opts...)
+			require.NoError(t, err)
 			assert.Equal(t, "", cfg.Host)
 			assert.Equal(t, 8080, cfg.Port)
 		})
@@ -123,11 +129,11 @@ func Test_withAddress(t *testing.T) {
 	invalidAddressPort := "localhost:notPort" // Invalid port
 
 	tests := []struct {
-		name      string
-		args      args
-		wantHost  string
-		wantPort  int
-		wantPanic bool
+		name     string
+		args     args
+		wantHost string
+		wantPort int
+		wantErr  bool
 	}{
 		{
 			name: "valid address with environment variable",
@@ -135,9 +141,9 @@ func Test_withAddress(t *testing.T) {
 				a:    address,
 				aPtr: nil,
 			},
-			wantHost:  "",
-			wantPort:  8080,
-			wantPanic: false,
+			wantHost: "",
+			wantPort: 8080,
+			wantErr:  false,
 		},
 		{
 			name: "valid address with command line argument",
@@ -145,9 +151,9 @@ func Test_withAddress(t *testing.T) {
 				a:    "",
 				aPtr: &address,
 			},
-			wantHost:  "",
-			wantPort:  8080,
-			wantPanic: false,
+			wantHost: "",
+			wantPort: 8080,
+			wantErr:  false,
 		},
 		{
 			name: "invalid address format",
@@ -155,7 +161,7 @@ func Test_withAddress(t *testing.T) {
 				a:    invalidAddressFormat,
 				aPtr: nil,
 			},
-			wantPanic: true,
+			wantErr: true,
 		},
 		{
 			name: "invalid address format with command line argument",
@@ -163,7 +169,7 @@ func Test_withAddress(t *testing.T) {
 				a:    "",
 				aPtr: &invalidAddressFormat,
 			},
-			wantPanic: true,
+			wantErr: true,
 		},
 		{
 			name: "invalid port number",
@@ -171,7 +177,7 @@ func Test_withAddress(t *testing.T) {
 				a:    invalidAddressPort,
 				aPtr: nil,
 			},
-			wantPanic: true,
+			wantErr: true,
 		},
 		{
 			name: "invalid port number with command line argument",
@@ -179,21 +185,61 @@ func Test_withAddress(t *testing.T) {
 				a:    "",
 				aPtr: &invalidAddressPort,
 			},
-			wantPanic: true,
+			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.wantPanic {
-				assert.Panics(t, func() {
-					config.InitConfig(config.WithAddress(tt.args.a, tt.args.aPtr))
-				})
+			cfg, err := config.InitConfig(config.WithAddress(tt.args.a, tt.args.aPtr))
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "[config]: address")
 			} else {
-				cfg := config.InitConfig(config.WithAddress(tt.args.a, tt.args.aPtr))
+				require.NoError(t, err)
 				assert.Equal(t, tt.wantHost, cfg.Host)
 				assert.Equal(t, tt.wantPort, cfg.Port)
 			}
 		})
 	}
 }
+
+func Test_withUpstream(t *testing.T) {
+	proxy := "socks5://127.0.0.1:1080"
+	timeout := "5s"
+
+	cfg, err := config.InitConfig(
+		config.WithUpstreamURL("wss://testnet.binance.vision/stream", nil),
+		config.WithUpstreamProxy("", &proxy),
+		config.WithUpstreamCA("/etc/ssl/ca.pem", nil),
+		config.WithUpstreamHandshakeTimeout("", &timeout),
+		config.WithUpstreamCompression("true", nil),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "wss://testnet.binance.vision/stream", cfg.Upstream.BaseURL)
+	assert.Equal(t, proxy, cfg.Upstream.ProxyURL)
+	assert.Equal(t, "/etc/ssl/ca.pem", cfg.Upstream.CAFile)
+	assert.Equal(t, 5*time.Second, cfg.Upstream.HandshakeTimeout)
+	assert.True(t, cfg.Upstream.Compression)
+
+	_, err = config.InitConfig(config.WithUpstreamHandshakeTimeout("soon", nil))
+	assert.ErrorContains(t, err, "upstream handshake timeout")
+
+	_, err = config.InitConfig(config.WithUpstreamCompression("maybe", nil))
+	assert.ErrorContains(t, err, "expected true or false")
+}
+
+func Test_withInstrumentPatterns(t *testing.T) {
+	cfg, err := config.InitConfig(
+		config.WithInstruments("btcusdt@depth, quote=USDT,top=50, *btc@bookTicker, base=ETH,quote=BTC@depth", nil),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"btcusdt@depth",
+		"quote=USDT,top=50",
+		"*btc@bookTicker",
+		"base=ETH,quote=BTC@depth",
+	}, cfg.Instruments)
+}
@@ -0,0 +1,22 @@
+package config_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/server/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	require.NoError(t, config.NewError(nil))
+
+	stdErr := errors.New("something went wrong")
+	err := config.NewError(stdErr)
+
+	var configErr *config.Error
+	require.True(t, errors.As(err, &configErr))
+	assert.Equal(t, "[config]: something went wrong", configErr.Error())
+	assert.True(t, errors.Is(err, stdErr))
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ole-larsen/binance-subscriber/internal/synthetic"
+)
+
+const masked = "******"
+
+// document is the YAML representation of Config.
+type document struct {
+	Address     string                 `yaml:"address"`
+	Admin       Admin                  `yaml:"admin"`
+	GRPC        GRPC                   `yaml:"grpc"`
+	TLS         TLS                    `yaml:"tls"`
+	Instruments []string               `yaml:"instruments"`
+	Synthetics  []synthetic.Instrument `yaml:"synthetics,omitempty"`
+	Upstream    Upstream               `yaml:"upstream"`
+	Exchange    Exchange               `yaml:"exchange"`
+	Auth        Auth                   `yaml:"auth"`
+	Limits      Limits                 `yaml:"limits"`
+	WebSocket   WebSocket              `yaml:"websocket"`
+	Stream      Stream                 `yaml:"stream"`
+	Sinks       Sinks                  `yaml:"sinks"`
+	Trades      Trades                 `yaml:"trades"`
+	Book        Book                   `yaml:"book"`
+	Arbitrage   Arbitrage              `yaml:"arbitrage"`
+	Alerts      Alerts                 `yaml:"alerts"`
+	Staleness   Staleness              `yaml:"staleness"`
+	Latency     Latency                `yaml:"latency"`
+	Ingest      Ingest                 `yaml:"ingest"`
+	Log         Log                    `yaml:"log"`
+	Storage     Storage                `yaml:"storage"`
+}
+
+func newDocument(c *Config) *document {
+	host := c.Host
+	if host == "" {
+		host = "localhost"
+	}
+
+	return &document{
+		Address:     host + ":" + strconv.Itoa(c.Port),
+		Admin:       c.Admin,
+		GRPC:        c.GRPC,
+		TLS:         c.TLS,
+		Instruments: c.Instruments,
+		Synthetics:  c.Synthetics,
+		Upstream:    c.Upstream,
+		Exchange:    c.Exchange,
+		Auth:        c.Auth,
+		Limits:      c.Limits,
+		WebSocket:   c.WebSocket,
+		Stream:      c.Stream,
+		Sinks:       c.Sinks,
+		Trades:      c.Trades,
+		Book:        c.Book,
+		Arbitrage:   c.Arbitrage,
+		Alerts:      c.Alerts,
+		Staleness:   c.Staleness,
+		Latency:     c.Latency,
+		Ingest:      c.Ingest,
+		Log:         c.Log,
+		Storage:     c.Storage,
+	}
+}
+
+// WithFile reads YAML config file. Keys missing in the file keep their current values,
+// unknown keys are rejected.
+func WithFile(path string) func(*Config) error {
+	return func(c *Config) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("config file: %w", err)
+		}
+
+		doc := newDocument(c)
+		doc.Address = ""
+
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+
+		if err = dec.Decode(doc); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+
+		c.Instruments = doc.Instruments
+		c.Synthetics = doc.Synthetics
+		c.Admin = doc.Admin
+		c.GRPC = doc.GRPC
+		c.TLS = doc.TLS
+		c.Upstream = doc.Upstream
+		c.Exchange = doc.Exchange
+		c.Auth = doc.Auth
+		c.Limits = doc.Limits
+		c.WebSocket = doc.WebSocket
+		c.Stream = doc.Stream
+		c.Sinks = doc.Sinks
+		c.Trades = doc.Trades
+		c.Book = doc.Book
+		c.Arbitrage = doc.Arbitrage
+		c.Alerts = doc.Alerts
+		c.Staleness = doc.Staleness
+		c.Latency = doc.Latency
+		c.Ingest = doc.Ingest
+		c.Log = doc.Log
+		c.Storage = doc.Storage
+
+		if err = WithAddress(doc.Address, nil)(c); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+
+		return nil
+	}
+}
+
+// Dump writes effective configuration as YAML, secrets are masked.
+func (c *Config) Dump(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	doc := newDocument(c)
+	if doc.Auth.JWTSecret != "" {
+		doc.Auth.JWTSecret = masked
+	}
+
+	if doc.Alerts.WebhookSecret != "" {
+		doc.Alerts.WebhookSecret = masked
+	}
+
+	doc.Upstream.ProxyURL = redact(doc.Upstream.ProxyURL)
+	doc.Sinks.NATS.URL = redact(doc.Sinks.NATS.URL)
+	doc.Sinks.Redis.URL = redact(doc.Sinks.Redis.URL)
+
+	if err := enc.Encode(doc); err != nil {
+		return NewError(err)
+	}
+
+	return NewError(enc.Close())
+}
+
+// redact masks password of the url.
+func redact(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	return u.Redacted()
+}
@@ -0,0 +1,566 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/ole-larsen/binance-subscriber/internal/alert"
+	"github.com/ole-larsen/binance-subscriber/internal/exchange"
+	"github.com/ole-larsen/binance-subscriber/internal/orderbook"
+	"github.com/ole-larsen/binance-subscriber/internal/synthetic"
+)
+
+const maxPort = 65535
+
+// storageBackends lists supported storage backends.
+var storageBackends = []string{StorageBackendMemory, StorageBackendSnapshot}
+
+// Validate checks the whole configuration and reports every problem at once.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Port < 1 || c.Port > maxPort {
+		errs = append(errs, fmt.Errorf("address: port %d is out of range 1-%d", c.Port, maxPort))
+	}
+
+	if c.Admin.Address != "" {
+		if err := c.Admin.validate(c.Host, c.Port); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.GRPC.Address != "" {
+		if err := c.GRPC.validate(c.Host, c.Port, c.Admin.Address); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	errs = append(errs, c.TLS.validate()...)
+
+	if len(c.Instruments) == 0 {
+		errs = append(errs, errors.New("instruments: at least one stream is required"))
+	}
+
+	patterns := false
+
+	for _, instrument := range c.Instruments {
+		if err := validateInstrument(instrument); err != nil {
+			errs = append(errs, err)
+		}
+
+		patterns = patterns || exchange.IsPattern(instrument)
+	}
+
+	if patterns && c.Exchange.InfoURL == "" && c.Exchange.InfoFile == "" {
+		errs = append(errs, errors.New("instruments: patterns require exchange.info_url or exchange.info_file"))
+	}
+
+	errs = append(errs, validateSynthetics(c.Synthetics, c.Instruments)...)
+	errs = append(errs, c.Upstream.validate()...)
+
+	if c.Exchange.InfoURL != "" {
+		if u, err := url.Parse(c.Exchange.InfoURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = append(errs, fmt.Errorf("exchange.info_url: %q must be a http:// or https:// url", c.Exchange.InfoURL))
+		}
+	}
+
+	if c.Exchange.ExpandInterval < 0 {
+		errs = append(errs, fmt.Errorf("exchange.expand_interval: %s must not be negative", c.Exchange.ExpandInterval))
+	}
+
+	if c.Auth.ReloadInterval < 0 {
+		errs = append(errs, fmt.Errorf("auth.reload_interval: %s must not be negative", c.Auth.ReloadInterval))
+	}
+
+	errs = append(errs, c.Limits.validate()...)
+
+	if c.Stream.Replay < 0 {
+		errs = append(errs, fmt.Errorf("stream.replay: %d must not be negative", c.Stream.Replay))
+	}
+
+	if c.Stream.Heartbeat < 0 {
+		errs = append(errs, fmt.Errorf("stream.heartbeat: %s must not be negative", c.Stream.Heartbeat))
+	}
+
+	errs = append(errs, c.Sinks.validate()...)
+	errs = append(errs, c.Trades.validate()...)
+	errs = append(errs, c.Book.validate()...)
+	errs = append(errs, c.Arbitrage.validate()...)
+	errs = append(errs, c.Alerts.validate()...)
+	errs = append(errs, c.Staleness.validate()...)
+	errs = append(errs, c.Ingest.validate()...)
+
+	if c.Latency.Window < time.Second {
+		errs = append(errs, fmt.Errorf("latency.window: %s must be at least 1s", c.Latency.Window))
+	}
+
+	if c.Latency.Samples < 1 {
+		errs = append(errs, fmt.Errorf("latency.samples: %d must be positive", c.Latency.Samples))
+	}
+
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: unknown level %q", c.Log.Level))
+	}
+
+	if !contains(storageBackends, c.Storage.Backend) {
+		errs = append(errs, fmt.Errorf("storage.backend: unsupported backend %q (supported: %s)",
+			c.Storage.Backend, strings.Join(storageBackends, ", ")))
+	}
+
+	return NewError(errors.Join(errs...))
+}
+
+func (u *Upstream) validate() []error {
+	var errs []error
+
+	if endpoint, err := url.Parse(u.BaseURL); err != nil || (endpoint.Scheme != "ws" && endpoint.Scheme != "wss") {
+		errs = append(errs, fmt.Errorf("upstream.base_url: %q must be a ws:// or wss:// url", u.BaseURL))
+	}
+
+	if u.ProxyURL != "" {
+		proxy, err := url.Parse(u.ProxyURL)
+		if err != nil || !contains([]string{"http", "socks5"}, proxy.Scheme) {
+			errs = append(errs, fmt.Errorf("upstream.proxy_url: %q must be a http:// or socks5:// url", u.ProxyURL))
+		}
+	}
+
+	if u.HandshakeTimeout < 0 {
+		errs = append(errs, fmt.Errorf("upstream.handshake_timeout: %s must not be negative", u.HandshakeTimeout))
+	}
+
+	return errs
+}
+
+func (a *Admin) validate(host string, port int) error {
+	return validateListener("admin.address", a.Address, host, port, "address")
+}
+
+func (g *GRPC) validate(host string, port int, admin string) error {
+	if err := validateListener("grpc.address", g.Address, host, port, "address"); err != nil {
+		return err
+	}
+
+	// invalid admin address is reported by its own validation
+	if adminHost, adminPort, err := splitAddress(admin); err == nil {
+		return validateListener("grpc.address", g.Address, adminHost, adminPort, "admin.address")
+	}
+
+	return nil
+}
+
+// validateListener checks that address is host:port and does not conflict with
+// other listener on host:port.
+func validateListener(name, address, host string, port int, other string) error {
+	listenerHost, p, err := splitAddress(address)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	if p == port && (listenerHost == host || listenerHost == "" || host == "") {
+		return fmt.Errorf("%s: %q conflicts with %s", name, address, other)
+	}
+
+	return nil
+}
+
+// splitAddress splits host:port, localhost is returned as empty host like Config.Host.
+func splitAddress(address string) (host string, port int, err error) {
+	host, p, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", 0, fmt.Errorf("%q: expected host:port", address)
+	}
+
+	port, err = strconv.Atoi(p)
+	if err != nil || port < 1 || port > maxPort {
+		return "", 0, fmt.Errorf("port %q is out of range 1-%d", p, maxPort)
+	}
+
+	if host == "localhost" {
+		host = ""
+	}
+
+	return host, port, nil
+}
+
+// tlsClientAuth lists supported client certificates policies.
+var tlsClientAuth = []string{"", "none", "optional", "require"}
+
+func (t *TLS) validate() []error {
+	var errs []error
+
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		errs = append(errs, errors.New("tls: cert_file and key_file must be set together"))
+	}
+
+	if t.ClientCAFile != "" && !t.Enabled() {
+		errs = append(errs, errors.New("tls.client_ca_file: requires cert_file and key_file"))
+	}
+
+	if !contains(tlsClientAuth, t.ClientAuth) {
+		errs = append(errs, fmt.Errorf("tls.client_auth: unknown policy %q (supported: none, optional, require)", t.ClientAuth))
+	}
+
+	if (t.ClientAuth == "optional" || t.ClientAuth == "require") && t.ClientCAFile == "" {
+		errs = append(errs, fmt.Errorf("tls.client_auth: %q requires client_ca_file", t.ClientAuth))
+	}
+
+	if t.ReloadInterval < 0 {
+		errs = append(errs, fmt.Errorf("tls.reload_interval: %s must not be negative", t.ReloadInterval))
+	}
+
+	return errs
+}
+
+func (l *Limits) validate() []error {
+	var errs []error
+
+	for _, limit := range []struct {
+		name  string
+		value float64
+	}{
+		{"limits.requests_per_second", l.RequestsPerSecond},
+		{"limits.requests_burst", float64(l.RequestsBurst)},
+		{"limits.ip_requests_per_second", l.IPRequestsPerSecond},
+		{"limits.ip_requests_burst", float64(l.IPRequestsBurst)},
+		{"limits.max_connections", float64(l.MaxConnections)},
+		{"limits.max_connections_per_client", float64(l.MaxConnectionsPerClient)},
+		{"limits.messages_per_second", l.MessagesPerSecond},
+		{"limits.messages_burst", float64(l.MessagesBurst)},
+	} {
+		if limit.value < 0 {
+			errs = append(errs, fmt.Errorf("%s: %v must not be negative", limit.name, limit.value))
+		}
+	}
+
+	return errs
+}
+
+func (i *Ingest) validate() []error {
+	var errs []error
+
+	for _, size := range []struct {
+		name  string
+		value int
+	}{
+		{"ingest.workers", i.Workers},
+		{"ingest.queue", i.Queue},
+		{"ingest.batch_size", i.BatchSize},
+	} {
+		if size.value < 1 {
+			errs = append(errs, fmt.Errorf("%s: %d must be positive", size.name, size.value))
+		}
+	}
+
+	if !contains(sinkOverflow, i.Overflow) {
+		errs = append(errs, fmt.Errorf("ingest.overflow: unknown policy %q (supported: block, drop)", i.Overflow))
+	}
+
+	return errs
+}
+
+// sinkOverflow lists supported full queue policies of sinks.
+var sinkOverflow = []string{"drop", "block"}
+
+func (s *Sinks) validate() []error {
+	var errs []error
+
+	if s.Buffer < 1 {
+		errs = append(errs, fmt.Errorf("sinks.buffer: %d must be positive", s.Buffer))
+	}
+
+	if s.BatchSize < 1 {
+		errs = append(errs, fmt.Errorf("sinks.batch_size: %d must be positive", s.BatchSize))
+	}
+
+	if s.FlushInterval <= 0 {
+		errs = append(errs, fmt.Errorf("sinks.flush_interval: %s must be positive", s.FlushInterval))
+	}
+
+	if !contains(sinkOverflow, s.Overflow) {
+		errs = append(errs, fmt.Errorf("sinks.overflow: unknown policy %q (supported: drop, block)", s.Overflow))
+	}
+
+	if s.NATS.URL != "" {
+		if u, err := url.Parse(s.NATS.URL); err != nil || !contains([]string{"nats", "tls"}, u.Scheme) {
+			errs = append(errs, fmt.Errorf("sinks.nats.url: %q must be a nats:// or tls:// url", s.NATS.URL))
+		}
+
+		if s.NATS.SubjectPrefix == "" || strings.ContainsAny(s.NATS.SubjectPrefix, " \t*>") {
+			errs = append(errs, fmt.Errorf("sinks.nats.subject_prefix: %q is not a valid subject", s.NATS.SubjectPrefix))
+		}
+	}
+
+	for _, broker := range s.Kafka.Brokers {
+		if _, _, err := splitAddress(broker); err != nil {
+			errs = append(errs, fmt.Errorf("sinks.kafka.brokers: %w", err))
+		}
+	}
+
+	if len(s.Kafka.Brokers) > 0 && s.Kafka.TopicPrefix == "" {
+		errs = append(errs, errors.New("sinks.kafka.topic_prefix: is required"))
+	}
+
+	if s.Redis.URL != "" {
+		if u, err := url.Parse(s.Redis.URL); err != nil || !contains([]string{"redis", "rediss", "unix"}, u.Scheme) {
+			errs = append(errs, errors.New("sinks.redis.url: must be a redis://, rediss:// or unix:// url"))
+		}
+
+		if s.Redis.Channel == "" {
+			errs = append(errs, errors.New("sinks.redis.channel: is required"))
+		}
+	}
+
+	return errs
+}
+
+// maxBPS is 100%, e.g. of mid.
+const maxBPS = 10000
+
+// maxTradesWindow bounds memory of per second volume buckets.
+const maxTradesWindow = 24 * time.Hour
+
+func (t *Trades) validate() []error {
+	var errs []error
+
+	if t.TapeSize < 1 {
+		errs = append(errs, fmt.Errorf("trades.tape_size: %d must be positive", t.TapeSize))
+	}
+
+	if len(t.Windows) == 0 {
+		errs = append(errs, errors.New("trades.windows: at least one window is required"))
+	}
+
+	for _, w := range t.Windows {
+		if w < time.Second || w > maxTradesWindow {
+			errs = append(errs, fmt.Errorf("trades.windows: %s must be between 1s and %s", w, maxTradesWindow))
+		}
+	}
+
+	return errs
+}
+
+func (b *Book) validate() []error {
+	var errs []error
+
+	if b.SnapshotURL != "" {
+		if u, err := url.Parse(b.SnapshotURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = append(errs, fmt.Errorf("book.snapshot_url: %q must be a http:// or https:// url", b.SnapshotURL))
+		}
+	}
+
+	if b.MaxLevels < 1 || b.MaxLevels > orderbook.MaxSnapshotLevels {
+		errs = append(errs, fmt.Errorf("book.max_levels: %d must be between 1 and %d",
+			b.MaxLevels, orderbook.MaxSnapshotLevels))
+	}
+
+	if b.ImbalanceLevels < 1 {
+		errs = append(errs, fmt.Errorf("book.imbalance_levels: %d must be positive", b.ImbalanceLevels))
+	}
+
+	for _, bps := range b.DepthBPS {
+		if bps <= 0 || bps > maxBPS {
+			errs = append(errs, fmt.Errorf("book.depth_bps: %g must be positive and at most %d", bps, maxBPS))
+		}
+	}
+
+	for _, notional := range b.Notionals {
+		if notional <= 0 {
+			errs = append(errs, fmt.Errorf("book.notionals: %g must be positive", notional))
+		}
+	}
+
+	return errs
+}
+
+func (a *Arbitrage) validate() []error {
+	var errs []error
+
+	if a.FeeBPS < 0 || a.FeeBPS >= maxBPS {
+		errs = append(errs, fmt.Errorf("arbitrage.fee_bps: %g must not be negative and less than %d", a.FeeBPS, maxBPS))
+	}
+
+	ids := make(map[string]bool, len(a.Triangles)+len(a.Pairs))
+
+	for i := range a.Triangles {
+		if err := a.Triangles[i].Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("arbitrage.triangles[%d]: %w", i, err))
+		}
+
+		if ids[a.Triangles[i].ID] {
+			errs = append(errs, fmt.Errorf("arbitrage.triangles[%d]: duplicate id %q", i, a.Triangles[i].ID))
+		}
+
+		ids[a.Triangles[i].ID] = true
+	}
+
+	for i := range a.Pairs {
+		if err := a.Pairs[i].Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("arbitrage.pairs[%d]: %w", i, err))
+		}
+
+		if ids[a.Pairs[i].ID] {
+			errs = append(errs, fmt.Errorf("arbitrage.pairs[%d]: duplicate id %q", i, a.Pairs[i].ID))
+		}
+
+		ids[a.Pairs[i].ID] = true
+	}
+
+	return errs
+}
+
+func (a *Alerts) validate() []error {
+	var errs []error
+
+	if a.Retries < 0 {
+		errs = append(errs, fmt.Errorf("alerts.retries: %d must not be negative", a.Retries))
+	}
+
+	if a.CheckInterval <= 0 {
+		errs = append(errs, fmt.Errorf("alerts.check_interval: %s must be positive", a.CheckInterval))
+	}
+
+	if a.MaxRules <= 0 {
+		errs = append(errs, fmt.Errorf("alerts.max_rules: %d must be positive", a.MaxRules))
+	} else if len(a.Rules) > a.MaxRules {
+		errs = append(errs, fmt.Errorf("alerts.rules: %d rules exceed max_rules %d", len(a.Rules), a.MaxRules))
+	}
+
+	for i, host := range a.WebhookHosts {
+		if err := alert.CheckWebhookHost(host); err != nil {
+			errs = append(errs, fmt.Errorf("alerts.webhook_hosts[%d]: %w", i, err))
+		}
+	}
+
+	ids := make(map[string]bool, len(a.Rules))
+
+	for i := range a.Rules {
+		rule := &a.Rules[i]
+
+		if err := rule.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("alerts.rules[%d]: %w", i, err))
+		}
+
+		if ids[rule.ID] {
+			errs = append(errs, fmt.Errorf("alerts.rules[%d]: duplicate id %q", i, rule.ID))
+		}
+
+		ids[rule.ID] = true
+	}
+
+	return errs
+}
+
+func (s *Staleness) validate() []error {
+	var errs []error
+
+	if s.Threshold < 0 {
+		errs = append(errs, fmt.Errorf("staleness.threshold: %s must not be negative", s.Threshold))
+	}
+
+	if s.CheckInterval <= 0 {
+		errs = append(errs, fmt.Errorf("staleness.check_interval: %s must be positive", s.CheckInterval))
+	}
+
+	symbols := make([]string, 0, len(s.Thresholds))
+	for symbol := range s.Thresholds {
+		symbols = append(symbols, symbol)
+	}
+
+	sort.Strings(symbols)
+
+	for _, symbol := range symbols {
+		if symbol == "" {
+			errs = append(errs, errors.New("staleness.thresholds: symbol is required"))
+		}
+
+		if s.Thresholds[symbol] < 0 {
+			errs = append(errs, fmt.Errorf("staleness.thresholds.%s: %s must not be negative",
+				symbol, s.Thresholds[symbol]))
+		}
+	}
+
+	return errs
+}
+
+// validateSynthetics checks synthetic instruments, their symbols must be unique and differ
+// from subscribed symbols and legs.
+func validateSynthetics(synthetics []synthetic.Instrument, instruments []string) []error {
+	var errs []error
+
+	subscribed := make(map[string]bool, len(instruments))
+
+	for _, instrument := range instruments {
+		if symbol, _, ok := strings.Cut(instrument, "@"); ok && !exchange.IsPattern(instrument) {
+			subscribed[strings.ToUpper(symbol)] = true
+		}
+	}
+
+	symbols := make(map[string]bool, len(synthetics))
+	legs := make(map[string]bool)
+
+	for i := range synthetics {
+		s := &synthetics[i]
+
+		if err := s.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("synthetics[%d]: %w", i, err))
+		} else if expr, err := synthetic.Parse(s.Expression); err == nil {
+			for _, leg := range expr.Legs() {
+				legs[leg] = true
+			}
+		}
+
+		if symbols[s.Symbol] {
+			errs = append(errs, fmt.Errorf("synthetics[%d]: duplicate symbol %q", i, s.Symbol))
+		}
+
+		if subscribed[s.Symbol] {
+			errs = append(errs, fmt.Errorf("synthetics[%d]: symbol %q is subscribed in instruments", i, s.Symbol))
+		}
+
+		symbols[s.Symbol] = true
+	}
+
+	for i := range synthetics {
+		if legs[synthetics[i].Symbol] {
+			errs = append(errs, fmt.Errorf("synthetics[%d]: %q can not be a leg of another synthetic", i, synthetics[i].Symbol))
+		}
+	}
+
+	return errs
+}
+
+// validateInstrument checks that instrument looks like <symbol>@<stream> or is a valid pattern.
+func validateInstrument(instrument string) error {
+	if exchange.IsPattern(instrument) {
+		if _, err := exchange.ParsePattern(instrument); err != nil {
+			return fmt.Errorf("instruments: %w", errors.Unwrap(err))
+		}
+
+		return nil
+	}
+
+	symbol, stream, ok := strings.Cut(instrument, "@")
+	if !ok || symbol == "" || stream == "" || strings.ContainsAny(instrument, " \t") {
+		return fmt.Errorf("instruments: %q must look like <symbol>@<stream>", instrument)
+	}
+
+	return nil
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/exchange"
+	"github.com/ole-larsen/binance-subscriber/internal/server/config"
+)
+
+const exchangeInfoTimeout = 10 * time.Second
+
+// loadExchangeInfo refreshes exchangeInfo snapshot from the configured file or endpoint.
+// It returns nil snapshot when validation is disabled.
+func (s *Server) loadExchangeInfo(ctx context.Context, settings *config.Config) (*exchange.Info, error) {
+	switch {
+	case settings.Exchange.InfoFile != "":
+		return exchange.LoadFile(settings.Exchange.InfoFile)
+	case settings.Exchange.InfoURL != "":
+		ctx, cancel := context.WithTimeout(ctx, exchangeInfoTimeout)
+		defer cancel()
+
+		return exchange.Fetch(ctx, s.client, settings.Exchange.InfoURL)
+	default:
+		return nil, nil //nolint:nilnil // explanation: validation is disabled
+	}
+}
+
+// resolveInstruments expands instrument patterns and validates the result against
+// the current exchangeInfo snapshot.
+func (s *Server) resolveInstruments(ctx context.Context, settings *config.Config) ([]string, error) {
+	info := s.exchange.Load()
+	if info == nil {
+		if hasPatterns(settings.Instruments) {
+			return nil, NewError(errors.New("instrument patterns require exchangeInfo"))
+		}
+
+		return settings.Instruments, nil
+	}
+
+	var tickers []exchange.Ticker
+
+	if exchange.NeedsTickers(settings.Instruments) {
+		ctx, cancel := context.WithTimeout(ctx, exchangeInfoTimeout)
+		defer cancel()
+
+		var err error
+
+		if tickers, err = exchange.FetchTickers(ctx, s.client, settings.Exchange.TickerURL); err != nil {
+			return nil, err
+		}
+	}
+
+	streams, err := info.Expand(settings.Instruments, tickers)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = info.Validate(streams); err != nil {
+		return nil, err
+	}
+
+	return streams, nil
+}
+
+// sync refreshes exchangeInfo, resolves instruments and subscribes/unsubscribes
+// the delta against active poller subscriptions.
+func (s *Server) sync(ctx context.Context, settings *config.Config) (subscribe, unsubscribe []string, err error) {
+	info, err := s.loadExchangeInfo(ctx, settings)
+	if err != nil {
+		s.logger.Warnw("failed to refresh exchangeInfo, keep previous snapshot", "error", err)
+	} else {
+		s.exchange.Store(info)
+	}
+
+	streams, err := s.resolveInstruments(ctx, settings)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	subscribe, unsubscribe = diff(s.poller.Streams(), streams)
+
+	if len(unsubscribe) > 0 {
+		if err = s.poller.Unsubscribe(unsubscribe); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(subscribe) > 0 {
+		if err = s.poller.Subscribe(subscribe); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return subscribe, unsubscribe, nil
+}
+
+// Expand re-expands instrument patterns so newly listed symbols are picked up.
+func (s *Server) Expand(ctx context.Context) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.poller == nil {
+		return NewError(errors.New("ws client is missing"))
+	}
+
+	if !hasPatterns(s.settings.Instruments) {
+		return nil
+	}
+
+	subscribe, unsubscribe, err := s.sync(ctx, s.settings)
+	if err != nil {
+		return NewError(err)
+	}
+
+	if len(subscribe) > 0 || len(unsubscribe) > 0 {
+		s.logger.Infow("...instruments re-expanded",
+			"subscribed", subscribe,
+			"unsubscribed", unsubscribe,
+		)
+	}
+
+	return nil
+}
+
+// formatPrice trims price to the symbol tick size when exchangeInfo is known.
+func (s *Server) formatPrice(symbol, price string) string {
+	info := s.exchange.Load()
+	if info == nil {
+		return price
+	}
+
+	return info.FormatPrice(symbol, price)
+}
+
+// expandTicker returns channel ticking when instrument patterns have to be re-expanded,
+// nil channel blocks forever when re-expansion is disabled. Patterns may appear after
+// reload, so Expand decides whether there is anything to do.
+func (s *Server) expandTicker() (<-chan time.Time, func()) {
+	if s.settings.Exchange.ExpandInterval <= 0 {
+		return nil, func() {}
+	}
+
+	t := time.NewTicker(s.settings.Exchange.ExpandInterval)
+
+	return t.C, t.Stop
+}
+
+func hasPatterns(instruments []string) bool {
+	for _, instrument := range instruments {
+		if exchange.IsPattern(instrument) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetHTTPClient sets client used to call exchange REST endpoints.
+func (s *Server) SetHTTPClient(client *http.Client) *Server {
+	s.client = client
+	return s
+}
+
+// GetExchangeInfo retrieves current exchangeInfo snapshot, nil if validation is disabled.
+func (s *Server) GetExchangeInfo() *exchange.Info {
+	return s.exchange.Load()
+}
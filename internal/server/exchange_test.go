@@ -0,0 +1,87 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/server"
+	"github.com/ole-larsen/binance-subscriber/internal/server/config"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Expand(t *testing.T) {
+	url, requests := fakeUpstream(t)
+
+	infoFile := filepath.Join(t.TempDir(), "exchangeInfo.json")
+	writeInfo := func(symbols string) {
+		require.NoError(t, os.WriteFile(infoFile, []byte(`{"symbols":[`+symbols+`]}`), 0o600))
+	}
+
+	writeInfo(`{"symbol":"BTCUSDT","status":"TRADING","quoteAsset":"USDT"},
+		{"symbol":"ETHBTC","status":"TRADING","quoteAsset":"BTC"}`)
+
+	tickers := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		_, _ = rw.Write([]byte(`[{"symbol":"BTCUSDT","quoteVolume":"10"},{"symbol":"SOLUSDT","quoteVolume":"20"}]`))
+	}))
+	defer tickers.Close()
+
+	settings := &config.Config{
+		Host:        "localhost",
+		Port:        8080,
+		Instruments: []string{"*usdt@bookTicker", "quote=USDT,top=1@depth"},
+		Upstream:    config.Upstream{BaseURL: url},
+		Exchange:    config.Exchange{InfoFile: infoFile, TickerURL: tickers.URL},
+	}
+
+	srv := server.NewServer()
+	require.NoError(t, srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{})))
+
+	p := srv.GetBinancePoller()
+	require.NoError(t, p.Connect(context.Background()))
+
+	defer func() {
+		require.NoError(t, p.Close())
+	}()
+
+	require.NoError(t, srv.Expand(context.Background()))
+
+	req := nextRequest(t, requests)
+	assert.Equal(t, "SUBSCRIBE", req.Method)
+	assert.Equal(t, []string{"btcusdt@bookTicker", "btcusdt@depth"}, req.Params)
+
+	// newly listed pair is picked up and becomes the most traded one
+	writeInfo(`{"symbol":"BTCUSDT","status":"TRADING","quoteAsset":"USDT"},
+		{"symbol":"SOLUSDT","status":"TRADING","quoteAsset":"USDT"}`)
+
+	require.NoError(t, srv.Expand(context.Background()))
+
+	req = nextRequest(t, requests)
+	assert.Equal(t, "UNSUBSCRIBE", req.Method)
+	assert.Equal(t, []string{"btcusdt@depth"}, req.Params)
+
+	req = nextRequest(t, requests)
+	assert.Equal(t, "SUBSCRIBE", req.Method)
+	assert.Equal(t, []string{"solusdt@bookTicker", "solusdt@depth"}, req.Params)
+
+	assert.Equal(t, []string{"btcusdt@bookTicker", "solusdt@bookTicker", "solusdt@depth"}, p.Streams())
+}
+
+func TestServer_ExpandWithoutPatterns(t *testing.T) {
+	srv := server.NewServer()
+	assert.EqualError(t, srv.Expand(context.Background()), "[server]: ws client is missing")
+
+	settings := &config.Config{
+		Host:        "localhost",
+		Port:        8080,
+		Instruments: []string{"btcusdt@depth"},
+	}
+
+	require.NoError(t, srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{})))
+	require.NoError(t, srv.Expand(context.Background()), "nothing to expand, upstream is not touched")
+}
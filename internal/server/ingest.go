@@ -0,0 +1,111 @@
+package server
+
+import (
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/ingest"
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// apply measures delay of decoded message from exchange times, stores best bid/ask, trade or
+// depth and publishes normalized update to sinks.
+func (s *Server) apply(msg *ingest.Message) error {
+	update := msg.Update
+
+	s.latency.Observe(msg.Stream, update.Time, update.TransactionTime(), msg.Received)
+
+	var err error
+
+	switch update.Type {
+	case marketdata.TypeBBO:
+		if update.BBO.Bid != "" && update.BBO.Ask != "" {
+			err = s.store(update.Symbol, update.BBO.Bid, update.BBO.Ask)
+		}
+	case marketdata.TypeTrade:
+		err = s.tape.Add(update.Symbol, update.Trade)
+	case marketdata.TypeDepth:
+		if len(update.Depth.Asks) > 0 && len(update.Depth.Bids) > 0 {
+			err = s.store(update.Symbol, update.Depth.Bids[0].Price, update.Depth.Asks[0].Price)
+		}
+
+		if err == nil {
+			err = s.books.Apply(update.Symbol, update.Depth)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.sinks.Publish(update)
+}
+
+// store saves quote and synthetic quotes priced from it, evaluates alert rules and arbitrage
+// routes and mirrors them to quote sinks.
+func (s *Server) store(symbol, bid, ask string) error {
+	data := storage.Data{
+		Symbol: symbol,
+		Bid:    s.formatPrice(symbol, bid),
+		Ask:    s.formatPrice(symbol, ask),
+	}
+
+	if err := s.publish(&data); err != nil {
+		return err
+	}
+
+	for _, quote := range s.synthetics.Update(&data) {
+		if err := s.publish(&quote); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) publish(data *storage.Data) error {
+	s.hub.Set(*data)
+	s.alerts.Observe(data)
+	s.arbitrage.Observe(data)
+	s.staleness.Observe(data)
+
+	return s.quotes.PublishQuote(data)
+}
+
+// markStale marks quotes of symbols that stopped updating stale and mirrors them to streaming
+// clients and quote sinks, suppressed stale quotes are only stored. They are replaced by the
+// next update of their symbols.
+func (s *Server) markStale() error {
+	for _, symbol := range s.staleness.Check() {
+		data := s.storage.Get(symbol)
+		if data == nil {
+			continue
+		}
+
+		data.Stale = true
+
+		if s.settings.Staleness.Suppress {
+			s.storage.Set(*data)
+			continue
+		}
+
+		s.hub.Set(*data)
+
+		if err := s.quotes.PublishQuote(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stalenessTicker ticks every staleness check interval, it never ticks when no symbol is tracked.
+func (s *Server) stalenessTicker() (<-chan time.Time, func()) {
+	if !s.staleness.Enabled() {
+		return nil, func() {}
+	}
+
+	t := time.NewTicker(s.settings.Staleness.CheckInterval)
+
+	return t.C, t.Stop
+}
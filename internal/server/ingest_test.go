@@ -0,0 +1,381 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/websocket"
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/ole-larsen/binance-subscriber/internal/alert"
+	"github.com/ole-larsen/binance-subscriber/internal/arbitrage"
+	"github.com/ole-larsen/binance-subscriber/internal/server"
+	"github.com/ole-larsen/binance-subscriber/internal/server/config"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+	"github.com/ole-larsen/binance-subscriber/internal/synthetic"
+	"github.com/ole-larsen/binance-subscriber/internal/tape"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamingUpstream replies to the first subscription with the given messages.
+func streamingUpstream(t *testing.T, messages ...string) string {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(rw, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		if _, _, err = conn.ReadMessage(); err != nil {
+			return
+		}
+
+		for _, msg := range messages {
+			if err = conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+				return
+			}
+		}
+
+		for {
+			if _, _, err = conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	return "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+func TestServer_RunIngestsStreams(t *testing.T) {
+	infoFile := filepath.Join(t.TempDir(), "exchangeInfo.json")
+	require.NoError(t, os.WriteFile(infoFile, []byte(`{"symbols":[
+		{"symbol":"BTCUSDT","status":"TRADING","filters":[{"filterType":"PRICE_FILTER","tickSize":"0.01000000"}]},
+		{"symbol":"ETHUSDT","status":"TRADING","filters":[{"filterType":"PRICE_FILTER","tickSize":"0.01000000"}]}
+	]}`), 0o600))
+
+	url := streamingUpstream(t,
+		`{"result":null,"id":"abc"}`,
+		`{"stream":"btcusdt@bookTicker","data":{"u":1,"s":"BTCUSDT","b":"97000.01000000","B":"1.0","a":"97000.02000000","A":"2.0"}}`,
+		`{"stream":"ethusdt@depth","data":{"e":"depthUpdate","s":"ETHUSDT","b":[["3000.10000000","1.0"]],"a":[["3000.20000000","1.0"]]}}`,
+		`{"stream":"ethusdt@aggTrade","data":{"e":"aggTrade","s":"ETHUSDT","a":7,"p":"3000.00000000","q":"0.5","T":1700000000000}}`,
+	)
+
+	settings := &config.Config{
+		Host:        "localhost",
+		Port:        18080,
+		Instruments: []string{"btcusdt@bookTicker", "ethusdt@depth"},
+		Upstream:    config.Upstream{BaseURL: url},
+		Exchange:    config.Exchange{InfoFile: infoFile},
+	}
+
+	store := storage.NewMemStorage()
+
+	srv := server.NewServer()
+	require.NoError(t, srv.Init(store, settings, make(chan os.Signal, 1), make(chan struct{})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go srv.Run(ctx, cancel)
+
+	require.Eventually(t, func() bool {
+		return len(store.GetAll()) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, &storage.Data{Symbol: "BTCUSDT", Bid: "97000.01", Ask: "97000.02"}, store.Get("BTCUSDT"))
+	assert.Equal(t, &storage.Data{Symbol: "ETHUSDT", Bid: "3000.10", Ask: "3000.20"}, store.Get("ETHUSDT"))
+
+	// trades are kept on the tape
+	require.Eventually(t, func() bool {
+		_, ok := srv.GetTape().Trades("ETHUSDT", 0)
+		return ok
+	}, time.Second, 10*time.Millisecond)
+
+	trades, _ := srv.GetTape().Trades("ETHUSDT", 0)
+	assert.Equal(t, []tape.Trade{{Price: "3000.00000000", Quantity: "0.5", Side: tape.SideBuy, ID: 7, Time: 1700000000000}}, trades)
+
+	// delay is measured from exchange times messages carry
+	report := srv.GetLatency().Report()
+	require.Len(t, report.Streams, 1)
+	assert.Equal(t, "aggTrade", report.Streams[0].Stream)
+	assert.Nil(t, report.Streams[0].Event)
+	assert.Equal(t, 1, report.Streams[0].Transaction.Count)
+
+	// depth updates build order books
+	book, ok := srv.GetBooks().Snapshot("ETHUSDT", 0)
+	require.True(t, ok)
+	require.NotNil(t, book.Analytics)
+	assert.InDelta(t, 3000.15, book.Analytics.Mid, 1e-9)
+}
+
+func TestServer_RunPublishesToSinks(t *testing.T) {
+	ns, err := natsserver.NewServer(&natsserver.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	require.NoError(t, err)
+
+	go ns.Start()
+
+	require.True(t, ns.ReadyForConnections(5*time.Second))
+	defer ns.Shutdown()
+
+	conn, err := nats.Connect(ns.ClientURL())
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	sub, err := conn.SubscribeSync("md.>")
+	require.NoError(t, err)
+	require.NoError(t, conn.Flush())
+
+	mr := miniredis.RunT(t)
+
+	url := streamingUpstream(t,
+		`{"stream":"btcusdt@bookTicker","data":{"u":1,"s":"BTCUSDT","b":"97000.01","B":"1.0","a":"97000.02","A":"2.0"}}`,
+		`{"stream":"ethusdt@trade","data":{"e":"trade","E":2,"s":"ETHUSDT","t":3,"p":"3000.00","q":"1.5","T":1,"m":true}}`,
+	)
+
+	settings := &config.Config{
+		Host:        "localhost",
+		Port:        18081,
+		Instruments: []string{"btcusdt@bookTicker", "ethusdt@trade"},
+		Upstream:    config.Upstream{BaseURL: url},
+		Sinks: config.Sinks{
+			Buffer:        10,
+			BatchSize:     10,
+			FlushInterval: 10 * time.Millisecond,
+			NATS:          config.NATSSink{URL: ns.ClientURL(), SubjectPrefix: "md"},
+			Redis:         config.RedisSink{URL: "redis://" + mr.Addr(), Channel: "quotes"},
+		},
+	}
+
+	srv := server.NewServer()
+	require.NoError(t, srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go srv.Run(ctx, cancel)
+
+	msg, err := sub.NextMsg(5 * time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "md.bbo.BTCUSDT", msg.Subject)
+	assert.JSONEq(t, `{"type":"bbo","symbol":"BTCUSDT",`+
+		`"bbo":{"bid":"97000.01","bid_quantity":"1.0","ask":"97000.02","ask_quantity":"2.0","update_id":1}}`, string(msg.Data))
+
+	msg, err = sub.NextMsg(5 * time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "md.trade.ETHUSDT", msg.Subject)
+	assert.JSONEq(t, `{"type":"trade","symbol":"ETHUSDT","time":2,`+
+		`"trade":{"price":"3000.00","quantity":"1.5","id":3,"time":1,"buyer_maker":true}}`, string(msg.Data))
+
+	// stored quotes are mirrored to redis
+	require.Eventually(t, func() bool {
+		return mr.HGet("quote:BTCUSDT", "ask") == "97000.02"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "97000.01", mr.HGet("quote:BTCUSDT", "bid"))
+}
+
+func TestServer_RunFiresAlerts(t *testing.T) {
+	received := make(chan alert.Alert, 1)
+
+	hook := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var a alert.Alert
+		if assert.NoError(t, json.NewDecoder(r.Body).Decode(&a)) {
+			received <- a
+		}
+	}))
+	defer hook.Close()
+
+	url := streamingUpstream(t,
+		`{"stream":"btcusdt@bookTicker","data":{"u":1,"s":"BTCUSDT","b":"97000.01","B":"1.0","a":"97000.02","A":"2.0"}}`,
+	)
+
+	settings := &config.Config{
+		Host:        "localhost",
+		Port:        18082,
+		Instruments: []string{"btcusdt@bookTicker"},
+		Upstream:    config.Upstream{BaseURL: url},
+		Alerts: config.Alerts{
+			Rules: []alert.Rule{{ID: "btc", Symbol: "BTCUSDT", Condition: "bid > 90000", Webhook: hook.URL}},
+		},
+	}
+
+	srv := server.NewServer()
+	require.NoError(t, srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{})))
+	require.Len(t, srv.GetAlerts().Rules(), 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go srv.Run(ctx, cancel)
+
+	select {
+	case a := <-received:
+		assert.Equal(t, "btc", a.Rule)
+		assert.Equal(t, alert.StatusFiring, a.Status)
+		assert.InDelta(t, 97000.01, a.Value, 1e-9)
+	case <-time.After(5 * time.Second):
+		t.Fatal("alert is not delivered")
+	}
+}
+
+func TestServer_RunPricesSynthetics(t *testing.T) {
+	url := streamingUpstream(t,
+		`{"stream":"btcusdt@bookTicker","data":{"u":1,"s":"BTCUSDT","b":"100000","B":"1.0","a":"100010","A":"2.0"}}`,
+		`{"stream":"ethusdt@bookTicker","data":{"u":2,"s":"ETHUSDT","b":"3000","B":"1.0","a":"3001","A":"2.0"}}`,
+	)
+
+	settings := &config.Config{
+		Host:        "localhost",
+		Port:        18083,
+		Instruments: []string{"btcusdt@bookTicker", "ethusdt@bookTicker"},
+		Synthetics:  []synthetic.Instrument{{Symbol: "ETHBTC_SYN", Expression: "ETHUSDT / BTCUSDT", Precision: 6}},
+		Upstream:    config.Upstream{BaseURL: url},
+	}
+
+	store := storage.NewMemStorage()
+
+	srv := server.NewServer()
+	require.NoError(t, srv.Init(store, settings, make(chan os.Signal, 1), make(chan struct{})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go srv.Run(ctx, cancel)
+
+	require.Eventually(t, func() bool {
+		return store.Get("ETHBTC_SYN") != nil
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, &storage.Data{Symbol: "ETHBTC_SYN", Bid: "0.029997", Ask: "0.030010", Synthetic: true}, store.Get("ETHBTC_SYN"))
+	assert.Len(t, store.GetAll(), 3)
+}
+
+func TestServer_RunMonitorsArbitrage(t *testing.T) {
+	url := streamingUpstream(t,
+		`{"stream":"btcusdt@bookTicker","data":{"u":1,"s":"BTCUSDT","b":"100000","B":"1.0","a":"100010","A":"2.0"}}`,
+		`{"stream":"btcusdc@bookTicker","data":{"u":2,"s":"BTCUSDC","b":"100500","B":"1.0","a":"100510","A":"2.0"}}`,
+	)
+
+	settings := &config.Config{
+		Host:        "localhost",
+		Port:        18084,
+		Instruments: []string{"btcusdt@bookTicker", "btcusdc@bookTicker"},
+		Upstream:    config.Upstream{BaseURL: url},
+		Arbitrage: config.Arbitrage{
+			FeeBPS:       10,
+			ThresholdBPS: 5,
+			Pairs:        []arbitrage.Pair{{ID: "btc", Symbols: []string{"BTCUSDT", "BTCUSDC"}}},
+		},
+	}
+
+	srv := server.NewServer()
+	require.NoError(t, srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go srv.Run(ctx, cancel)
+
+	require.Eventually(t, func() bool {
+		return len(srv.GetArbitrage().Opportunities()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// buy BTCUSDT at 100010, sell BTCUSDC at 100500 after two 10 bps fees
+	o := srv.GetArbitrage().Opportunities()[0]
+	assert.Equal(t, arbitrage.DirectionForward, o.Direction)
+	assert.InDelta(t, 28.9, o.EdgeBPS, 0.1)
+}
+
+func TestServer_RunMarksStaleQuotes(t *testing.T) {
+	url := streamingUpstream(t,
+		`{"stream":"btcusdt@bookTicker","data":{"u":1,"s":"BTCUSDT","b":"100000","B":"1.0","a":"100010","A":"2.0"}}`,
+	)
+
+	settings := &config.Config{
+		Host:        "localhost",
+		Port:        18085,
+		Instruments: []string{"btcusdt@bookTicker"},
+		Upstream:    config.Upstream{BaseURL: url},
+		Staleness: config.Staleness{
+			Threshold:     50 * time.Millisecond,
+			CheckInterval: 10 * time.Millisecond,
+			Suppress:      true,
+		},
+	}
+
+	srv := server.NewServer()
+	require.NoError(t, srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub, _, _ := srv.GetHub().Subscribe(0)
+	defer srv.GetHub().Unsubscribe(sub)
+
+	go srv.Run(ctx, cancel)
+
+	require.Eventually(t, func() bool {
+		data := srv.GetStorage().Get("BTCUSDT")
+		return data != nil && data.Stale
+	}, time.Second, 10*time.Millisecond)
+
+	// stale quote is kept in storage and suppressed from clients
+	assert.Equal(t, "100000", srv.GetStorage().Get("BTCUSDT").Bid)
+	assert.Nil(t, srv.GetHub().Get("BTCUSDT"))
+	assert.Empty(t, srv.GetHub().GetAll())
+
+	// streaming clients get the fresh quote only
+	require.Len(t, sub.Updates(), 1)
+	assert.False(t, (<-sub.Updates()).Data.Stale)
+
+	freshness := srv.GetStaleness().Freshness()
+	require.Len(t, freshness, 1)
+	assert.True(t, freshness[0].Stale)
+}
+
+func TestServer_RunSkipsMalformedMessages(t *testing.T) {
+	url := streamingUpstream(t,
+		`not json`,
+		`{"stream":"btcusdt@bookTicker","data":"quote"}`,
+		`{"stream":"btcusdt@bookTicker","data":{"u":1,"s":"BTCUSDT","b":"100000","B":"1.0","a":"100010","A":"2.0"}}`,
+	)
+
+	settings := &config.Config{
+		Host:        "localhost",
+		Port:        18086,
+		Instruments: []string{"btcusdt@bookTicker"},
+		Upstream:    config.Upstream{BaseURL: url},
+		Ingest:      config.Ingest{Workers: 2, Queue: 16, BatchSize: 8, Overflow: "block"},
+	}
+
+	store := storage.NewMemStorage()
+
+	srv := server.NewServer()
+	require.NoError(t, srv.Init(store, settings, make(chan os.Signal, 1), make(chan struct{})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go srv.Run(ctx, cancel)
+
+	// malformed messages do not stop ingestion
+	require.Eventually(t, func() bool {
+		return store.Get("BTCUSDT") != nil
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, &storage.Data{Symbol: "BTCUSDT", Bid: "100000", Ask: "100010"}, store.Get("BTCUSDT"))
+}
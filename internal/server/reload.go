@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/ole-larsen/binance-subscriber/internal/arbitrage"
+	"github.com/ole-larsen/binance-subscriber/internal/log"
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+	"github.com/ole-larsen/binance-subscriber/internal/server/config"
+)
+
+// ReloadFunc loads fresh configuration, config.Load by default.
+type ReloadFunc func() (*config.Config, error)
+
+// Reload re-reads configuration and auth keys, applies the new log level and then
+// subscribes/unsubscribes the instruments delta on the live poller, so a failed reload
+// leaves subscriptions as they were. Downstream clients are kept.
+func (s *Server) Reload() (err error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	defer func() {
+		result := "ok"
+		if err != nil {
+			result = "error"
+		}
+
+		metrics.Reloads.WithLabelValues(result).Inc()
+	}()
+
+	if s.reload == nil {
+		return NewError(errors.New("reload is not configured"))
+	}
+
+	next, err := s.reload()
+	if err != nil {
+		return NewError(err)
+	}
+
+	if s.poller == nil {
+		return NewError(errors.New("ws client is missing"))
+	}
+
+	if err = s.auth.Reload(); err != nil {
+		return NewError(err)
+	}
+
+	if next.Log.Level != "" && next.Log.Level != s.settings.Log.Level {
+		if err = log.SetLevel(next.Log.Level); err != nil {
+			return NewError(err)
+		}
+	}
+
+	s.settings.Log = next.Log
+
+	subscribe, unsubscribe, err := s.sync(context.Background(), next)
+	if err != nil {
+		return NewError(err)
+	}
+
+	s.settings.Instruments = next.Instruments
+	s.settings.Exchange = next.Exchange
+
+	if next.Host != s.settings.Host || next.Port != s.settings.Port || next.Admin != s.settings.Admin ||
+		next.GRPC != s.settings.GRPC || next.Upstream != s.settings.Upstream {
+		s.logger.Warnw("address, admin and grpc addresses and upstream changes require restart")
+	}
+
+	if next.TLS != s.settings.TLS {
+		s.logger.Warnw("tls settings changes require restart, certificate files are re-read")
+	}
+
+	if next.Limits != s.settings.Limits || next.WebSocket != s.settings.WebSocket || next.Stream != s.settings.Stream {
+		s.logger.Warnw("limits, websocket and stream changes require restart")
+	}
+
+	if !equalSinks(&next.Sinks, &s.settings.Sinks) {
+		s.logger.Warnw("sinks changes require restart")
+	}
+
+	if next.Trades.TapeSize != s.settings.Trades.TapeSize || !slices.Equal(next.Trades.Windows, s.settings.Trades.Windows) {
+		s.logger.Warnw("trades changes require restart")
+	}
+
+	if !equalBook(&next.Book, &s.settings.Book) {
+		s.logger.Warnw("book changes require restart")
+	}
+
+	if !slices.Equal(next.Synthetics, s.settings.Synthetics) {
+		s.logger.Warnw("synthetics changes require restart")
+	}
+
+	if !equalArbitrage(&next.Arbitrage, &s.settings.Arbitrage) {
+		s.logger.Warnw("arbitrage changes require restart")
+	}
+
+	if !equalAlerts(&next.Alerts, &s.settings.Alerts) {
+		s.logger.Warnw("alerts changes require restart, rules are managed by /api/v1/alerts at runtime")
+	}
+
+	if !equalStaleness(&next.Staleness, &s.settings.Staleness) {
+		s.logger.Warnw("staleness changes require restart")
+	}
+
+	if next.Latency != s.settings.Latency {
+		s.logger.Warnw("latency changes require restart")
+	}
+
+	if next.Ingest != s.settings.Ingest {
+		s.logger.Warnw("ingest changes require restart")
+	}
+
+	if !equalAuth(&next.Auth, &s.settings.Auth) {
+		s.logger.Warnw("auth settings changes require restart, keys files are re-read")
+	}
+
+	s.logger.Infow("...config reloaded",
+		"subscribed", subscribe,
+		"unsubscribed", unsubscribe,
+		"log_level", next.Log.Level,
+	)
+
+	return nil
+}
+
+// SetReloader sets function used to load configuration on reload.
+func (s *Server) SetReloader(fn ReloadFunc) *Server {
+	s.reload = fn
+	return s
+}
+
+// diff returns streams to subscribe and to unsubscribe to move from active to next.
+func diff(active, next []string) (subscribe, unsubscribe []string) {
+	current := make(map[string]struct{}, len(active))
+	for _, stream := range active {
+		current[stream] = struct{}{}
+	}
+
+	wanted := make(map[string]struct{}, len(next))
+
+	for _, stream := range next {
+		if _, ok := wanted[stream]; ok {
+			continue
+		}
+
+		wanted[stream] = struct{}{}
+
+		if _, ok := current[stream]; !ok {
+			subscribe = append(subscribe, stream)
+		}
+	}
+
+	for _, stream := range active {
+		if _, ok := wanted[stream]; !ok {
+			unsubscribe = append(unsubscribe, stream)
+		}
+	}
+
+	return subscribe, unsubscribe
+}
+
+func equalSinks(a, b *config.Sinks) bool {
+	return a.Overflow == b.Overflow && a.NATS == b.NATS && a.Redis == b.Redis && a.Buffer == b.Buffer && a.BatchSize == b.BatchSize &&
+		a.FlushInterval == b.FlushInterval && a.Kafka.TopicPrefix == b.Kafka.TopicPrefix &&
+		slices.Equal(a.Kafka.Brokers, b.Kafka.Brokers)
+}
+
+func equalBook(a, b *config.Book) bool {
+	return a.SnapshotURL == b.SnapshotURL && a.MaxLevels == b.MaxLevels && a.ImbalanceLevels == b.ImbalanceLevels &&
+		slices.Equal(a.DepthBPS, b.DepthBPS) && slices.Equal(a.Notionals, b.Notionals)
+}
+
+func equalArbitrage(a, b *config.Arbitrage) bool {
+	return a.FeeBPS == b.FeeBPS && a.ThresholdBPS == b.ThresholdBPS &&
+		slices.EqualFunc(a.Triangles, b.Triangles, func(x, y arbitrage.Triangle) bool {
+			return x.ID == y.ID && slices.Equal(x.Legs, y.Legs)
+		}) &&
+		slices.EqualFunc(a.Pairs, b.Pairs, func(x, y arbitrage.Pair) bool {
+			return x.ID == y.ID && slices.Equal(x.Symbols, y.Symbols)
+		})
+}
+
+func equalStaleness(a, b *config.Staleness) bool {
+	return a.Threshold == b.Threshold && a.CheckInterval == b.CheckInterval && a.Suppress == b.Suppress &&
+		maps.Equal(a.Thresholds, b.Thresholds)
+}
+
+func equalAlerts(a, b *config.Alerts) bool {
+	return a.WebhookSecret == b.WebhookSecret && a.Retries == b.Retries && a.CheckInterval == b.CheckInterval &&
+		a.MaxRules == b.MaxRules && slices.Equal(a.WebhookHosts, b.WebhookHosts) && slices.Equal(a.Rules, b.Rules)
+}
+
+func equalAuth(a, b *config.Auth) bool {
+	return a.KeysFile == b.KeysFile && a.JWTSecret == b.JWTSecret && a.JWKSFile == b.JWKSFile &&
+		a.Issuer == b.Issuer && a.Audience == b.Audience && slices.Equal(a.AllowedOrigins, b.AllowedOrigins)
+}
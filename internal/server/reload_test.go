@@ -0,0 +1,242 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ole-larsen/binance-subscriber/internal/poller"
+	"github.com/ole-larsen/binance-subscriber/internal/server"
+	"github.com/ole-larsen/binance-subscriber/internal/server/config"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUpstream accepts websocket connections and records subscription requests.
+func fakeUpstream(t *testing.T) (url string, requests chan poller.BinanceRequest) {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	requests = make(chan poller.BinanceRequest, 10)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(rw, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			var req poller.BinanceRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+
+			requests <- req
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	return "ws" + strings.TrimPrefix(ts.URL, "http"), requests
+}
+
+func nextRequest(t *testing.T, requests chan poller.BinanceRequest) poller.BinanceRequest {
+	t.Helper()
+
+	select {
+	case req := <-requests:
+		return req
+	case <-time.After(time.Second):
+		t.Fatal("no request received")
+	}
+
+	return poller.BinanceRequest{}
+}
+
+func TestServer_Reload(t *testing.T) {
+	url, requests := fakeUpstream(t)
+
+	settings := &config.Config{
+		Host:        "localhost",
+		Port:        8080,
+		Instruments: []string{"btcusdt@depth", "ethusdt@depth"},
+		Upstream:    config.Upstream{BaseURL: url},
+		Log:         config.Log{Level: "info"},
+	}
+
+	srv := server.NewServer()
+	require.NoError(t, srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{})))
+
+	p := srv.GetBinancePoller()
+	require.NoError(t, p.Connect(context.Background()))
+
+	defer func() {
+		require.NoError(t, p.Close())
+	}()
+
+	require.NoError(t, p.Subscribe(settings.Instruments))
+	nextRequest(t, requests)
+
+	next := &config.Config{
+		Host:        "localhost",
+		Port:        8080,
+		Instruments: []string{"ethusdt@depth", "solusdt@depth"},
+		Upstream:    config.Upstream{BaseURL: url},
+		Log:         config.Log{Level: "debug"},
+	}
+
+	srv.SetReloader(func() (*config.Config, error) {
+		return next, nil
+	})
+
+	require.NoError(t, srv.Reload())
+
+	req := nextRequest(t, requests)
+	assert.Equal(t, "UNSUBSCRIBE", req.Method)
+	assert.Equal(t, []string{"btcusdt@depth"}, req.Params)
+
+	req = nextRequest(t, requests)
+	assert.Equal(t, "SUBSCRIBE", req.Method)
+	assert.Equal(t, []string{"solusdt@depth"}, req.Params)
+
+	assert.Equal(t, []string{"ethusdt@depth", "solusdt@depth"}, p.Streams())
+	assert.Equal(t, next.Instruments, srv.GetSettings().Instruments)
+	assert.True(t, srv.GetLogger().Desugar().Core().Enabled(-1))
+
+	// nothing changed, nothing is sent
+	require.NoError(t, srv.Reload())
+
+	select {
+	case req = <-requests:
+		t.Fatalf("unexpected request %v", req)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, srv.SetReloader(func() (*config.Config, error) {
+		return &config.Config{Log: config.Log{Level: "info"}}, nil
+	}).Reload())
+}
+
+func TestServer_ReloadError(t *testing.T) {
+	srv := server.NewServer()
+
+	srv.SetReloader(nil)
+	assert.EqualError(t, srv.Reload(), "[server]: reload is not configured")
+
+	srv.SetReloader(func() (*config.Config, error) {
+		return nil, errors.New("bad config")
+	})
+	assert.EqualError(t, srv.Reload(), "[server]: bad config")
+
+	srv.SetReloader(func() (*config.Config, error) {
+		return &config.Config{}, nil
+	})
+	assert.EqualError(t, srv.Reload(), "[server]: ws client is missing")
+}
+
+func TestServer_ReloadValidatesInstruments(t *testing.T) {
+	url, requests := fakeUpstream(t)
+
+	infoFile := filepath.Join(t.TempDir(), "exchangeInfo.json")
+	require.NoError(t, os.WriteFile(infoFile, []byte(`{"symbols":[
+		{"symbol":"BTCUSDT","status":"TRADING","filters":[{"filterType":"PRICE_FILTER","tickSize":"0.01000000"}]},
+		{"symbol":"LUNAUSDT","status":"BREAK"}
+	]}`), 0o600))
+
+	settings := &config.Config{
+		Host:        "localhost",
+		Port:        8080,
+		Instruments: []string{"btcusdt@depth"},
+		Upstream:    config.Upstream{BaseURL: url},
+		Exchange:    config.Exchange{InfoFile: infoFile},
+	}
+
+	srv := server.NewServer()
+	require.NoError(t, srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{})))
+	assert.Nil(t, srv.GetExchangeInfo())
+
+	p := srv.GetBinancePoller()
+	require.NoError(t, p.Connect(context.Background()))
+
+	defer func() {
+		require.NoError(t, p.Close())
+	}()
+
+	srv.SetReloader(func() (*config.Config, error) {
+		return &config.Config{
+			Instruments: []string{"btcusdt@depth", "btcusd@depth", "lunausdt@depth"},
+			Exchange:    config.Exchange{InfoFile: infoFile},
+		}, nil
+	})
+
+	err := srv.Reload()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown symbol "BTCUSD"`)
+	assert.Contains(t, err.Error(), "LUNAUSDT is not trading")
+	assert.NotNil(t, srv.GetExchangeInfo())
+
+	select {
+	case req := <-requests:
+		t.Fatalf("unexpected request %v", req)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.Empty(t, p.Streams())
+}
+
+func TestServer_ReloadAuthError(t *testing.T) {
+	url, requests := fakeUpstream(t)
+
+	keys := filepath.Join(t.TempDir(), "keys.yaml")
+	require.NoError(t, os.WriteFile(keys, []byte("keys:\n  - id: dashboard\n    key: plain-key\n    scopes: [quotes:read]\n"), 0o600))
+
+	settings := &config.Config{
+		Host:        "localhost",
+		Port:        8080,
+		Instruments: []string{"btcusdt@depth"},
+		Upstream:    config.Upstream{BaseURL: url},
+		Auth:        config.Auth{KeysFile: keys},
+	}
+
+	srv := server.NewServer()
+	require.NoError(t, srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{})))
+
+	p := srv.GetBinancePoller()
+	require.NoError(t, p.Connect(context.Background()))
+
+	defer func() {
+		require.NoError(t, p.Close())
+	}()
+
+	require.NoError(t, p.Subscribe(settings.Instruments))
+	nextRequest(t, requests)
+
+	require.NoError(t, os.WriteFile(keys, []byte("keys:\n  - id: broken\n    scopes: [everything]\n"), 0o600))
+
+	srv.SetReloader(func() (*config.Config, error) {
+		return &config.Config{
+			Instruments: []string{"ethusdt@depth"},
+			Upstream:    config.Upstream{BaseURL: url},
+			Auth:        config.Auth{KeysFile: keys},
+		}, nil
+	})
+	require.Error(t, srv.Reload())
+
+	// subscriptions and settings are left as they were
+	select {
+	case req := <-requests:
+		t.Fatalf("unexpected request %v", req)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.Equal(t, []string{"btcusdt@depth"}, p.Streams())
+	assert.Equal(t, []string{"btcusdt@depth"}, srv.GetSettings().Instruments)
+}
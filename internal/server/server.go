@@ -2,20 +2,40 @@ package server
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/tls"
 	"errors"
-	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
+	"github.com/ole-larsen/binance-subscriber/internal/alert"
+	"github.com/ole-larsen/binance-subscriber/internal/arbitrage"
+	"github.com/ole-larsen/binance-subscriber/internal/auth"
+	"github.com/ole-larsen/binance-subscriber/internal/exchange"
+	"github.com/ole-larsen/binance-subscriber/internal/grpcserver"
 	"github.com/ole-larsen/binance-subscriber/internal/httpserver"
 	"github.com/ole-larsen/binance-subscriber/internal/httpserver/router"
+	"github.com/ole-larsen/binance-subscriber/internal/hub"
+	"github.com/ole-larsen/binance-subscriber/internal/ingest"
+	"github.com/ole-larsen/binance-subscriber/internal/latency"
 	"github.com/ole-larsen/binance-subscriber/internal/log"
+	"github.com/ole-larsen/binance-subscriber/internal/orderbook"
 	"github.com/ole-larsen/binance-subscriber/internal/poller"
+	"github.com/ole-larsen/binance-subscriber/internal/ratelimit"
 	"github.com/ole-larsen/binance-subscriber/internal/server/config"
+	"github.com/ole-larsen/binance-subscriber/internal/sink"
+	"github.com/ole-larsen/binance-subscriber/internal/staleness"
 	"github.com/ole-larsen/binance-subscriber/internal/storage"
+	"github.com/ole-larsen/binance-subscriber/internal/synthetic"
+	"github.com/ole-larsen/binance-subscriber/internal/tape"
 )
 
 var (
@@ -25,19 +45,39 @@ var (
 // Server represents the server instance, encapsulating settings,
 // logger, signal handling, and storage and gRPC server components.
 type Server struct {
-	http     *httpserver.HTTPServer
-	poller   *poller.BinancePoller
-	storage  storage.Storage
-	settings *config.Config
-	logger   *log.Logger
-	signal   chan os.Signal
-	done     chan struct{}
+	http       *httpserver.HTTPServer
+	admin      *httpserver.HTTPServer
+	grpc       *grpcserver.Server
+	poller     *poller.BinancePoller
+	client     *http.Client
+	auth       *auth.Authenticator
+	exchange   atomic.Pointer[exchange.Info]
+	storage    storage.Storage
+	hub        *hub.Hub
+	tape       *tape.Tape
+	books      *orderbook.Books
+	synthetics *synthetic.Engine
+	arbitrage  *arbitrage.Monitor
+	staleness  *staleness.Tracker
+	latency    *latency.Tracker
+	ingest     *ingest.Pipeline
+	sinks      *sink.Publisher
+	quotes     *sink.Publisher
+	alerts     *alert.Engine
+	notifier   *alert.Dispatcher
+	settings   *config.Config
+	logger     *log.Logger
+	signal     chan os.Signal
+	done       chan struct{}
+	reload     ReloadFunc
+	mx         sync.Mutex
 }
 
 // NewServer creates and returns a new Server instance with default logger settings.
 func NewServer() *Server {
 	return &Server{
 		logger: logger,
+		reload: config.Load,
 	}
 }
 
@@ -48,7 +88,7 @@ var SetupFunc = Setup
 func Setup(settings *config.Config) (*Server, error) {
 	s := NewServer()
 
-	if err := s.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{})); err != nil {
+	if err := s.Init(newStorage(settings), settings, make(chan os.Signal, 1), make(chan struct{})); err != nil {
 		return nil, err
 	}
 
@@ -73,10 +113,35 @@ func (s *Server) Run(ctx context.Context, cancel context.CancelFunc) {
 		s.logger.Infow("...graceful server shutdown")
 	}(s.signal, s.done)
 
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	defer signal.Stop(hup)
+
 	port := s.settings.Port
 	host := s.settings.Host
 
-	if err := s.poller.Connect(ctx); err != nil {
+	info, err := s.loadExchangeInfo(ctx, s.settings)
+	if err != nil {
+		s.logger.Errorln(err)
+		return
+	}
+
+	s.exchange.Store(info)
+
+	streams, err := s.resolveInstruments(ctx, s.settings)
+	if err != nil {
+		s.logger.Errorln(err)
+		return
+	}
+
+	if missing := s.missingLegs(streams); len(missing) > 0 {
+		s.logger.Warnw("synthetic legs are not subscribed, instruments priced from them are not published",
+			"legs", missing,
+		)
+	}
+
+	if err = s.poller.Connect(ctx); err != nil {
 		s.logger.Errorln(err)
 		return
 	}
@@ -88,7 +153,7 @@ func (s *Server) Run(ctx context.Context, cancel context.CancelFunc) {
 		}
 	}()
 
-	if err := s.poller.Subscribe(s.settings.Instruments); err != nil {
+	if err = s.poller.Subscribe(streams); err != nil {
 		s.logger.Errorln(err)
 		return
 	}
@@ -103,6 +168,7 @@ func (s *Server) Run(ctx context.Context, cancel context.CancelFunc) {
 	s.logger.Infow("...starting server",
 		"host", host,
 		"port", port,
+		"tls", s.http.TLS != nil,
 		"goroutines", runtime.NumGoroutine(),
 	)
 
@@ -112,28 +178,90 @@ func (s *Server) Run(ctx context.Context, cancel context.CancelFunc) {
 		}
 	}()
 
+	if s.admin != nil {
+		s.logger.Infow("...starting admin server",
+			"host", s.admin.GetHost(),
+			"port", s.admin.GetPort(),
+		)
+
+		go func() {
+			if err := s.admin.ListenAndServe(); err != nil {
+				s.logger.Errorln(err)
+			}
+		}()
+	}
+
+	if s.grpc != nil {
+		s.logger.Infow("...starting grpc server",
+			"address", s.grpc.GetAddress(),
+			"tls", s.grpc.TLS != nil,
+		)
+
+		go func() {
+			if err := s.grpc.ListenAndServe(); err != nil {
+				s.logger.Errorln(err)
+			}
+		}()
+
+		defer s.grpc.Stop()
+	}
+
+	defer s.runSinks()()
+
+	go s.books.Run(ctx)
+	go s.notifier.Run(ctx)
+	go s.alerts.Run(ctx, s.settings.Alerts.CheckInterval)
+
+	go s.auth.Watch(ctx, s.settings.Auth.ReloadInterval, func(err error) {
+		s.logger.Errorln(err)
+	})
+
+	go s.http.TLS.Watch(ctx, s.settings.TLS.ReloadInterval, func(err error) {
+		s.logger.Errorln(err)
+	})
+
+	expand, stopExpand := s.expandTicker()
+	defer stopExpand()
+
+	check, stopCheck := s.stalenessTicker()
+	defer stopCheck()
+
+	messages := s.ingest.Run(ctx, s.poller.GetMsg())
+
 	for {
 		select {
-		case message, ok := <-s.poller.GetMsg():
-			if ok {
-				var resp poller.DepthMessage
-
-				err := json.Unmarshal(message, &resp)
-				if err != nil {
-					fmt.Println("Error unmarshalling JSON:", err)
-					return
-				}
+		case batch, ok := <-messages:
+			if !ok {
+				s.logger.Warnw("upstream connection is closed")
+
+				messages = nil
 
-				if len(resp.Data.Asks) > 0 && len(resp.Data.Bids) > 0 {
-					s.storage.Set(storage.Data{
-						Symbol: resp.Data.Symbol,
-						Bid:    resp.Data.Bids[0][0],
-						Ask:    resp.Data.Asks[0][0],
-					})
+				continue
+			}
+
+			for i := range batch {
+				if err := s.apply(&batch[i]); err != nil {
+					s.ingest.Malformed(ingest.StageApply, err)
+				}
+			}
+		case <-expand:
+			go func() {
+				if err := s.Expand(ctx); err != nil {
+					s.logger.Errorln(err)
 				}
+			}()
+		case <-check:
+			if err := s.markStale(); err != nil {
+				s.logger.Errorln(err)
 			}
+		case <-hup:
+			go func() {
+				if err := s.Reload(); err != nil {
+					s.logger.Errorln(err)
+				}
+			}()
 		case <-s.done:
-			if err := s.poller.Unsubscribe(s.settings.Instruments); err != nil {
+			if err := s.poller.Unsubscribe(s.poller.Streams()); err != nil {
 				s.logger.Errorln(err)
 				return
 			}
@@ -171,6 +299,12 @@ func (s *Server) Init(
 		return NewError(errors.New("storage is missing"))
 	}
 
+	if s.settings.Log.Level != "" {
+		if err := log.SetLevel(s.settings.Log.Level); err != nil {
+			return NewError(err)
+		}
+	}
+
 	if s.signal == nil {
 		return NewError(errors.New("signal is missing"))
 	}
@@ -179,18 +313,167 @@ func (s *Server) Init(
 		return NewError(errors.New("done is missing"))
 	}
 
+	authenticator, err := auth.New(auth.Options{
+		KeysFile:  s.settings.Auth.KeysFile,
+		JWTSecret: s.settings.Auth.JWTSecret,
+		JWKSFile:  s.settings.Auth.JWKSFile,
+		Issuer:    s.settings.Auth.Issuer,
+		Audience:  s.settings.Auth.Audience,
+	})
+	if err != nil {
+		return NewError(err)
+	}
+
+	s.SetAuthenticator(authenticator)
+
+	limits := s.settings.Limits
+	// websocket, event stream and gRPC stream connections share the quota
+	quota := ratelimit.NewQuota(limits.MaxConnections, limits.MaxConnectionsPerClient)
+	// REST requests and unary gRPC calls share the token buckets
+	limiter := ratelimit.NewLimiter(limits.RequestsPerSecond, limits.RequestsBurst)
+
+	s.staleness = staleness.New().
+		SetThreshold(s.settings.Staleness.Threshold).
+		SetThresholds(s.settings.Staleness.Thresholds).
+		SetLogger(s.logger)
+
+	if s.settings.Staleness.Suppress {
+		store = staleness.Suppress(store)
+	}
+
+	s.latency = latency.New().SetWindow(s.settings.Latency.Window).SetSamples(s.settings.Latency.Samples)
+	s.ingest = ingest.New().
+		SetWorkers(s.settings.Ingest.Workers).
+		SetQueue(s.settings.Ingest.Queue).
+		SetBatchSize(s.settings.Ingest.BatchSize).
+		SetBlock(s.settings.Ingest.Overflow != "drop").
+		SetLogger(s.logger)
+
+	s.hub = hub.New(store).SetHistory(s.settings.Stream.Replay)
+	s.tape = tape.New().SetSize(s.settings.Trades.TapeSize).SetWindows(s.settings.Trades.Windows)
+	s.books = orderbook.New().
+		SetMaxLevels(s.settings.Book.MaxLevels).
+		SetImbalanceLevels(s.settings.Book.ImbalanceLevels).
+		SetBands(s.settings.Book.DepthBPS).
+		SetNotionals(s.settings.Book.Notionals).
+		SetLogger(s.logger)
+
+	s.synthetics = synthetic.New()
+	if err = s.synthetics.Set(s.settings.Synthetics); err != nil {
+		return NewError(err)
+	}
+
+	s.arbitrage = arbitrage.New().
+		SetFee(s.settings.Arbitrage.FeeBPS).
+		SetThreshold(s.settings.Arbitrage.ThresholdBPS).
+		SetLogger(s.logger)
+	if err = s.arbitrage.Set(s.settings.Arbitrage.Triangles, s.settings.Arbitrage.Pairs); err != nil {
+		return NewError(err)
+	}
+
+	alerts, notifier, err := newAlerts(&s.settings.Alerts, s.logger)
+	if err != nil {
+		return NewError(err)
+	}
+
+	s.SetAlerts(alerts, notifier)
+
 	r := router.NewMux().
 		SetStorage(store).
+		SetHub(s.hub).
+		SetTape(s.tape).
+		SetBooks(s.books).
+		SetArbitrage(s.arbitrage).
+		SetStaleness(s.staleness).
+		SetAlerts(s.alerts).
+		SetReloader(s.Reload).
+		SetAuthenticator(s.auth).
+		SetAllowedOrigins(s.settings.Auth.AllowedOrigins).
+		SetRateLimiter(limiter).
+		SetIPRateLimiter(ratelimit.NewLimiter(limits.IPRequestsPerSecond, limits.IPRequestsBurst)).
+		SetConnectionQuota(quota).
+		SetMessageRate(limits.MessagesPerSecond, limits.MessagesBurst).
+		SetCompression(s.settings.WebSocket.Compression).
+		SetStreamHeartbeat(s.settings.Stream.Heartbeat).
 		SetMiddlewares().
 		SetHandlers()
 
+	certs, err := newCertificates(&s.settings.TLS)
+	if err != nil {
+		return NewError(err)
+	}
+
 	s.
 		SetHTTPServer(httpserver.NewHTTPServer().
 			SetHost(s.settings.Host).
 			SetPort(s.settings.Port).
-			SetRouter(r))
+			SetRouter(r).
+			SetTLS(certs))
+
+	if s.settings.GRPC.Address != "" {
+		s.SetGRPCServer(grpcserver.NewServer().
+			SetAddress(s.settings.GRPC.Address).
+			SetHub(s.hub).
+			SetAuthenticator(s.auth).
+			SetQuota(quota).
+			SetRateLimiter(limiter).
+			SetTLS(certs))
+	}
+
+	sinks, err := newSinks(&s.settings.Sinks)
+	if err != nil {
+		return NewError(err)
+	}
+
+	quotes, err := newQuoteSinks(&s.settings.Sinks)
+	if err != nil {
+		return NewError(err)
+	}
+
+	s.SetSinks(sinks).SetQuoteSinks(quotes)
+
+	if s.settings.Admin.Address != "" {
+		admin, err := newAdminServer(s.settings.Admin.Address, router.NewMux().
+			SetReloader(s.Reload).
+			SetLatency(s.latency).
+			SetAuthenticator(s.auth).
+			SetMiddlewares().
+			SetAdminHandlers())
+		if err != nil {
+			return NewError(err)
+		}
+
+		s.SetAdminServer(admin)
+	}
+
+	dialer, err := poller.NewDialer(poller.DialerOpts{
+		ProxyURL:         s.settings.Upstream.ProxyURL,
+		CAFile:           s.settings.Upstream.CAFile,
+		HandshakeTimeout: s.settings.Upstream.HandshakeTimeout,
+		Compression:      s.settings.Upstream.Compression,
+	})
+	if err != nil {
+		return NewError(err)
+	}
+
+	const clientTimeout = 30 * time.Second
+
+	s.SetHTTPClient(&http.Client{
+		Timeout: clientTimeout,
+		Transport: &http.Transport{
+			Proxy:           dialer.Proxy,
+			TLSClientConfig: dialer.TLSClientConfig,
+		},
+	})
 
-	s.SetBinancePoller(poller.NewBinancePoller())
+	if s.settings.Book.SnapshotURL != "" {
+		s.books.SetSnapshot(orderbook.NewSnapshotFunc(s.client, s.settings.Book.SnapshotURL, s.settings.Book.MaxLevels))
+	}
+
+	s.SetBinancePoller(poller.NewBinancePoller(
+		poller.WithBaseEndpoint(s.settings.Upstream.BaseURL),
+		poller.WithDialer(dialer),
+	))
 
 	if s.http == nil {
 		return NewError(errors.New("http server is missing"))
@@ -236,11 +519,49 @@ func (s *Server) SetHTTPServer(hs *httpserver.HTTPServer) *Server {
 	return s
 }
 
+// SetGRPCServer sets gRPC API server.
+func (s *Server) SetGRPCServer(gs *grpcserver.Server) *Server {
+	s.grpc = gs
+	return s
+}
+
+// SetAdminServer sets listener of admin, pprof, swagger and metrics endpoints.
+func (s *Server) SetAdminServer(hs *httpserver.HTTPServer) *Server {
+	s.admin = hs
+	return s
+}
+
+// SetSinks sets publisher of normalized market data, nil disables publishing.
+func (s *Server) SetSinks(p *sink.Publisher) *Server {
+	s.sinks = p
+	return s
+}
+
+// SetQuoteSinks sets publisher of stored quotes, nil disables publishing.
+func (s *Server) SetQuoteSinks(p *sink.Publisher) *Server {
+	s.quotes = p
+	return s
+}
+
+// SetAlerts sets engine of alert rules and dispatcher of its alerts.
+func (s *Server) SetAlerts(e *alert.Engine, d *alert.Dispatcher) *Server {
+	s.alerts = e
+	s.notifier = d
+
+	return s
+}
+
 func (s *Server) SetBinancePoller(ws *poller.BinancePoller) *Server {
 	s.poller = ws
 	return s
 }
 
+// SetAuthenticator sets authenticator of REST and websocket clients.
+func (s *Server) SetAuthenticator(a *auth.Authenticator) *Server {
+	s.auth = a
+	return s
+}
+
 func (s *Server) SetStorage(store storage.Storage) *Server {
 	s.storage = store
 	return s
@@ -267,23 +588,243 @@ func (s *Server) GetLogger() *log.Logger {
 }
 
 // GetStorage retrives the storage.
-func (s *Server) GetStorage() *storage.MemStorage {
-	if s.storage == nil {
-		return nil
+func (s *Server) GetStorage() storage.Storage {
+	return s.storage
+}
+
+// GetHub retrieves hub publishing stored quotes to streaming clients.
+func (s *Server) GetHub() *hub.Hub {
+	return s.hub
+}
+
+// GetTape retrieves trade tape.
+func (s *Server) GetTape() *tape.Tape {
+	return s.tape
+}
+
+// missingLegs returns legs of synthetic instruments without subscribed stream.
+func (s *Server) missingLegs(streams []string) []string {
+	subscribed := make(map[string]bool, len(streams))
+
+	for _, stream := range streams {
+		symbol, _, _ := strings.Cut(stream, "@")
+		subscribed[strings.ToUpper(symbol)] = true
 	}
 
-	store, ok := s.storage.(*storage.MemStorage)
-	if !ok {
-		return nil
+	var missing []string
+
+	for _, leg := range s.synthetics.Legs() {
+		if !subscribed[leg] {
+			missing = append(missing, leg)
+		}
 	}
 
-	return store
+	return missing
+}
+
+// GetBooks retrieves order books.
+func (s *Server) GetBooks() *orderbook.Books {
+	return s.books
+}
+
+// GetArbitrage retrieves arbitrage monitor.
+func (s *Server) GetArbitrage() *arbitrage.Monitor {
+	return s.arbitrage
+}
+
+// GetLatency retrieves tracker of upstream latency.
+func (s *Server) GetLatency() *latency.Tracker {
+	return s.latency
+}
+
+// GetStaleness retrieves staleness tracker.
+func (s *Server) GetStaleness() *staleness.Tracker {
+	return s.staleness
 }
 
 func (s *Server) GetHTTPServer() *httpserver.HTTPServer {
 	return s.http
 }
 
+func (s *Server) GetAdminServer() *httpserver.HTTPServer {
+	return s.admin
+}
+
+func (s *Server) GetGRPCServer() *grpcserver.Server {
+	return s.grpc
+}
+
+func (s *Server) GetSinks() *sink.Publisher {
+	return s.sinks
+}
+
+func (s *Server) GetQuoteSinks() *sink.Publisher {
+	return s.quotes
+}
+
+func (s *Server) GetAlerts() *alert.Engine {
+	return s.alerts
+}
+
 func (s *Server) GetBinancePoller() *poller.BinancePoller {
 	return s.poller
 }
+
+// newStorage returns storage of the configured backend.
+func newStorage(settings *config.Config) storage.Storage {
+	if settings != nil && settings.Storage.Backend == config.StorageBackendMemory {
+		return storage.NewMemStorage()
+	}
+
+	return storage.NewSnapshotStorage()
+}
+
+// newAdminServer creates admin listener on host:port address.
+func newAdminServer(address string, r *router.Mux) (*httpserver.HTTPServer, error) {
+	host, p, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := strconv.Atoi(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return httpserver.NewHTTPServer().
+		SetHost(host).
+		SetPort(port).
+		SetRouter(r), nil
+}
+
+// runSinks starts publishers, they outlive the ingest loop to deliver queued messages
+// on shutdown. The returned func stops and closes them.
+func (s *Server) runSinks() (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	publishers := []*sink.Publisher{s.sinks, s.quotes}
+
+	var wg sync.WaitGroup
+
+	for _, p := range publishers {
+		wg.Add(1)
+
+		go func(p *sink.Publisher) {
+			defer wg.Done()
+			p.Run(ctx)
+		}(p)
+	}
+
+	return func() {
+		cancel()
+		wg.Wait()
+
+		for _, p := range publishers {
+			if err := p.Close(); err != nil {
+				s.logger.Errorln(err)
+			}
+		}
+	}
+}
+
+// newSinks creates publisher of normalized market data, nil when no sink is configured.
+func newSinks(settings *config.Sinks) (*sink.Publisher, error) {
+	var sinks []sink.Sink
+
+	if settings.NATS.URL != "" {
+		n, err := sink.NewNATS(settings.NATS.URL, settings.NATS.SubjectPrefix)
+		if err != nil {
+			return nil, err
+		}
+
+		sinks = append(sinks, n)
+	}
+
+	if len(settings.Kafka.Brokers) > 0 {
+		writer := sink.NewKafkaWriter(settings.Kafka.Brokers, settings.BatchSize)
+		sinks = append(sinks, sink.NewKafka(writer, settings.Kafka.TopicPrefix))
+	}
+
+	return newPublisher(settings, sinks), nil
+}
+
+// newQuoteSinks creates publisher of stored quotes, nil when no sink is configured.
+func newQuoteSinks(settings *config.Sinks) (*sink.Publisher, error) {
+	var sinks []sink.Sink
+
+	if settings.Redis.URL != "" {
+		r, err := sink.NewRedis(settings.Redis.URL, settings.Redis.Channel)
+		if err != nil {
+			return nil, err
+		}
+
+		sinks = append(sinks, r)
+	}
+
+	return newPublisher(settings, sinks), nil
+}
+
+func newPublisher(settings *config.Sinks, sinks []sink.Sink) *sink.Publisher {
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	queues := make([]*sink.Queue, 0, len(sinks))
+
+	for _, sk := range sinks {
+		queues = append(queues, sink.NewQueue(sk).
+			SetBuffer(settings.Buffer).
+			SetBatchSize(settings.BatchSize).
+			SetFlushInterval(settings.FlushInterval).
+			SetBlock(settings.Overflow == "block"))
+	}
+
+	return sink.NewPublisher(queues...)
+}
+
+// newAlerts creates engine with configured rules and dispatcher delivering its alerts.
+func newAlerts(settings *config.Alerts, logger *log.Logger) (*alert.Engine, *alert.Dispatcher, error) {
+	notifier := alert.NewDispatcher().
+		SetSecret(settings.WebhookSecret).
+		SetRetries(settings.Retries).
+		SetLogger(logger)
+
+	engine := alert.NewEngine().
+		SetNotifier(notifier).
+		SetMaxRules(settings.MaxRules).
+		SetWebhookHosts(settings.WebhookHosts)
+
+	for _, rule := range settings.Rules {
+		if err := engine.Set(rule); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return engine, notifier, nil
+}
+
+// newCertificates loads TLS certificates of the public listener, nil when TLS is disabled.
+func newCertificates(settings *config.TLS) (*httpserver.Certificates, error) {
+	if !settings.Enabled() {
+		return nil, nil //nolint:nilnil // explanation: plain HTTP without TLS
+	}
+
+	clientAuth := tls.NoClientCert
+
+	switch settings.ClientAuth {
+	case "optional":
+		clientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		clientAuth = tls.RequireAndVerifyClientCert
+	case "":
+		if settings.ClientCAFile != "" {
+			clientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return httpserver.NewCertificates(httpserver.TLSOptions{
+		CertFile:     settings.CertFile,
+		KeyFile:      settings.KeyFile,
+		ClientCAFile: settings.ClientCAFile,
+		ClientAuth:   clientAuth,
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
 	"time"
@@ -252,6 +253,13 @@ func TestServer_Setup_Success(t *testing.T) {
 	assert.Equal(t, settings, srv.GetSettings())
 	assert.NotNil(t, srv.GetSignal())
 	assert.NotNil(t, srv.GetDone())
+	assert.IsType(t, &storage.SnapshotStorage{}, srv.GetStorage())
+
+	settings.Storage.Backend = config.StorageBackendMemory
+
+	srv, err = server.Setup(settings)
+	require.NoError(t, err)
+	assert.IsType(t, &storage.MemStorage{}, srv.GetStorage())
 }
 
 func TestServer_Run(t *testing.T) {
@@ -434,3 +442,121 @@ func TestServer_Init_MissingWSPoller(t *testing.T) {
 	err = srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{}))
 	assert.NoError(t, err)
 }
+
+func TestServer_Init_UpstreamSettings(t *testing.T) {
+	srv := server.NewServer()
+
+	settings := &config.Config{
+		Host: "localhost",
+		Port: 8080,
+		Upstream: config.Upstream{
+			BaseURL:          "ws://127.0.0.1:9443/stream",
+			HandshakeTimeout: time.Second,
+		},
+	}
+
+	err := srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{}))
+	require.NoError(t, err)
+	assert.Equal(t, "ws://127.0.0.1:9443/stream", srv.GetBinancePoller().BaseEndpoint)
+	assert.Equal(t, time.Second, srv.GetBinancePoller().Dialer.HandshakeTimeout)
+
+	settings.Upstream.CAFile = "/nonexistent/ca.pem"
+	err = srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read ca file")
+}
+
+func TestServer_Run_InvalidInstruments(t *testing.T) {
+	infoFile := filepath.Join(t.TempDir(), "exchangeInfo.json")
+	require.NoError(t, os.WriteFile(infoFile, []byte(`{"symbols":[{"symbol":"BTCUSDT","status":"TRADING"}]}`), 0o600))
+
+	settings := &config.Config{
+		Host:        "localhost",
+		Port:        8080,
+		Instruments: []string{"btcusd@depth"},
+		Exchange:    config.Exchange{InfoFile: infoFile},
+	}
+
+	srv := server.NewServer()
+	require.NoError(t, srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	finished := make(chan struct{})
+
+	go func() {
+		srv.Run(ctx, cancel)
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("server should stop on unknown instrument")
+	}
+
+	assert.Nil(t, srv.GetBinancePoller().Conn, "upstream must not be dialed")
+	require.NotNil(t, srv.GetExchangeInfo())
+}
+
+func TestServer_Init_AdminServer(t *testing.T) {
+	srv := server.NewServer()
+
+	settings := &config.Config{
+		Port:  8080,
+		Admin: config.Admin{Address: "127.0.0.1:8081"},
+	}
+
+	err := srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{}))
+	require.NoError(t, err)
+	require.NotNil(t, srv.GetAdminServer())
+	assert.Equal(t, "127.0.0.1", srv.GetAdminServer().GetHost())
+	assert.Equal(t, 8081, srv.GetAdminServer().GetPort())
+
+	srv = server.NewServer()
+	settings.Admin.Address = ""
+
+	err = srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{}))
+	require.NoError(t, err)
+	assert.Nil(t, srv.GetAdminServer())
+}
+
+func TestServer_Init_GRPCServer(t *testing.T) {
+	srv := server.NewServer()
+
+	settings := &config.Config{
+		Port: 8080,
+		GRPC: config.GRPC{Address: "127.0.0.1:9090"},
+	}
+
+	err := srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{}))
+	require.NoError(t, err)
+	require.NotNil(t, srv.GetGRPCServer())
+	assert.Equal(t, "127.0.0.1:9090", srv.GetGRPCServer().GetAddress())
+	assert.Nil(t, srv.GetGRPCServer().TLS)
+
+	srv = server.NewServer()
+	settings.GRPC.Address = ""
+
+	err = srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{}))
+	require.NoError(t, err)
+	assert.Nil(t, srv.GetGRPCServer())
+}
+
+func TestServer_Init_TLS(t *testing.T) {
+	srv := server.NewServer()
+
+	settings := &config.Config{
+		Port: 8080,
+		TLS:  config.TLS{CertFile: "/nonexistent/server.crt", KeyFile: "/nonexistent/server.key"},
+	}
+
+	err := srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{}))
+	require.ErrorContains(t, err, "tls certificate")
+
+	settings.TLS = config.TLS{}
+
+	err = srv.Init(storage.NewMemStorage(), settings, make(chan os.Signal, 1), make(chan struct{}))
+	require.NoError(t, err)
+	assert.Nil(t, srv.GetHTTPServer().TLS)
+}
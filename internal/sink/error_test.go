@@ -0,0 +1,22 @@
+package sink_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/sink"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	require.NoError(t, sink.NewError(nil))
+
+	stdErr := errors.New("something went wrong")
+	err := sink.NewError(stdErr)
+
+	var sinkErr *sink.Error
+	require.True(t, errors.As(err, &sinkErr))
+	assert.Equal(t, "[sink]: something went wrong", sinkErr.Error())
+	assert.True(t, errors.Is(err, stdErr))
+}
@@ -0,0 +1,68 @@
+package sink
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaBatchTimeout bounds waiting of the writer for a full batch, Queue batches already.
+const kafkaBatchTimeout = 10 * time.Millisecond
+
+// KafkaWriter writes messages to Kafka, it is implemented by *kafka.Writer.
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Kafka publishes messages to <prefix>.<type> topics keyed by symbol, e.g. binance.bbo,
+// so updates of a symbol land in one partition in order.
+type Kafka struct {
+	writer KafkaWriter
+	prefix string
+}
+
+func NewKafka(w KafkaWriter, prefix string) *Kafka {
+	return &Kafka{writer: w, prefix: prefix}
+}
+
+// NewKafkaWriter creates writer acknowledged by all in-sync replicas that hashes keys to partitions.
+func NewKafkaWriter(brokers []string, batchSize int) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		BatchSize:              batchSize,
+		BatchTimeout:           kafkaBatchTimeout,
+		AllowAutoTopicCreation: true,
+	}
+}
+
+func (k *Kafka) Name() string {
+	return "kafka"
+}
+
+// Publish writes batch synchronously, it fails unless every message is written.
+func (k *Kafka) Publish(ctx context.Context, batch []Message) error {
+	msgs := make([]kafka.Message, len(batch))
+
+	for i := range batch {
+		msgs[i] = kafka.Message{
+			Topic: k.Topic(&batch[i]),
+			Key:   []byte(batch[i].Symbol),
+			Value: batch[i].Value,
+		}
+	}
+
+	return NewError(k.writer.WriteMessages(ctx, msgs...))
+}
+
+// Topic returns topic of the message.
+func (k *Kafka) Topic(msg *Message) string {
+	return k.prefix + "." + msg.Type
+}
+
+func (k *Kafka) Close() error {
+	return NewError(k.writer.Close())
+}
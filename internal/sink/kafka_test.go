@@ -0,0 +1,99 @@
+package sink_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+	"github.com/ole-larsen/binance-subscriber/internal/sink"
+)
+
+// fakeBroker is in-process Kafka writer that stores messages by topic, writes fail while down.
+type fakeBroker struct {
+	topics map[string][]kafka.Message
+	down   bool
+	mx     sync.Mutex
+}
+
+func (b *fakeBroker) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	if b.down {
+		return kafka.LeaderNotAvailable
+	}
+
+	for _, msg := range msgs {
+		b.topics[msg.Topic] = append(b.topics[msg.Topic], msg)
+	}
+
+	return nil
+}
+
+func (b *fakeBroker) Close() error {
+	return nil
+}
+
+func (b *fakeBroker) setDown(down bool) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	b.down = down
+}
+
+func (b *fakeBroker) messages(topic string) []kafka.Message {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	return b.topics[topic]
+}
+
+func TestKafka_Publish(t *testing.T) {
+	broker := &fakeBroker{topics: make(map[string][]kafka.Message)}
+	s := sink.NewKafka(broker, "binance")
+
+	require.NoError(t, s.Publish(context.Background(), []sink.Message{
+		{Type: marketdata.TypeBBO, Symbol: "BTCUSDT", Value: []byte("1")},
+		{Type: marketdata.TypeDepth, Symbol: "ETHUSDT", Value: []byte("2")},
+	}))
+
+	bbo := broker.messages("binance.bbo")
+	require.Len(t, bbo, 1)
+	assert.Equal(t, "BTCUSDT", string(bbo[0].Key))
+	assert.Equal(t, "1", string(bbo[0].Value))
+
+	depth := broker.messages("binance.depth")
+	require.Len(t, depth, 1)
+	assert.Equal(t, "ETHUSDT", string(depth[0].Key))
+
+	broker.setDown(true)
+
+	err := s.Publish(context.Background(), []sink.Message{{Type: marketdata.TypeBBO, Symbol: "BTCUSDT"}})
+	assert.True(t, errors.Is(err, kafka.LeaderNotAvailable))
+}
+
+func TestKafka_BuffersWhileBrokerIsDown(t *testing.T) {
+	broker := &fakeBroker{topics: make(map[string][]kafka.Message), down: true}
+	q := sink.NewQueue(sink.NewKafka(broker, "binance")).SetFlushInterval(10 * time.Millisecond)
+	stop := run(q)
+
+	defer stop()
+
+	offer(q, "BTCUSDT", "ETHUSDT")
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 2, q.Len())
+
+	broker.setDown(false)
+
+	require.Eventually(t, func() bool { return len(broker.messages("binance.bbo")) == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "BTCUSDT", string(broker.messages("binance.bbo")[0].Key))
+	assert.Equal(t, "ETHUSDT", string(broker.messages("binance.bbo")[1].Key))
+}
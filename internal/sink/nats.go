@@ -0,0 +1,62 @@
+package sink
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsFlushTimeout bounds waiting for acknowledgement of a batch.
+const natsFlushTimeout = 5 * time.Second
+
+// NATS publishes messages to <prefix>.<type>.<symbol> subjects, e.g. binance.bbo.BTCUSDT.
+// Client reconnects forever, its own reconnect buffer is disabled so messages published
+// while disconnected fail and stay in the Queue.
+type NATS struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+func NewNATS(url, prefix string, opts ...nats.Option) (*NATS, error) {
+	opts = append([]nats.Option{
+		nats.Name("binance-subscriber"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ReconnectBufSize(-1),
+	}, opts...)
+
+	conn, err := nats.Connect(url, opts...)
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	return &NATS{conn: conn, prefix: prefix}, nil
+}
+
+func (n *NATS) Name() string {
+	return "nats"
+}
+
+// Publish sends batch and waits for the server to acknowledge it with flush.
+func (n *NATS) Publish(ctx context.Context, batch []Message) error {
+	for i := range batch {
+		if err := n.conn.Publish(n.Subject(&batch[i]), batch[i].Value); err != nil {
+			return NewError(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, natsFlushTimeout)
+	defer cancel()
+
+	return NewError(n.conn.FlushWithContext(ctx))
+}
+
+// Subject returns subject of the message.
+func (n *NATS) Subject(msg *Message) string {
+	return n.prefix + "." + msg.Type + "." + msg.Symbol
+}
+
+func (n *NATS) Close() error {
+	return NewError(n.conn.Drain())
+}
@@ -0,0 +1,104 @@
+package sink_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+	"github.com/ole-larsen/binance-subscriber/internal/sink"
+)
+
+// runNATS starts embedded NATS server on port, -1 selects a random one.
+func runNATS(t *testing.T, port int) *server.Server {
+	t.Helper()
+
+	ns, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: port, NoLog: true, NoSigs: true})
+	require.NoError(t, err)
+
+	go ns.Start()
+
+	require.True(t, ns.ReadyForConnections(5*time.Second))
+	t.Cleanup(ns.Shutdown)
+
+	return ns
+}
+
+func subscribe(t *testing.T, url string) *nats.Subscription {
+	t.Helper()
+
+	conn, err := nats.Connect(url)
+	require.NoError(t, err)
+	t.Cleanup(conn.Close)
+
+	sub, err := conn.SubscribeSync("binance.>")
+	require.NoError(t, err)
+	require.NoError(t, conn.Flush())
+
+	return sub
+}
+
+func TestNATS_Publish(t *testing.T) {
+	ns := runNATS(t, -1)
+	sub := subscribe(t, ns.ClientURL())
+
+	s, err := sink.NewNATS(ns.ClientURL(), "binance")
+	require.NoError(t, err)
+
+	defer s.Close()
+
+	require.NoError(t, s.Publish(context.Background(), []sink.Message{
+		{Type: marketdata.TypeBBO, Symbol: "BTCUSDT", Value: []byte("1")},
+		{Type: marketdata.TypeTrade, Symbol: "ETHUSDT", Value: []byte("2")},
+	}))
+
+	for _, want := range []struct{ subject, data string }{
+		{"binance.bbo.BTCUSDT", "1"},
+		{"binance.trade.ETHUSDT", "2"},
+	} {
+		msg, err := sub.NextMsg(time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, want.subject, msg.Subject)
+		assert.Equal(t, want.data, string(msg.Data))
+	}
+}
+
+func TestNATS_BuffersWhileDisconnected(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	port := lis.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert // explanation: tcp listener
+	require.NoError(t, lis.Close())
+
+	// server is not started yet, the sink keeps connecting in background
+	s, err := sink.NewNATS("nats://"+lis.Addr().String(), "binance", nats.ReconnectWait(20*time.Millisecond))
+	require.NoError(t, err)
+
+	q := sink.NewQueue(s).SetFlushInterval(10 * time.Millisecond)
+	stop := run(q)
+
+	defer func() {
+		stop()
+		s.Close()
+	}()
+
+	offer(q, "BTCUSDT", "ETHUSDT")
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 2, q.Len())
+
+	ns := runNATS(t, port)
+	sub := subscribe(t, ns.ClientURL())
+
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT"} {
+		msg, err := sub.NextMsg(5 * time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "binance.bbo."+symbol, msg.Subject)
+	}
+}
@@ -0,0 +1,243 @@
+package sink
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+)
+
+const (
+	DefaultBuffer        = 10000
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 100 * time.Millisecond
+
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 5 * time.Second
+	// drainTimeout bounds delivery of messages left in the queue on shutdown.
+	drainTimeout = 5 * time.Second
+)
+
+// Queue batches messages of a sink. Messages of a failed batch stay queued and are
+// retried with backoff. When the buffer is full the oldest message is dropped or,
+// in blocking mode, Offer waits for free space to apply backpressure to the ingest loop.
+type Queue struct {
+	sink          Sink
+	items         []Message
+	notify        chan struct{}
+	space         chan struct{}
+	done          chan struct{}
+	buffer        int
+	batchSize     int
+	flushInterval time.Duration
+	block         bool
+	stopped       bool
+	mx            sync.Mutex
+}
+
+func NewQueue(s Sink) *Queue {
+	return &Queue{
+		sink:          s,
+		notify:        make(chan struct{}, 1),
+		space:         make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		buffer:        DefaultBuffer,
+		batchSize:     DefaultBatchSize,
+		flushInterval: DefaultFlushInterval,
+	}
+}
+
+// SetBuffer sets max number of queued messages, values below 1 keep the default.
+func (q *Queue) SetBuffer(size int) *Queue {
+	if size > 0 {
+		q.buffer = size
+	}
+
+	return q
+}
+
+// SetBatchSize sets max number of messages published at once, values below 1 keep the default.
+func (q *Queue) SetBatchSize(size int) *Queue {
+	if size > 0 {
+		q.batchSize = size
+	}
+
+	return q
+}
+
+// SetFlushInterval sets how long a partial batch may wait, values below 1 keep the default.
+func (q *Queue) SetFlushInterval(interval time.Duration) *Queue {
+	if interval > 0 {
+		q.flushInterval = interval
+	}
+
+	return q
+}
+
+// SetBlock makes Offer wait for free space instead of dropping the oldest message.
+func (q *Queue) SetBlock(block bool) *Queue {
+	q.block = block
+	return q
+}
+
+// Len returns the number of queued messages.
+func (q *Queue) Len() int {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	return len(q.items)
+}
+
+// Offer queues message. It does not block after Run returned.
+func (q *Queue) Offer(msg Message) {
+	q.mx.Lock()
+
+	for q.block && !q.stopped && len(q.items) >= q.buffer {
+		q.mx.Unlock()
+
+		select {
+		case <-q.space:
+		case <-q.done:
+		}
+
+		q.mx.Lock()
+	}
+
+	if len(q.items) >= q.buffer {
+		q.items = q.items[1:]
+		metrics.SinkMessages.WithLabelValues(q.sink.Name(), "dropped").Inc()
+	}
+
+	q.items = append(q.items, msg)
+	full := len(q.items) >= q.batchSize
+
+	metrics.SinkQueue.WithLabelValues(q.sink.Name()).Set(float64(len(q.items)))
+	q.mx.Unlock()
+
+	if full {
+		signal(q.notify)
+	}
+}
+
+// Run publishes full batches at once and partial ones every flush interval until ctx
+// is done, then tries to deliver the rest within drainTimeout.
+func (q *Queue) Run(ctx context.Context) {
+	defer q.stop()
+
+	ticker := time.NewTicker(q.flushInterval)
+	defer ticker.Stop()
+
+	var backoff time.Duration
+
+	for {
+		partial := false
+
+		select {
+		case <-ctx.Done():
+			q.drain()
+			return
+		case <-q.notify:
+		case <-ticker.C:
+			partial = true
+		}
+
+		if err := q.flush(ctx, partial); err == nil {
+			backoff = 0
+			continue
+		}
+
+		backoff = min(max(2*backoff, minBackoff), maxBackoff)
+
+		select {
+		case <-ctx.Done():
+			q.drain()
+			return
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// flush publishes full batches and, if partial is set, the rest of queued messages.
+// Failed batch is queued back.
+func (q *Queue) flush(ctx context.Context, partial bool) error {
+	for {
+		batch := q.take(partial)
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := q.sink.Publish(ctx, batch); err != nil {
+			metrics.SinkMessages.WithLabelValues(q.sink.Name(), "failed").Add(float64(len(batch)))
+			q.requeue(batch)
+
+			return err
+		}
+
+		metrics.SinkMessages.WithLabelValues(q.sink.Name(), "published").Add(float64(len(batch)))
+
+		if len(batch) < q.batchSize {
+			return nil
+		}
+	}
+}
+
+func (q *Queue) drain() {
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	_ = q.flush(ctx, true)
+}
+
+// take removes the next batch from the queue, partial batch is taken if allowed.
+func (q *Queue) take(partial bool) []Message {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	if !partial && len(q.items) < q.batchSize {
+		return nil
+	}
+
+	n := min(len(q.items), q.batchSize)
+	batch := make([]Message, n)
+	copy(batch, q.items)
+	q.items = q.items[n:]
+
+	metrics.SinkQueue.WithLabelValues(q.sink.Name()).Set(float64(len(q.items)))
+	signal(q.space)
+
+	return batch
+}
+
+// requeue puts failed batch back in front of newer messages, the oldest ones are
+// dropped if the buffer overflows meanwhile.
+func (q *Queue) requeue(batch []Message) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	items := append(batch, q.items...) //nolint:gocritic // explanation: batch is owned by the queue
+	if over := len(items) - q.buffer; over > 0 {
+		items = items[over:]
+		metrics.SinkMessages.WithLabelValues(q.sink.Name(), "dropped").Add(float64(over))
+	}
+
+	q.items = items
+
+	metrics.SinkQueue.WithLabelValues(q.sink.Name()).Set(float64(len(q.items)))
+}
+
+func (q *Queue) stop() {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	q.stopped = true
+	close(q.done)
+}
+
+// signal wakes up a waiter without blocking.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,205 @@
+package sink_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+	"github.com/ole-larsen/binance-subscriber/internal/sink"
+)
+
+// fakeSink records published batches, the first fail calls return an error.
+type fakeSink struct {
+	batches [][]sink.Message
+	fail    int
+	closed  bool
+	mx      sync.Mutex
+}
+
+func (f *fakeSink) Name() string {
+	return "fake"
+}
+
+func (f *fakeSink) Publish(_ context.Context, batch []sink.Message) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	if f.fail > 0 {
+		f.fail--
+		return errors.New("unavailable")
+	}
+
+	f.batches = append(f.batches, batch)
+
+	return nil
+}
+
+func (f *fakeSink) Close() error {
+	f.closed = true
+	return nil
+}
+
+func (f *fakeSink) symbols() []string {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	var symbols []string
+
+	for _, batch := range f.batches {
+		for _, msg := range batch {
+			symbols = append(symbols, msg.Symbol)
+		}
+	}
+
+	return symbols
+}
+
+func (f *fakeSink) sizes() []int {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	sizes := make([]int, 0, len(f.batches))
+	for _, batch := range f.batches {
+		sizes = append(sizes, len(batch))
+	}
+
+	return sizes
+}
+
+func offer(q *sink.Queue, symbols ...string) {
+	for _, symbol := range symbols {
+		q.Offer(sink.Message{Type: marketdata.TypeBBO, Symbol: symbol})
+	}
+}
+
+// run runs q until the returned stop is called.
+func run(q *sink.Queue) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func TestQueue_Batches(t *testing.T) {
+	s := &fakeSink{}
+	q := sink.NewQueue(s).SetBatchSize(2).SetFlushInterval(time.Hour)
+	stop := run(q)
+
+	offer(q, "A", "B", "C", "D", "E")
+
+	require.Eventually(t, func() bool { return len(s.sizes()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, 1, q.Len())
+
+	// the rest is delivered on shutdown
+	stop()
+	assert.Equal(t, []int{2, 2, 1}, s.sizes())
+	assert.Equal(t, []string{"A", "B", "C", "D", "E"}, s.symbols())
+
+	s = &fakeSink{}
+	q = sink.NewQueue(s).SetBatchSize(10).SetFlushInterval(10 * time.Millisecond)
+	stop = run(q)
+	defer stop()
+
+	offer(q, "A")
+
+	// partial batch is flushed by interval
+	require.Eventually(t, func() bool { return len(s.sizes()) == 1 }, time.Second, time.Millisecond)
+}
+
+func TestQueue_RetriesFailedBatches(t *testing.T) {
+	s := &fakeSink{fail: 2}
+	q := sink.NewQueue(s).SetBatchSize(2).SetFlushInterval(10 * time.Millisecond)
+	stop := run(q)
+	defer stop()
+
+	offer(q, "A", "B", "C")
+
+	require.Eventually(t, func() bool { return len(s.symbols()) == 3 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"A", "B", "C"}, s.symbols())
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestQueue_DropsOldest(t *testing.T) {
+	s := &fakeSink{}
+	q := sink.NewQueue(s).SetBuffer(2)
+
+	offer(q, "A", "B", "C")
+	assert.Equal(t, 2, q.Len())
+
+	run(q)()
+	assert.Equal(t, []string{"B", "C"}, s.symbols())
+}
+
+func TestQueue_Blocks(t *testing.T) {
+	s := &fakeSink{}
+	q := sink.NewQueue(s).SetBuffer(1).SetBlock(true).SetFlushInterval(10 * time.Millisecond)
+
+	offer(q, "A")
+
+	offered := make(chan struct{})
+
+	go func() {
+		offer(q, "B")
+		close(offered)
+	}()
+
+	select {
+	case <-offered:
+		t.Fatal("offer to full queue must block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	stop := run(q)
+	<-offered
+	stop()
+
+	assert.Equal(t, []string{"A", "B"}, s.symbols())
+
+	// offer does not block after run returned
+	offer(q, "C", "D")
+	assert.Equal(t, 1, q.Len())
+}
+
+func TestPublisher(t *testing.T) {
+	var nilPublisher *sink.Publisher
+
+	require.NoError(t, nilPublisher.Publish(&marketdata.Update{Type: marketdata.TypeBBO, Symbol: "BTCUSDT"}))
+	require.NoError(t, nilPublisher.Close())
+
+	first, second := &fakeSink{}, &fakeSink{}
+	p := sink.NewPublisher(sink.NewQueue(first), sink.NewQueue(second))
+
+	require.NoError(t, p.Publish(&marketdata.Update{
+		Type:   marketdata.TypeBBO,
+		Symbol: "BTCUSDT",
+		BBO:    &marketdata.BBO{Bid: "1", Ask: "2"},
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.Run(ctx)
+	require.NoError(t, p.Close())
+
+	for _, s := range []*fakeSink{first, second} {
+		require.Len(t, s.batches, 1)
+		assert.Equal(t, "BTCUSDT", s.batches[0][0].Symbol)
+		assert.JSONEq(t,
+			`{"type":"bbo","symbol":"BTCUSDT","bbo":{"bid":"1","bid_quantity":"","ask":"2","ask_quantity":"","update_id":0}}`,
+			string(s.batches[0][0].Value))
+		assert.True(t, s.closed)
+	}
+}
@@ -0,0 +1,100 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// RedisKeyPrefix prefixes hashes of quotes, e.g. quote:BTCUSDT.
+const RedisKeyPrefix = "quote:"
+
+// Redis mirrors stored quotes into quote:<symbol> hashes with symbol, bid, ask and stale fields
+// and publishes them as JSON on a pub/sub channel. Client reconnects on the next command,
+// after a failed batch every known quote is written again so hashes catch up with updates
+// dropped meanwhile.
+type Redis struct {
+	client  *redis.Client
+	latest  map[string]storage.Data
+	channel string
+	resync  bool
+}
+
+// NewRedis creates sink of redis://, rediss:// or unix:// url.
+func NewRedis(url, channel string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	return &Redis{
+		client:  redis.NewClient(opts),
+		latest:  make(map[string]storage.Data),
+		channel: channel,
+	}, nil
+}
+
+func (r *Redis) Name() string {
+	return "redis"
+}
+
+// Publish writes hashes and publishes quotes of the batch in one pipeline, other
+// message types and malformed quotes are ignored. Only the latest quote of a symbol is written to its hash.
+func (r *Redis) Publish(ctx context.Context, batch []Message) error {
+	updated := make(map[string]storage.Data, len(batch))
+
+	pipe := r.client.Pipeline()
+
+	for i := range batch {
+		if batch[i].Type != TypeQuote {
+			continue
+		}
+
+		var data storage.Data
+
+		// retrying the batch would not fix the message
+		if err := json.Unmarshal(batch[i].Value, &data); err != nil {
+			continue
+		}
+
+		r.latest[data.Symbol] = data
+		updated[data.Symbol] = data
+
+		pipe.Publish(ctx, r.channel, batch[i].Value)
+	}
+
+	if r.resync {
+		updated = r.latest
+	}
+
+	for symbol, data := range updated {
+		// stale is always written to clear it on recovery
+		values := []any{"symbol", data.Symbol, "bid", data.Bid, "ask", data.Ask, "stale", strconv.FormatBool(data.Stale)}
+		if data.Synthetic {
+			values = append(values, "synthetic", "true")
+		}
+
+		pipe.HSet(ctx, RedisKeyPrefix+symbol, values...)
+	}
+
+	if pipe.Len() == 0 {
+		return nil
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.resync = true
+		return NewError(err)
+	}
+
+	r.resync = false
+
+	return nil
+}
+
+func (r *Redis) Close() error {
+	return NewError(r.client.Close())
+}
@@ -0,0 +1,109 @@
+package sink_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/sink"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+func quotes(t *testing.T, data ...storage.Data) []sink.Message {
+	t.Helper()
+
+	s := &fakeSink{}
+	p := sink.NewPublisher(sink.NewQueue(s))
+
+	for i := range data {
+		require.NoError(t, p.PublishQuote(&data[i]))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.Run(ctx)
+
+	return s.batches[0]
+}
+
+func TestRedis_Publish(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	s, err := sink.NewRedis("redis://"+mr.Addr(), "quotes")
+	require.NoError(t, err)
+
+	defer s.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	sub := client.Subscribe(context.Background(), "quotes")
+	defer sub.Close()
+
+	_, err = sub.Receive(context.Background())
+	require.NoError(t, err)
+
+	batch := quotes(t,
+		storage.Data{Symbol: "BTCUSDT", Bid: "1", Ask: "2"},
+		storage.Data{Symbol: "ETHUSDT", Bid: "3", Ask: "4"},
+		storage.Data{Symbol: "ETHUSDT", Bid: "3", Ask: "4", Stale: true},
+		storage.Data{Symbol: "BTCUSDT", Bid: "5", Ask: "6"},
+	)
+	batch = append(batch, sink.Message{Type: "bbo", Symbol: "XRPUSDT", Value: []byte("{}")})
+
+	require.NoError(t, s.Publish(context.Background(), batch))
+
+	assert.Equal(t, "5", mr.HGet("quote:BTCUSDT", "bid"))
+	assert.Equal(t, "6", mr.HGet("quote:BTCUSDT", "ask"))
+	assert.Equal(t, "false", mr.HGet("quote:BTCUSDT", "stale"))
+	assert.Equal(t, "ETHUSDT", mr.HGet("quote:ETHUSDT", "symbol"))
+	assert.Equal(t, "true", mr.HGet("quote:ETHUSDT", "stale"))
+	assert.False(t, mr.Exists("quote:XRPUSDT"))
+
+	for _, want := range []string{
+		`{"symbol":"BTCUSDT","bid":"1","ask":"2"}`,
+		`{"symbol":"ETHUSDT","bid":"3","ask":"4"}`,
+		`{"symbol":"ETHUSDT","bid":"3","ask":"4","stale":true}`,
+		`{"symbol":"BTCUSDT","bid":"5","ask":"6"}`,
+	} {
+		select {
+		case msg := <-sub.Channel():
+			assert.Equal(t, "quotes", msg.Channel)
+			assert.JSONEq(t, want, msg.Payload)
+		case <-time.After(time.Second):
+			t.Fatal("no message published")
+		}
+	}
+}
+
+func TestRedis_Reconnects(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	s, err := sink.NewRedis("redis://"+mr.Addr(), "quotes")
+	require.NoError(t, err)
+
+	defer s.Close()
+
+	ctx := context.Background()
+
+	require.NoError(t, s.Publish(ctx, quotes(t, storage.Data{Symbol: "BTCUSDT", Bid: "1", Ask: "2"})))
+
+	mr.Close()
+
+	// the update is lost, e.g. dropped from the full queue, while redis is down
+	require.Error(t, s.Publish(ctx, quotes(t, storage.Data{Symbol: "BTCUSDT", Bid: "3", Ask: "4"})))
+
+	// restarted redis lost its data
+	require.NoError(t, mr.Restart())
+	mr.FlushAll()
+
+	require.NoError(t, s.Publish(ctx, quotes(t, storage.Data{Symbol: "ETHUSDT", Bid: "5", Ask: "6"})))
+
+	assert.Equal(t, "3", mr.HGet("quote:BTCUSDT", "bid"))
+	assert.Equal(t, "5", mr.HGet("quote:ETHUSDT", "bid"))
+}
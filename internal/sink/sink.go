@@ -0,0 +1,112 @@
+// Package sink publishes normalized market data to message buses. Every sink is fed by
+// its own Queue which batches messages and keeps them buffered while delivery fails.
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// TypeQuote is the type of stored quote messages.
+const TypeQuote = "quote"
+
+// Message is an encoded market data update.
+type Message struct {
+	Type   string
+	Symbol string
+	Value  []byte
+}
+
+// Sink delivers batches of messages, failed batch is retried by Queue.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, batch []Message) error
+	Close() error
+}
+
+// Publisher fans out updates to queues of every sink. Nil publisher discards updates.
+type Publisher struct {
+	queues []*Queue
+}
+
+func NewPublisher(queues ...*Queue) *Publisher {
+	return &Publisher{queues: queues}
+}
+
+// Publish encodes update as JSON once and offers it to every queue.
+func (p *Publisher) Publish(update *marketdata.Update) error {
+	if p == nil || len(p.queues) == 0 || update == nil {
+		return nil
+	}
+
+	value, err := json.Marshal(update)
+	if err != nil {
+		return NewError(err)
+	}
+
+	p.offer(Message{Type: update.Type, Symbol: update.Symbol, Value: value})
+
+	return nil
+}
+
+// PublishQuote encodes stored quote as JSON once and offers it to every queue.
+func (p *Publisher) PublishQuote(data *storage.Data) error {
+	if p == nil || len(p.queues) == 0 || data == nil {
+		return nil
+	}
+
+	value, err := json.Marshal(data)
+	if err != nil {
+		return NewError(err)
+	}
+
+	p.offer(Message{Type: TypeQuote, Symbol: data.Symbol, Value: value})
+
+	return nil
+}
+
+func (p *Publisher) offer(msg Message) {
+	for _, q := range p.queues {
+		q.Offer(msg)
+	}
+}
+
+// Run delivers queued messages until ctx is done, then flushes what is left.
+func (p *Publisher) Run(ctx context.Context) {
+	if p == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+
+	for _, q := range p.queues {
+		wg.Add(1)
+
+		go func(q *Queue) {
+			defer wg.Done()
+			q.Run(ctx)
+		}(q)
+	}
+
+	wg.Wait()
+}
+
+// Close closes every sink, it must be called after Run returns.
+func (p *Publisher) Close() error {
+	if p == nil {
+		return nil
+	}
+
+	errs := make([]error, 0, len(p.queues))
+
+	for _, q := range p.queues {
+		errs = append(errs, q.sink.Close())
+	}
+
+	return NewError(errors.Join(errs...))
+}
@@ -0,0 +1,298 @@
+// Package staleness tracks when every symbol was last updated and reports symbols that
+// stopped updating for longer than their threshold and their recovery.
+package staleness
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/fanout"
+	"github.com/ole-larsen/binance-subscriber/internal/log"
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// Event statuses.
+const (
+	StatusStale     = "stale"
+	StatusRecovered = "recovered"
+)
+
+const (
+	// DefaultCheckInterval is how often symbols are checked for staleness.
+	DefaultCheckInterval = time.Second
+)
+
+// Event is emitted once when symbol goes stale and once when it is updated again.
+// Updated is the time of the last update before the event.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Updated time.Time `json:"updated"`
+	Symbol  string    `json:"symbol"`
+	Status  string    `json:"status"`
+}
+
+// Freshness is the age of the last update of symbol, threshold is 0 when staleness of
+// symbol is not tracked.
+type Freshness struct {
+	Updated   time.Time `json:"updated"`
+	Symbol    string    `json:"symbol"`
+	Age       float64   `json:"age_seconds"`
+	Threshold float64   `json:"threshold_seconds"`
+	Stale     bool      `json:"stale"`
+}
+
+// Subscriber receives events of its symbols emitted after Subscribe.
+type Subscriber = fanout.Subscriber[Event]
+
+// Tracker records updates of symbols and marks them stale on Check, events are published
+// to subscribers.
+type Tracker struct {
+	*fanout.Fanout[Event]
+	symbols    map[string]*symbol
+	thresholds map[string]time.Duration
+	logger     *log.Logger
+	now        func() time.Time
+	threshold  time.Duration
+	mx         sync.Mutex
+}
+
+// symbol is the last update of a symbol and when it went stale.
+type symbol struct {
+	updated time.Time
+	since   time.Time
+	stale   bool
+}
+
+func New() *Tracker {
+	return &Tracker{
+		Fanout:     fanout.New[Event](),
+		symbols:    make(map[string]*symbol),
+		thresholds: make(map[string]time.Duration),
+		now:        time.Now,
+	}
+}
+
+// SetThreshold sets how long symbols may go without updates before they are stale,
+// 0 disables tracking of symbols without own threshold.
+func (t *Tracker) SetThreshold(threshold time.Duration) *Tracker {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.threshold = threshold
+
+	return t
+}
+
+// SetThresholds replaces thresholds of symbols overriding the default one, 0 disables
+// tracking of the symbol.
+func (t *Tracker) SetThresholds(thresholds map[string]time.Duration) *Tracker {
+	res := make(map[string]time.Duration, len(thresholds))
+
+	for s, threshold := range thresholds {
+		res[strings.ToUpper(s)] = threshold
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.thresholds = res
+
+	return t
+}
+
+// SetLogger sets logger of stale and recovered symbols.
+func (t *Tracker) SetLogger(l *log.Logger) *Tracker {
+	t.logger = l
+	return t
+}
+
+// SetClock replaces time.Now.
+func (t *Tracker) SetClock(now func() time.Time) *Tracker {
+	t.now = now
+	return t
+}
+
+// Enabled reports whether staleness of any symbol is tracked.
+func (t *Tracker) Enabled() bool {
+	if t == nil {
+		return false
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if t.threshold > 0 {
+		return true
+	}
+
+	for _, threshold := range t.thresholds {
+		if threshold > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Observe records update of the stored quote and emits recovered event if its symbol was stale.
+// Quotes marked stale are ignored.
+func (t *Tracker) Observe(data *storage.Data) {
+	if t == nil || data.Stale {
+		return
+	}
+
+	now := t.now()
+
+	t.mx.Lock()
+
+	s, ok := t.symbols[data.Symbol]
+	if !ok {
+		t.symbols[data.Symbol] = &symbol{updated: now}
+		t.mx.Unlock()
+
+		return
+	}
+
+	updated, recovered := s.updated, s.stale
+	s.updated, s.stale = now, false
+
+	var events []Event
+
+	if recovered {
+		events = append(events, Event{Time: now, Updated: updated, Symbol: data.Symbol, Status: StatusRecovered})
+		t.publish(events)
+	}
+
+	t.mx.Unlock()
+
+	t.log(events)
+}
+
+// Check marks symbols without updates for their threshold stale, emits events and returns
+// them sorted. Symbols that are already stale are not returned again.
+func (t *Tracker) Check() []string {
+	if t == nil {
+		return nil
+	}
+
+	now := t.now()
+
+	t.mx.Lock()
+
+	var events []Event
+
+	for name, s := range t.symbols {
+		threshold := t.thresholdOf(name)
+		if s.stale || threshold <= 0 || now.Sub(s.updated) < threshold {
+			continue
+		}
+
+		s.stale, s.since = true, now
+
+		events = append(events, Event{Time: now, Updated: s.updated, Symbol: name, Status: StatusStale})
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Symbol < events[j].Symbol })
+
+	t.publish(events)
+	t.mx.Unlock()
+
+	t.log(events)
+
+	symbols := make([]string, 0, len(events))
+	for _, e := range events {
+		symbols = append(symbols, e.Symbol)
+	}
+
+	return symbols
+}
+
+// Freshness returns freshness of every updated symbol sorted by symbol.
+func (t *Tracker) Freshness() []Freshness {
+	now := t.now()
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	res := make([]Freshness, 0, len(t.symbols))
+
+	for name, s := range t.symbols {
+		res = append(res, Freshness{
+			Updated:   s.updated,
+			Symbol:    name,
+			Age:       now.Sub(s.updated).Seconds(),
+			Threshold: max(t.thresholdOf(name), 0).Seconds(),
+			Stale:     s.stale,
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Symbol < res[j].Symbol })
+
+	return res
+}
+
+// Stale returns events of symbols that are stale sorted by symbol.
+func (t *Tracker) Stale() []Event {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	res := make([]Event, 0)
+
+	for name, s := range t.symbols {
+		if s.stale {
+			res = append(res, Event{Time: s.since, Updated: s.updated, Symbol: name, Status: StatusStale})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Symbol < res[j].Symbol })
+
+	return res
+}
+
+func (t *Tracker) thresholdOf(name string) time.Duration {
+	if threshold, ok := t.thresholds[name]; ok {
+		return threshold
+	}
+
+	return t.threshold
+}
+
+// publish sends events to subscribers without blocking and updates metrics.
+func (t *Tracker) publish(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	stale := 0
+
+	for _, s := range t.symbols {
+		if s.stale {
+			stale++
+		}
+	}
+
+	metrics.StaleSymbols.Set(float64(stale))
+
+	for _, e := range events {
+		metrics.StalenessEvents.WithLabelValues(e.Status).Inc()
+		t.Publish(e.Symbol, e)
+	}
+}
+
+// log logs stale and recovered symbols.
+func (t *Tracker) log(events []Event) {
+	if t.logger == nil {
+		return
+	}
+
+	for _, e := range events {
+		t.logger.Infow("symbol "+e.Status,
+			"symbol", e.Symbol,
+			"updated", e.Updated,
+			"age", e.Time.Sub(e.Updated).String(),
+		)
+	}
+}
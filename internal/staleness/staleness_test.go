@@ -0,0 +1,117 @@
+package staleness_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/staleness"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+func receive(t *testing.T, sub *staleness.Subscriber) []staleness.Event {
+	t.Helper()
+
+	var res []staleness.Event
+
+	for {
+		select {
+		case e := <-sub.Events():
+			res = append(res, e)
+		default:
+			return res
+		}
+	}
+}
+
+func TestTracker(t *testing.T) {
+	start := time.Unix(1700000000, 0).UTC()
+	now := start
+
+	tr := staleness.New().
+		SetThreshold(10 * time.Second).
+		SetThresholds(map[string]time.Duration{"ethusdt": 30 * time.Second, "SOLUSDT": 0}).
+		SetClock(func() time.Time { return now })
+	require.True(t, tr.Enabled())
+
+	sub := tr.Subscribe(nil, 0)
+	defer tr.Unsubscribe(sub)
+
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"} {
+		tr.Observe(&storage.Data{Symbol: symbol})
+	}
+
+	now = start.Add(9 * time.Second)
+	assert.Empty(t, tr.Check())
+
+	now = start.Add(10 * time.Second)
+	assert.Equal(t, []string{"BTCUSDT"}, tr.Check())
+
+	// stale symbols are reported once
+	now = start.Add(time.Minute)
+	assert.Equal(t, []string{"ETHUSDT"}, tr.Check())
+	assert.Empty(t, tr.Check())
+
+	assert.Equal(t, []staleness.Freshness{
+		{Updated: start, Symbol: "BTCUSDT", Age: 60, Threshold: 10, Stale: true},
+		{Updated: start, Symbol: "ETHUSDT", Age: 60, Threshold: 30, Stale: true},
+		{Updated: start, Symbol: "SOLUSDT", Age: 60},
+	}, tr.Freshness())
+
+	assert.Equal(t, []staleness.Event{
+		{Time: start.Add(10 * time.Second), Updated: start, Symbol: "BTCUSDT", Status: staleness.StatusStale},
+		{Time: now, Updated: start, Symbol: "ETHUSDT", Status: staleness.StatusStale},
+	}, tr.Stale())
+
+	// quotes marked stale do not recover symbol
+	tr.Observe(&storage.Data{Symbol: "BTCUSDT", Stale: true})
+	tr.Observe(&storage.Data{Symbol: "BTCUSDT"})
+
+	assert.Equal(t, []staleness.Event{
+		{Time: start.Add(10 * time.Second), Updated: start, Symbol: "BTCUSDT", Status: staleness.StatusStale},
+		{Time: now, Updated: start, Symbol: "ETHUSDT", Status: staleness.StatusStale},
+		{Time: now, Updated: start, Symbol: "BTCUSDT", Status: staleness.StatusRecovered},
+	}, receive(t, sub))
+	assert.Len(t, tr.Stale(), 1)
+
+	var nilTracker *staleness.Tracker
+	nilTracker.Observe(&storage.Data{Symbol: "BTCUSDT"})
+	assert.Nil(t, nilTracker.Check())
+	assert.False(t, nilTracker.Enabled())
+	assert.False(t, staleness.New().Enabled())
+}
+
+func TestTracker_Dropped(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tr := staleness.New().SetThreshold(time.Second).SetClock(func() time.Time { return now })
+
+	sub := tr.Subscribe(nil, 1)
+	defer tr.Unsubscribe(sub)
+
+	tr.Observe(&storage.Data{Symbol: "BTCUSDT"})
+	tr.Observe(&storage.Data{Symbol: "ETHUSDT"})
+
+	now = now.Add(time.Second)
+	assert.Equal(t, []string{"BTCUSDT", "ETHUSDT"}, tr.Check())
+	assert.Equal(t, uint64(1), tr.Dropped(sub))
+	assert.Len(t, receive(t, sub), 1)
+}
+
+func TestSuppress(t *testing.T) {
+	store := storage.NewMemStorage()
+	store.Set(storage.Data{Symbol: "BTCUSDT", Bid: "1", Ask: "2", Stale: true})
+	store.Set(storage.Data{Symbol: "ETHUSDT", Bid: "3", Ask: "4"})
+
+	s := staleness.Suppress(store)
+	assert.Nil(t, s.Get("BTCUSDT"))
+	assert.Nil(t, s.Get("SOLUSDT"))
+	assert.Equal(t, &storage.Data{Symbol: "ETHUSDT", Bid: "3", Ask: "4"}, s.Get("ETHUSDT"))
+	assert.Equal(t, []*storage.Data{{Symbol: "ETHUSDT", Bid: "3", Ask: "4"}}, s.GetAll())
+
+	s.Set(storage.Data{Symbol: "BTCUSDT", Bid: "5", Ask: "6"})
+	assert.Equal(t, &storage.Data{Symbol: "BTCUSDT", Bid: "5", Ask: "6"}, store.Get("BTCUSDT"))
+	assert.Len(t, s.GetAll(), 2)
+}
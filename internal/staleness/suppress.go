@@ -0,0 +1,38 @@
+package staleness
+
+import (
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// suppressed hides stale quotes of the wrapped storage.
+type suppressed struct {
+	storage.Storage
+}
+
+// Suppress wraps store so Get and GetAll do not return quotes marked stale, they are
+// returned again once their symbols are updated. Writes go to store.
+func Suppress(store storage.Storage) storage.Storage {
+	return &suppressed{Storage: store}
+}
+
+func (s *suppressed) Get(symbol string) *storage.Data {
+	data := s.Storage.Get(symbol)
+	if data == nil || data.Stale {
+		return nil
+	}
+
+	return data
+}
+
+func (s *suppressed) GetAll() []*storage.Data {
+	all := s.Storage.GetAll()
+	res := make([]*storage.Data, 0, len(all))
+
+	for _, data := range all {
+		if !data.Stale {
+			res = append(res, data)
+		}
+	}
+
+	return res
+}
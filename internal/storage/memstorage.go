@@ -5,10 +5,14 @@ import (
 	"sync"
 )
 
+// Data is a quote of symbol, Synthetic quotes are derived from quotes of other symbols.
+// Stale quotes are the last ones of symbols that stopped updating.
 type Data struct {
-	Symbol string `json:"symbol"`
-	Bid    string `json:"bid"`
-	Ask    string `json:"ask"`
+	Symbol    string `json:"symbol"`
+	Bid       string `json:"bid"`
+	Ask       string `json:"ask"`
+	Synthetic bool   `json:"synthetic,omitempty"`
+	Stale     bool   `json:"stale,omitempty"`
 }
 
 type MemStorage struct {
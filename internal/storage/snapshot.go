@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// SnapshotStorage keeps quotes in an immutable snapshot sorted by symbol that is replaced
+// atomically on every write. Readers never lock and never block writers. Writers are
+// serialized and copy the list of quotes, so it suits many readers of up to thousands of
+// symbols.
+type SnapshotStorage struct {
+	snapshot atomic.Pointer[snapshot]
+	mx       sync.Mutex
+}
+
+// snapshot is a version of stored quotes, index maps symbol to its position in quotes.
+// Neither is modified once stored.
+type snapshot struct {
+	index  map[string]int
+	quotes []*Data
+}
+
+var _ Storage = (*SnapshotStorage)(nil)
+
+func NewSnapshotStorage() *SnapshotStorage {
+	s := &SnapshotStorage{}
+	s.snapshot.Store(&snapshot{index: make(map[string]int), quotes: make([]*Data, 0)})
+
+	return s
+}
+
+// Set replaces quote of the symbol in a new snapshot. Index of the previous snapshot is
+// shared unless a new symbol is added.
+func (s *SnapshotStorage) Set(data Data) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	current := s.snapshot.Load()
+
+	if i, ok := current.index[data.Symbol]; ok {
+		quotes := slices.Clone(current.quotes)
+		quotes[i] = &data
+
+		s.snapshot.Store(&snapshot{index: current.index, quotes: quotes})
+
+		return
+	}
+
+	i := sort.Search(len(current.quotes), func(i int) bool {
+		return current.quotes[i].Symbol >= data.Symbol
+	})
+
+	quotes := make([]*Data, 0, len(current.quotes)+1)
+	quotes = append(quotes, current.quotes[:i]...)
+	quotes = append(quotes, &data)
+	quotes = append(quotes, current.quotes[i:]...)
+
+	index := make(map[string]int, len(quotes))
+	for i, q := range quotes {
+		index[q.Symbol] = i
+	}
+
+	s.snapshot.Store(&snapshot{index: index, quotes: quotes})
+}
+
+// Get returns copy of stored quote.
+func (s *SnapshotStorage) Get(symbol string) *Data {
+	current := s.snapshot.Load()
+
+	i, ok := current.index[symbol]
+	if !ok {
+		return nil
+	}
+
+	data := *current.quotes[i]
+
+	return &data
+}
+
+// GetAll returns quotes sorted by symbol without copying, they are shared between readers
+// and must not be modified.
+func (s *SnapshotStorage) GetAll() []*Data {
+	return slices.Clip(s.snapshot.Load().quotes)
+}
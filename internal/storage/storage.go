@@ -5,3 +5,19 @@ type Storage interface {
 	Get(symbol string) *Data
 	GetAll() []*Data
 }
+
+// ValidSymbol reports whether symbol is not empty and consists of upper case letters, digits
+// and underscores of synthetic instruments.
+func ValidSymbol(symbol string) bool {
+	if symbol == "" {
+		return false
+	}
+
+	for _, r := range symbol {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,210 @@
+package storage_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+var backends = []struct {
+	new  func() storage.Storage
+	name string
+}{
+	{name: "mutex", new: func() storage.Storage { return storage.NewMemStorage() }},
+	{name: "snapshot", new: func() storage.Storage { return storage.NewSnapshotStorage() }},
+}
+
+func TestStorage(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			store := backend.new()
+			assert.Nil(t, store.Get("BTCUSDT"))
+			assert.Empty(t, store.GetAll())
+
+			store.Set(storage.Data{Symbol: "ETHUSDT", Bid: "1", Ask: "2"})
+			store.Set(storage.Data{Symbol: "BTCUSDT", Bid: "3", Ask: "4"})
+			store.Set(storage.Data{Symbol: "SOLUSDT", Bid: "5", Ask: "6"})
+			store.Set(storage.Data{Symbol: "BTCUSDT", Bid: "7", Ask: "8", Stale: true})
+
+			assert.Equal(t, []*storage.Data{
+				{Symbol: "BTCUSDT", Bid: "7", Ask: "8", Stale: true},
+				{Symbol: "ETHUSDT", Bid: "1", Ask: "2"},
+				{Symbol: "SOLUSDT", Bid: "5", Ask: "6"},
+			}, store.GetAll())
+
+			// returned quote is a copy
+			data := store.Get("ETHUSDT")
+			require.NotNil(t, data)
+			data.Bid = "0"
+			assert.Equal(t, &storage.Data{Symbol: "ETHUSDT", Bid: "1", Ask: "2"}, store.Get("ETHUSDT"))
+		})
+	}
+}
+
+func TestValidSymbol(t *testing.T) {
+	for _, symbol := range []string{"BTCUSDT", "1000SATSUSDT", "ETH_BTC"} {
+		assert.True(t, storage.ValidSymbol(symbol), symbol)
+	}
+
+	for _, symbol := range []string{"", "btcusdt", "BTC-USDT", "BTC USDT"} {
+		assert.False(t, storage.ValidSymbol(symbol), symbol)
+	}
+}
+
+func TestSnapshotStorage_GetAllIsImmutable(t *testing.T) {
+	store := storage.NewSnapshotStorage()
+	store.Set(storage.Data{Symbol: "BTCUSDT", Bid: "1", Ask: "2"})
+
+	all := store.GetAll()
+
+	// later writes do not change returned snapshot, appends do not reach storage
+	store.Set(storage.Data{Symbol: "BTCUSDT", Bid: "3", Ask: "4"})
+	store.Set(storage.Data{Symbol: "ETHUSDT", Bid: "5", Ask: "6"})
+	_ = append(all, &storage.Data{Symbol: "XRPUSDT"})
+
+	assert.Equal(t, []*storage.Data{{Symbol: "BTCUSDT", Bid: "1", Ask: "2"}}, all)
+	assert.Len(t, store.GetAll(), 2)
+}
+
+func TestSnapshotStorage_Concurrent(t *testing.T) {
+	store := storage.NewSnapshotStorage()
+	symbols := quoteSymbols(50)
+
+	var wg sync.WaitGroup
+
+	for w := range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range 1000 {
+				store.Set(storage.Data{Symbol: symbols[(w+i)%len(symbols)], Bid: "1", Ask: "2"})
+			}
+		}()
+	}
+
+	for range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range 1000 {
+				all := store.GetAll()
+				for i := 1; i < len(all); i++ {
+					assert.Less(t, all[i-1].Symbol, all[i].Symbol)
+				}
+
+				store.Get(symbols[0])
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, store.GetAll(), len(symbols))
+}
+
+func quoteSymbols(n int) []string {
+	symbols := make([]string, n)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("SYM%04dUSDT", i)
+	}
+
+	return symbols
+}
+
+// populated returns storage of backend with n symbols.
+func populated(newStorage func() storage.Storage, n int) (storage.Storage, []string) {
+	store := newStorage()
+	symbols := quoteSymbols(n)
+
+	for _, symbol := range symbols {
+		store.Set(storage.Data{Symbol: symbol, Bid: "97000.01", Ask: "97000.02"})
+	}
+
+	return store, symbols
+}
+
+func BenchmarkStorage_Set(b *testing.B) {
+	for _, backend := range backends {
+		b.Run(backend.name, func(b *testing.B) {
+			store, symbols := populated(backend.new, 500)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				store.Set(storage.Data{Symbol: symbols[i%len(symbols)], Bid: "97000.03", Ask: "97000.04"})
+			}
+		})
+	}
+}
+
+func BenchmarkStorage_GetAll(b *testing.B) {
+	for _, backend := range backends {
+		b.Run(backend.name, func(b *testing.B) {
+			store, _ := populated(backend.new, 500)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					store.GetAll()
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkStorage_Concurrent measures readers of every quote, e.g. /ws echoes, and of single
+// quotes while a writer keeps updating every symbol.
+func BenchmarkStorage_Concurrent(b *testing.B) {
+	for _, backend := range backends {
+		for _, symbolsCount := range []int{100, 1000} {
+			b.Run(fmt.Sprintf("%s/%d", backend.name, symbolsCount), func(b *testing.B) {
+				store, symbols := populated(backend.new, symbolsCount)
+
+				done := make(chan struct{})
+				stopped := make(chan struct{})
+
+				go func() {
+					defer close(stopped)
+
+					for i := 0; ; i++ {
+						select {
+						case <-done:
+							return
+						default:
+							store.Set(storage.Data{Symbol: symbols[i%len(symbols)], Bid: "97000.03", Ask: "97000.04"})
+						}
+					}
+				}()
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				b.RunParallel(func(pb *testing.PB) {
+					for i := 0; pb.Next(); i++ {
+						if i%10 == 0 {
+							store.GetAll()
+						} else {
+							store.Get(symbols[i%len(symbols)])
+						}
+					}
+				})
+
+				b.StopTimer()
+				close(done)
+				<-stopped
+			})
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package synthetic_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/synthetic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	require.NoError(t, synthetic.NewError(nil))
+
+	stdErr := errors.New("something went wrong")
+	err := synthetic.NewError(stdErr)
+
+	var syntheticErr *synthetic.Error
+	require.True(t, errors.As(err, &syntheticErr))
+	assert.Equal(t, "[synthetic]: something went wrong", syntheticErr.Error())
+	assert.True(t, errors.Is(err, stdErr))
+}
@@ -0,0 +1,335 @@
+package synthetic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Price fields of leg references, a bare symbol refers to its bid/ask range.
+const (
+	FieldBid = "bid"
+	FieldAsk = "ask"
+	FieldMid = "mid"
+)
+
+var errDivisionByZero = errors.New("division by range containing zero")
+
+// interval is a price range, a quote is [bid, ask].
+type interval struct {
+	lo float64
+	hi float64
+}
+
+// QuoteFunc returns bid and ask of symbol, false if there is no quote.
+type QuoteFunc func(symbol string) (bid, ask float64, ok bool)
+
+// Expr is a parsed expression over quotes of legs. It is evaluated with interval
+// arithmetic: a bare symbol is its [bid, ask] range and the result range is the
+// synthetic [bid, ask], e.g. "ETHUSDT / BTCUSDT" gives ETHUSDT bid / BTCUSDT ask
+// as bid and ETHUSDT ask / BTCUSDT bid as ask.
+type Expr struct {
+	root node
+	legs []string
+}
+
+// Parse parses expression of numbers, symbols optionally suffixed with .bid, .ask or
+// .mid, operators + - * / and parentheses. Symbols are case-insensitive.
+func Parse(expression string) (*Expr, error) {
+	p := &parser{input: expression, legs: make(map[string]struct{})}
+
+	p.next()
+
+	root, err := p.expr()
+	if err == nil && p.tok.kind != tokEOF {
+		err = p.errorf("unexpected %q", p.tok.text)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", expression, err)
+	}
+
+	legs := make([]string, 0, len(p.legs))
+	for leg := range p.legs {
+		legs = append(legs, leg)
+	}
+
+	sort.Strings(legs)
+
+	return &Expr{root: root, legs: legs}, nil
+}
+
+// Legs returns sorted symbols of expression.
+func (e *Expr) Legs() []string {
+	return e.legs
+}
+
+// Eval returns bid and ask of expression, false if a leg has no quote or the result
+// is undefined, e.g. division by a range containing zero.
+func (e *Expr) Eval(quote QuoteFunc) (bid, ask float64, ok bool) {
+	v, err := e.root.eval(quote)
+	if err != nil || math.IsNaN(v.lo) || math.IsNaN(v.hi) || math.IsInf(v.lo, 0) || math.IsInf(v.hi, 0) {
+		return 0, 0, false
+	}
+
+	return v.lo, v.hi, true
+}
+
+// errMissing is returned by references of legs without quote.
+var errMissing = errors.New("missing quote")
+
+type node interface {
+	eval(quote QuoteFunc) (interval, error)
+}
+
+type number float64
+
+func (n number) eval(QuoteFunc) (interval, error) {
+	return interval{float64(n), float64(n)}, nil
+}
+
+type ref struct {
+	symbol string
+	field  string
+}
+
+func (r *ref) eval(quote QuoteFunc) (interval, error) {
+	bid, ask, ok := quote(r.symbol)
+	if !ok {
+		return interval{}, errMissing
+	}
+
+	switch r.field {
+	case FieldBid:
+		return interval{bid, bid}, nil
+	case FieldAsk:
+		return interval{ask, ask}, nil
+	case FieldMid:
+		mid := (bid + ask) / 2
+		return interval{mid, mid}, nil
+	}
+
+	return interval{min(bid, ask), max(bid, ask)}, nil
+}
+
+type neg struct {
+	x node
+}
+
+func (n *neg) eval(quote QuoteFunc) (interval, error) {
+	v, err := n.x.eval(quote)
+
+	return interval{-v.hi, -v.lo}, err
+}
+
+type binary struct {
+	x  node
+	y  node
+	op byte
+}
+
+func (b *binary) eval(quote QuoteFunc) (interval, error) {
+	x, err := b.x.eval(quote)
+	if err != nil {
+		return interval{}, err
+	}
+
+	y, err := b.y.eval(quote)
+	if err != nil {
+		return interval{}, err
+	}
+
+	switch b.op {
+	case '+':
+		return interval{x.lo + y.lo, x.hi + y.hi}, nil
+	case '-':
+		return interval{x.lo - y.hi, x.hi - y.lo}, nil
+	case '*':
+		return multiply(x, y), nil
+	}
+
+	if y.lo <= 0 && y.hi >= 0 {
+		return interval{}, errDivisionByZero
+	}
+
+	return multiply(x, interval{1 / y.hi, 1 / y.lo}), nil
+}
+
+func multiply(x, y interval) interval {
+	a, b, c, d := x.lo*y.lo, x.lo*y.hi, x.hi*y.lo, x.hi*y.hi
+
+	return interval{min(a, b, c, d), max(a, b, c, d)}
+}
+
+const (
+	tokEOF = iota
+	tokNumber
+	tokSymbol
+	tokOp
+)
+
+type token struct {
+	text string
+	kind int
+	pos  int
+}
+
+// parser is a recursive descent parser of
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/") unary }
+//	unary   = "-" unary | primary
+//	primary = number | symbol [ "." field ] | "(" expr ")"
+type parser struct {
+	legs  map[string]struct{}
+	input string
+	tok   token
+	pos   int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("at %d: %s", p.tok.pos, fmt.Sprintf(format, args...))
+}
+
+// next scans the next token.
+func (p *parser) next() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+
+	start := p.pos
+
+	if p.pos == len(p.input) {
+		p.tok = token{kind: tokEOF, pos: start}
+		return
+	}
+
+	c := p.input[p.pos]
+
+	switch {
+	case isDigit(c) || c == '.':
+		for p.pos < len(p.input) && (isDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+
+		p.tok = token{kind: tokNumber, text: p.input[start:p.pos], pos: start}
+	case isLetter(c):
+		// symbol with optional .field suffix
+		for p.pos < len(p.input) && (isLetter(p.input[p.pos]) || isDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+
+		p.tok = token{kind: tokSymbol, text: p.input[start:p.pos], pos: start}
+	default:
+		p.pos++
+		p.tok = token{kind: tokOp, text: string(c), pos: start}
+	}
+}
+
+func (p *parser) expr() (node, error) {
+	x, err := p.term()
+
+	for err == nil && p.tok.kind == tokOp && (p.tok.text == "+" || p.tok.text == "-") {
+		op := p.tok.text[0]
+		p.next()
+
+		var y node
+
+		if y, err = p.term(); err == nil {
+			x = &binary{op: op, x: x, y: y}
+		}
+	}
+
+	return x, err
+}
+
+func (p *parser) term() (node, error) {
+	x, err := p.unary()
+
+	for err == nil && p.tok.kind == tokOp && (p.tok.text == "*" || p.tok.text == "/") {
+		op := p.tok.text[0]
+		p.next()
+
+		var y node
+
+		if y, err = p.unary(); err == nil {
+			x = &binary{op: op, x: x, y: y}
+		}
+	}
+
+	return x, err
+}
+
+func (p *parser) unary() (node, error) {
+	if p.tok.kind == tokOp && p.tok.text == "-" {
+		p.next()
+
+		x, err := p.unary()
+
+		return &neg{x: x}, err
+	}
+
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.tok
+
+	switch {
+	case tok.kind == tokNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", tok.text)
+		}
+
+		p.next()
+
+		return number(v), nil
+	case tok.kind == tokSymbol:
+		symbol, field, _ := strings.Cut(tok.text, ".")
+		field = strings.ToLower(field)
+
+		switch field {
+		case "", FieldBid, FieldAsk, FieldMid:
+		default:
+			return nil, p.errorf("unknown field %q of %s, expected bid, ask or mid", field, symbol)
+		}
+
+		symbol = strings.ToUpper(symbol)
+		p.legs[symbol] = struct{}{}
+		p.next()
+
+		return &ref{symbol: symbol, field: field}, nil
+	case tok.kind == tokOp && tok.text == "(":
+		p.next()
+
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.tok.kind != tokOp || p.tok.text != ")" {
+			return nil, p.errorf("expected )")
+		}
+
+		p.next()
+
+		return x, nil
+	case tok.kind == tokEOF:
+		return nil, p.errorf("unexpected end")
+	}
+
+	return nil, p.errorf("unexpected %q", tok.text)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+}
@@ -0,0 +1,112 @@
+package synthetic_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/synthetic"
+)
+
+func quotes(q map[string][2]float64) synthetic.QuoteFunc {
+	return func(symbol string) (float64, float64, bool) {
+		v, ok := q[symbol]
+		return v[0], v[1], ok
+	}
+}
+
+func TestParse(t *testing.T) {
+	market := quotes(map[string][2]float64{
+		"BTCUSDT":  {100, 101},
+		"ETHUSDT":  {10, 11},
+		"USDCUSDT": {-1, 1},
+	})
+
+	tests := []struct {
+		name       string
+		expression string
+		legs       []string
+		bid        float64
+		ask        float64
+		ok         bool
+	}{
+		{
+			name:       "cross rate",
+			expression: "ethusdt / BTCUSDT",
+			legs:       []string{"BTCUSDT", "ETHUSDT"},
+			bid:        10.0 / 101,
+			ask:        11.0 / 100,
+			ok:         true,
+		},
+		{
+			name:       "basket",
+			expression: "(BTCUSDT + ETHUSDT * 2) / 2",
+			legs:       []string{"BTCUSDT", "ETHUSDT"},
+			bid:        60,
+			ask:        61.5,
+			ok:         true,
+		},
+		{
+			name:       "fields",
+			expression: "BTCUSDT.ask - ETHUSDT.Bid + ETHUSDT.mid",
+			legs:       []string{"BTCUSDT", "ETHUSDT"},
+			bid:        101.5,
+			ask:        101.5,
+			ok:         true,
+		},
+		{
+			name:       "negation",
+			expression: "-BTCUSDT * -1",
+			legs:       []string{"BTCUSDT"},
+			bid:        100,
+			ask:        101,
+			ok:         true,
+		},
+		{
+			name:       "difference",
+			expression: "BTCUSDT - ETHUSDT",
+			legs:       []string{"BTCUSDT", "ETHUSDT"},
+			bid:        89,
+			ask:        91,
+			ok:         true,
+		},
+		{
+			name:       "missing leg",
+			expression: "BTCUSDT / SOLUSDT",
+			legs:       []string{"BTCUSDT", "SOLUSDT"},
+		},
+		{
+			name:       "division by range containing zero",
+			expression: "BTCUSDT / USDCUSDT",
+			legs:       []string{"BTCUSDT", "USDCUSDT"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := synthetic.Parse(tt.expression)
+			require.NoError(t, err)
+			assert.Equal(t, tt.legs, expr.Legs())
+
+			bid, ask, ok := expr.Eval(market)
+			require.Equal(t, tt.ok, ok)
+			assert.InDelta(t, tt.bid, bid, 1e-12)
+			assert.InDelta(t, tt.ask, ask, 1e-12)
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for expression, want := range map[string]string{
+		"":                  "at 0: unexpected end",
+		"BTCUSDT +":         "at 9: unexpected end",
+		"(BTCUSDT":          "at 8: expected )",
+		"BTCUSDT ETHUSDT":   `at 8: unexpected "ETHUSDT"`,
+		"BTCUSDT.last":      `unknown field "last" of BTCUSDT`,
+		"1.2.3 * BTCUSDT":   `invalid number "1.2.3"`,
+		"BTCUSDT % ETHUSDT": `unexpected "%"`,
+	} {
+		_, err := synthetic.Parse(expression)
+		require.ErrorContains(t, err, want, expression)
+	}
+}
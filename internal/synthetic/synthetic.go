@@ -0,0 +1,214 @@
+// Package synthetic prices instruments derived from quotes of subscribed symbols, e.g. cross
+// rates like ETHBTC implied from ETHUSDT and BTCUSDT or basket indexes.
+package synthetic
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+const (
+	// DefaultPrecision is the number of decimals of synthetic prices.
+	DefaultPrecision = 8
+	// MaxPrecision bounds decimals of synthetic prices.
+	MaxPrecision = 16
+)
+
+// maxSymbol limits synthetic symbols to 16 bytes of the bbo /ws encoding.
+const maxSymbol = 16
+
+// Instrument is a synthetic Symbol priced by Expression over quotes of other symbols,
+// e.g. "ETHUSDT / BTCUSDT" or "BTCUSDT.mid * 0.6 + ETHUSDT.mid * 12". Prices are
+// formatted with Precision decimals, DefaultPrecision if zero.
+type Instrument struct {
+	Symbol     string `json:"symbol"              yaml:"symbol"`
+	Expression string `json:"expression"          yaml:"expression"`
+	Precision  int    `json:"precision,omitempty" yaml:"precision,omitempty"`
+}
+
+// Validate checks symbol and precision and parses expression.
+func (i *Instrument) Validate() error {
+	_, err := i.parse()
+	return err
+}
+
+func (i *Instrument) parse() (*Expr, error) {
+	var errs []error
+
+	if !storage.ValidSymbol(i.Symbol) || len(i.Symbol) > maxSymbol {
+		errs = append(errs, errors.New("symbol must be 1 to 16 upper case letters, digits or underscores"))
+	}
+
+	if i.Precision < 0 || i.Precision > MaxPrecision {
+		errs = append(errs, fmt.Errorf("precision %d must be between 0 and %d", i.Precision, MaxPrecision))
+	}
+
+	expr, err := Parse(i.Expression)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if err == nil {
+		for _, leg := range expr.Legs() {
+			if leg == i.Symbol {
+				errs = append(errs, errors.New("expression refers to the instrument itself"))
+			}
+		}
+	}
+
+	if err = errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("synthetic %q: %w", i.Symbol, err)
+	}
+
+	return expr, nil
+}
+
+// instrument is a parsed Instrument with its last published quote.
+type instrument struct {
+	expr      *Expr
+	last      storage.Data
+	symbol    string
+	precision int
+}
+
+// Engine recalculates synthetic instruments whenever quote of one of their legs changes.
+type Engine struct {
+	instruments []*instrument
+	legs        map[string][]*instrument
+	quotes      map[string]interval
+	mx          sync.Mutex
+}
+
+func New() *Engine {
+	return &Engine{
+		legs:   make(map[string][]*instrument),
+		quotes: make(map[string]interval),
+	}
+}
+
+// Set replaces instruments, they must not be legs of each other.
+func (e *Engine) Set(instruments []Instrument) error {
+	parsed := make([]*instrument, 0, len(instruments))
+	legs := make(map[string][]*instrument)
+	symbols := make(map[string]struct{}, len(instruments))
+
+	for k := range instruments {
+		expr, err := instruments[k].parse()
+		if err != nil {
+			return NewError(err)
+		}
+
+		if _, ok := symbols[instruments[k].Symbol]; ok {
+			return NewError(fmt.Errorf("synthetic %q: duplicate symbol", instruments[k].Symbol))
+		}
+
+		symbols[instruments[k].Symbol] = struct{}{}
+
+		precision := instruments[k].Precision
+		if precision == 0 {
+			precision = DefaultPrecision
+		}
+
+		in := &instrument{expr: expr, symbol: instruments[k].Symbol, precision: precision}
+		parsed = append(parsed, in)
+
+		for _, leg := range expr.Legs() {
+			legs[leg] = append(legs[leg], in)
+		}
+	}
+
+	for leg := range legs {
+		if _, ok := symbols[leg]; ok {
+			return NewError(fmt.Errorf("synthetic %q: synthetic instruments can not be legs", leg))
+		}
+	}
+
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	e.instruments = parsed
+	e.legs = legs
+
+	return nil
+}
+
+// Legs returns sorted symbols instruments are priced from.
+func (e *Engine) Legs() []string {
+	if e == nil {
+		return nil
+	}
+
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	legs := make([]string, 0, len(e.legs))
+	for leg := range e.legs {
+		legs = append(legs, leg)
+	}
+
+	sort.Strings(legs)
+
+	return legs
+}
+
+// Update records quote of a leg and returns changed quotes of instruments priced from it.
+// Instruments with a leg without quote or with undefined price are skipped.
+func (e *Engine) Update(data *storage.Data) []storage.Data {
+	if e == nil {
+		return nil
+	}
+
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	dependents, ok := e.legs[data.Symbol]
+	if !ok {
+		return nil
+	}
+
+	bid, bidErr := strconv.ParseFloat(data.Bid, 64)
+	ask, askErr := strconv.ParseFloat(data.Ask, 64)
+
+	if bidErr != nil || askErr != nil {
+		delete(e.quotes, data.Symbol)
+		return nil
+	}
+
+	e.quotes[data.Symbol] = interval{bid, ask}
+
+	var res []storage.Data
+
+	for _, in := range dependents {
+		bid, ask, ok := in.expr.Eval(e.quote)
+		if !ok {
+			continue
+		}
+
+		quote := storage.Data{
+			Symbol:    in.symbol,
+			Bid:       strconv.FormatFloat(bid, 'f', in.precision, 64),
+			Ask:       strconv.FormatFloat(ask, 'f', in.precision, 64),
+			Synthetic: true,
+		}
+
+		if quote == in.last {
+			continue
+		}
+
+		in.last = quote
+		res = append(res, quote)
+	}
+
+	return res
+}
+
+func (e *Engine) quote(symbol string) (bid, ask float64, ok bool) {
+	q, ok := e.quotes[symbol]
+
+	return q.lo, q.hi, ok
+}
@@ -0,0 +1,108 @@
+package synthetic_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+	"github.com/ole-larsen/binance-subscriber/internal/synthetic"
+)
+
+func TestInstrument_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		want       string
+		instrument synthetic.Instrument
+	}{
+		{
+			name:       "valid",
+			instrument: synthetic.Instrument{Symbol: "ETHBTC_SYN", Expression: "ETHUSDT / BTCUSDT", Precision: 6},
+		},
+		{
+			name:       "lower case symbol",
+			instrument: synthetic.Instrument{Symbol: "ethbtc", Expression: "ETHUSDT / BTCUSDT"},
+			want:       `synthetic "ethbtc": symbol must be 1 to 16 upper case letters, digits or underscores`,
+		},
+		{
+			name:       "long symbol",
+			instrument: synthetic.Instrument{Symbol: "CRYPTO_INDEX_TOP10", Expression: "BTCUSDT"},
+			want:       "symbol must be 1 to 16",
+		},
+		{
+			name:       "precision",
+			instrument: synthetic.Instrument{Symbol: "BTC_X", Expression: "BTCUSDT", Precision: 17},
+			want:       "precision 17 must be between 0 and 16",
+		},
+		{
+			name:       "expression",
+			instrument: synthetic.Instrument{Symbol: "BTC_X", Expression: "BTCUSDT *"},
+			want:       `expression "BTCUSDT *": at 9: unexpected end`,
+		},
+		{
+			name:       "self reference",
+			instrument: synthetic.Instrument{Symbol: "BTC_X", Expression: "BTC_X * 2"},
+			want:       "expression refers to the instrument itself",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.instrument.Validate()
+			if tt.want == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestEngine_Update(t *testing.T) {
+	e := synthetic.New()
+	require.NoError(t, e.Set([]synthetic.Instrument{
+		{Symbol: "ETHBTC_SYN", Expression: "ETHUSDT / BTCUSDT", Precision: 5},
+		{Symbol: "INDEX", Expression: "BTCUSDT.mid + ETHUSDT.mid * 10"},
+	}))
+
+	assert.Equal(t, []string{"BTCUSDT", "ETHUSDT"}, e.Legs())
+
+	// instruments are priced once every leg has a quote
+	assert.Empty(t, e.Update(&storage.Data{Symbol: "BTCUSDT", Bid: "100", Ask: "101"}))
+	assert.Empty(t, e.Update(&storage.Data{Symbol: "SOLUSDT", Bid: "1", Ask: "2"}))
+
+	assert.Equal(t, []storage.Data{
+		{Symbol: "ETHBTC_SYN", Bid: "0.09901", Ask: "0.11000", Synthetic: true},
+		{Symbol: "INDEX", Bid: "205.50000000", Ask: "205.50000000", Synthetic: true},
+	}, e.Update(&storage.Data{Symbol: "ETHUSDT", Bid: "10", Ask: "11"}))
+
+	// unchanged quotes are not published again, mid of ETHUSDT stays 10.5
+	assert.Equal(t, []storage.Data{
+		{Symbol: "ETHBTC_SYN", Bid: "0.09802", Ask: "0.11100", Synthetic: true},
+	}, e.Update(&storage.Data{Symbol: "ETHUSDT", Bid: "9.9", Ask: "11.1"}))
+
+	// malformed quote removes leg until the next valid one
+	assert.Empty(t, e.Update(&storage.Data{Symbol: "BTCUSDT", Bid: "n/a", Ask: "101"}))
+	assert.Empty(t, e.Update(&storage.Data{Symbol: "ETHUSDT", Bid: "10", Ask: "11"}))
+
+	var nilEngine *synthetic.Engine
+	assert.Nil(t, nilEngine.Update(&storage.Data{Symbol: "BTCUSDT"}))
+}
+
+func TestEngine_Set(t *testing.T) {
+	e := synthetic.New()
+
+	require.ErrorContains(t, e.Set([]synthetic.Instrument{
+		{Symbol: "A", Expression: "BTCUSDT"},
+		{Symbol: "A", Expression: "ETHUSDT"},
+	}), `synthetic "A": duplicate symbol`)
+
+	require.ErrorContains(t, e.Set([]synthetic.Instrument{
+		{Symbol: "A", Expression: "BTCUSDT"},
+		{Symbol: "B", Expression: "A * 2"},
+	}), `synthetic "A": synthetic instruments can not be legs`)
+
+	require.ErrorContains(t, e.Set([]synthetic.Instrument{{Symbol: "A", Expression: "("}}), "[synthetic]: ")
+	assert.Empty(t, e.Legs())
+}
@@ -0,0 +1,22 @@
+package tape_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/tape"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	require.NoError(t, tape.NewError(nil))
+
+	stdErr := errors.New("something went wrong")
+	err := tape.NewError(stdErr)
+
+	var tapeErr *tape.Error
+	require.True(t, errors.As(err, &tapeErr))
+	assert.Equal(t, "[tape]: something went wrong", tapeErr.Error())
+	assert.True(t, errors.Is(err, stdErr))
+}
@@ -0,0 +1,89 @@
+package tape
+
+import (
+	"strings"
+	"time"
+)
+
+// Stats are rolling statistics of trades over Window. VWAP is zero without trades.
+type Stats struct {
+	Window      string  `json:"window"`
+	VWAP        float64 `json:"vwap"`
+	Volume      float64 `json:"volume"`
+	QuoteVolume float64 `json:"quote_volume"`
+	BuyVolume   float64 `json:"buy_volume"`
+	SellVolume  float64 `json:"sell_volume"`
+	Trades      int     `json:"trades"`
+	BuyTrades   int     `json:"buy_trades"`
+	SellTrades  int     `json:"sell_trades"`
+}
+
+// SymbolStats are statistics of symbol over every window from the shortest one.
+type SymbolStats struct {
+	Symbol  string  `json:"symbol"`
+	Windows []Stats `json:"windows"`
+}
+
+// Stats returns statistics of symbol over windows ending now, windows are aligned
+// to seconds and include the current one. False is returned for symbol without trades.
+func (t *Tape) Stats(symbol string) (*SymbolStats, bool) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	b, ok := t.symbols[symbol]
+	if !ok {
+		return nil, false
+	}
+
+	now := t.now().Unix()
+	res := &SymbolStats{Symbol: symbol, Windows: make([]Stats, 0, len(t.windows))}
+
+	for _, w := range t.windows {
+		res.Windows = append(res.Windows, b.stats(w, now))
+	}
+
+	return res, true
+}
+
+// stats sums buckets of the window, the newest first.
+func (b *book) stats(w time.Duration, now int64) Stats {
+	s := Stats{Window: FormatWindow(w)}
+	from := now - int64(w/time.Second)
+
+	for i := len(b.buckets) - 1; i >= 0 && b.buckets[i].second > from; i-- {
+		bk := &b.buckets[i]
+		if bk.second > now {
+			continue
+		}
+
+		s.BuyVolume += bk.buyVolume
+		s.SellVolume += bk.sellVolume
+		s.QuoteVolume += bk.quoteVolume
+		s.BuyTrades += bk.buyTrades
+		s.SellTrades += bk.sellTrades
+	}
+
+	s.Volume = s.BuyVolume + s.SellVolume
+	s.Trades = s.BuyTrades + s.SellTrades
+
+	if s.Volume > 0 {
+		s.VWAP = s.QuoteVolume / s.Volume
+	}
+
+	return s
+}
+
+// FormatWindow formats window without zero units, e.g. "5m" instead of "5m0s".
+func FormatWindow(w time.Duration) string {
+	s := w.String()
+
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+
+	return s
+}
@@ -0,0 +1,271 @@
+// Package tape keeps the latest trades of every symbol and rolling volume statistics over time windows.
+package tape
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ole-larsen/binance-subscriber/internal/fanout"
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+)
+
+// Aggressor sides of trades.
+const (
+	SideBuy  = "buy"
+	SideSell = "sell"
+)
+
+// DefaultSize is the number of the latest trades kept per symbol.
+const DefaultSize = 1000
+
+// DefaultWindows are windows of rolling statistics.
+var DefaultWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// Trade is an executed trade, Side is the aggressor side: buy when the buyer took liquidity.
+type Trade struct {
+	Price    string `json:"price"`
+	Quantity string `json:"quantity"`
+	Side     string `json:"side"`
+	ID       int64  `json:"id"`
+	// Time is trade time in milliseconds.
+	Time int64 `json:"time"`
+}
+
+// Event is a trade published to subscribers.
+type Event struct {
+	Symbol string `json:"symbol"`
+	Trade  Trade  `json:"trade"`
+}
+
+// Subscriber receives trades of its symbols added after Subscribe.
+type Subscriber = fanout.Subscriber[Event]
+
+// Tape keeps a bounded ring of the latest trades and per second volume buckets covering
+// the longest window of every symbol. Added trades are published to subscribers.
+type Tape struct {
+	*fanout.Fanout[Event]
+	symbols map[string]*book
+	now     func() time.Time
+	windows []time.Duration
+	size    int
+	longest int64
+	mx      sync.Mutex
+}
+
+// book is the state of a symbol.
+type book struct {
+	trades  []Trade
+	buckets []bucket
+	next    int
+	count   int
+}
+
+// bucket aggregates trades of one second.
+type bucket struct {
+	second      int64
+	buyVolume   float64
+	sellVolume  float64
+	quoteVolume float64
+	buyTrades   int
+	sellTrades  int
+}
+
+func New() *Tape {
+	t := &Tape{
+		Fanout:  fanout.New[Event](),
+		symbols: make(map[string]*book),
+		now:     time.Now,
+		size:    DefaultSize,
+	}
+
+	return t.SetWindows(DefaultWindows)
+}
+
+// SetSize sets number of the latest trades kept per symbol, kept trades are forgotten.
+func (t *Tape) SetSize(size int) *Tape {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.size = max(size, 1)
+	t.symbols = make(map[string]*book)
+
+	return t
+}
+
+// SetWindows sets windows of rolling statistics, they are sorted and rounded up to seconds.
+func (t *Tape) SetWindows(windows []time.Duration) *Tape {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.windows = make([]time.Duration, 0, len(windows))
+	t.longest = 0
+
+	for _, w := range windows {
+		w = ceilSecond(w)
+		if w <= 0 {
+			continue
+		}
+
+		t.windows = append(t.windows, w)
+		t.longest = max(t.longest, int64(w/time.Second))
+	}
+
+	sort.Slice(t.windows, func(i, j int) bool {
+		return t.windows[i] < t.windows[j]
+	})
+
+	return t
+}
+
+// SetClock replaces time.Now.
+func (t *Tape) SetClock(now func() time.Time) *Tape {
+	t.now = now
+	return t
+}
+
+// Windows returns windows of rolling statistics.
+func (t *Tape) Windows() []time.Duration {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	return append([]time.Duration(nil), t.windows...)
+}
+
+// Add records trade of symbol and publishes it to subscribers without blocking.
+// Trade without time is recorded at the current time.
+func (t *Tape) Add(symbol string, trade *marketdata.Trade) error {
+	if t == nil {
+		return nil
+	}
+
+	price, err := strconv.ParseFloat(trade.Price, 64)
+	if err != nil {
+		return NewError(fmt.Errorf("trade %s %d: price %q: %w", symbol, trade.ID, trade.Price, err))
+	}
+
+	quantity, err := strconv.ParseFloat(trade.Quantity, 64)
+	if err != nil {
+		return NewError(fmt.Errorf("trade %s %d: quantity %q: %w", symbol, trade.ID, trade.Quantity, err))
+	}
+
+	tr := Trade{
+		Price:    trade.Price,
+		Quantity: trade.Quantity,
+		Side:     SideBuy,
+		ID:       trade.ID,
+		Time:     trade.Time,
+	}
+
+	// maker buyer means the seller took liquidity
+	if trade.BuyerMaker {
+		tr.Side = SideSell
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if tr.Time == 0 {
+		tr.Time = t.now().UnixMilli()
+	}
+
+	b, ok := t.symbols[symbol]
+	if !ok {
+		b = &book{trades: make([]Trade, t.size)}
+		t.symbols[symbol] = b
+	}
+
+	b.trades[b.next] = tr
+	b.next = (b.next + 1) % len(b.trades)
+	b.count = min(b.count+1, len(b.trades))
+
+	b.add(&tr, price, quantity, t.longest)
+
+	t.Publish(symbol, Event{Symbol: symbol, Trade: tr})
+
+	return nil
+}
+
+// Trades returns up to limit latest trades of symbol from the oldest to the newest,
+// zero limit returns every kept trade. False is returned for symbol without trades.
+func (t *Tape) Trades(symbol string, limit int) ([]Trade, bool) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	b, ok := t.symbols[symbol]
+	if !ok {
+		return nil, false
+	}
+
+	n := b.count
+	if limit > 0 && limit < n {
+		n = limit
+	}
+
+	trades := make([]Trade, 0, n)
+
+	for i := n; i > 0; i-- {
+		trades = append(trades, b.trades[(b.next-i+len(b.trades))%len(b.trades)])
+	}
+
+	return trades, true
+}
+
+// Symbols returns sorted symbols with trades.
+func (t *Tape) Symbols() []string {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	symbols := make([]string, 0, len(t.symbols))
+	for symbol := range t.symbols {
+		symbols = append(symbols, symbol)
+	}
+
+	sort.Strings(symbols)
+
+	return symbols
+}
+
+// add accounts trade in its second bucket and forgets buckets older than the longest window.
+func (b *book) add(tr *Trade, price, quantity float64, longest int64) {
+	second := tr.Time / int64(time.Second/time.Millisecond)
+
+	i := len(b.buckets)
+	for i > 0 && b.buckets[i-1].second > second {
+		i--
+	}
+
+	if i == 0 || b.buckets[i-1].second != second {
+		// trades arrive in order, late ones are inserted
+		b.buckets = append(b.buckets, bucket{})
+		copy(b.buckets[i+1:], b.buckets[i:])
+		b.buckets[i] = bucket{second: second}
+		i++
+	}
+
+	bk := &b.buckets[i-1]
+	bk.quoteVolume += price * quantity
+
+	if tr.Side == SideBuy {
+		bk.buyVolume += quantity
+		bk.buyTrades++
+	} else {
+		bk.sellVolume += quantity
+		bk.sellTrades++
+	}
+
+	last := b.buckets[len(b.buckets)-1].second
+
+	expired := 0
+	for expired < len(b.buckets) && b.buckets[expired].second <= last-longest {
+		expired++
+	}
+
+	b.buckets = b.buckets[expired:]
+}
+
+func ceilSecond(d time.Duration) time.Duration {
+	return (d + time.Second - 1).Truncate(time.Second)
+}
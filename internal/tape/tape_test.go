@@ -0,0 +1,149 @@
+package tape_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ole-larsen/binance-subscriber/internal/marketdata"
+	"github.com/ole-larsen/binance-subscriber/internal/tape"
+)
+
+var start = time.Unix(1700000000, 0)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func trade(id int64, price, quantity string, at time.Duration, buyerMaker bool) *marketdata.Trade {
+	return &marketdata.Trade{
+		ID:         id,
+		Price:      price,
+		Quantity:   quantity,
+		Time:       start.Add(at).UnixMilli(),
+		BuyerMaker: buyerMaker,
+	}
+}
+
+func TestTape_Trades(t *testing.T) {
+	tp := tape.New().SetSize(3)
+
+	_, ok := tp.Trades("BTCUSDT", 0)
+	assert.False(t, ok)
+
+	for i := int64(1); i <= 4; i++ {
+		require.NoError(t, tp.Add("BTCUSDT", trade(i, "100", "1", time.Duration(i)*time.Second, i%2 == 0)))
+	}
+
+	require.NoError(t, tp.Add("ETHUSDT", trade(9, "10", "1", 0, false)))
+
+	// the oldest trade is overwritten
+	trades, ok := tp.Trades("BTCUSDT", 0)
+	require.True(t, ok)
+	require.Len(t, trades, 3)
+	assert.Equal(t, []int64{2, 3, 4}, []int64{trades[0].ID, trades[1].ID, trades[2].ID})
+	assert.Equal(t, tape.Trade{Price: "100", Quantity: "1", Side: tape.SideSell, ID: 4, Time: start.Add(4 * time.Second).UnixMilli()},
+		trades[2])
+	assert.Equal(t, tape.SideBuy, trades[1].Side)
+
+	trades, _ = tp.Trades("BTCUSDT", 1)
+	require.Len(t, trades, 1)
+	assert.Equal(t, int64(4), trades[0].ID)
+
+	assert.Equal(t, []string{"BTCUSDT", "ETHUSDT"}, tp.Symbols())
+
+	require.ErrorContains(t, tp.Add("BTCUSDT", trade(5, "high", "1", 0, false)), `price "high"`)
+	require.ErrorContains(t, tp.Add("BTCUSDT", trade(5, "1", "", 0, false)), `quantity ""`)
+}
+
+func TestTape_Stats(t *testing.T) {
+	clk := &clock{now: start}
+	tp := tape.New().SetClock(clk.Now).SetWindows([]time.Duration{5 * time.Minute, time.Minute})
+
+	assert.Equal(t, []time.Duration{time.Minute, 5 * time.Minute}, tp.Windows())
+
+	_, ok := tp.Stats("BTCUSDT")
+	assert.False(t, ok)
+
+	require.NoError(t, tp.Add("BTCUSDT", trade(1, "100", "2", 0, false)))
+	require.NoError(t, tp.Add("BTCUSDT", trade(2, "110", "1", 30*time.Second, true)))
+	require.NoError(t, tp.Add("BTCUSDT", trade(3, "120", "1", 2*time.Minute, false)))
+	// late trade goes to its own second
+	require.NoError(t, tp.Add("BTCUSDT", trade(4, "90", "1", 90*time.Second, true)))
+
+	clk.now = start.Add(2*time.Minute + 500*time.Millisecond)
+
+	stats, ok := tp.Stats("BTCUSDT")
+	require.True(t, ok)
+	assert.Equal(t, "BTCUSDT", stats.Symbol)
+	require.Len(t, stats.Windows, 2)
+
+	// trades 3 and 4
+	minute := stats.Windows[0]
+	assert.Equal(t, "1m", minute.Window)
+	assert.Equal(t, 2, minute.Trades)
+	assert.Equal(t, 1, minute.BuyTrades)
+	assert.Equal(t, 1, minute.SellTrades)
+	assert.InDelta(t, 2, minute.Volume, 1e-9)
+	assert.InDelta(t, 105, minute.VWAP, 1e-9)
+
+	// every trade: (200 + 110 + 120 + 90) / 5
+	all := stats.Windows[1]
+	assert.Equal(t, "5m", all.Window)
+	assert.Equal(t, 4, all.Trades)
+	assert.InDelta(t, 3, all.BuyVolume, 1e-9)
+	assert.InDelta(t, 2, all.SellVolume, 1e-9)
+	assert.InDelta(t, 520, all.QuoteVolume, 1e-9)
+	assert.InDelta(t, 104, all.VWAP, 1e-9)
+
+	// trades leave windows as time passes
+	clk.now = start.Add(10 * time.Minute)
+
+	stats, _ = tp.Stats("BTCUSDT")
+	assert.Equal(t, tape.Stats{Window: "5m"}, stats.Windows[1])
+}
+
+func TestTape_Subscribe(t *testing.T) {
+	tp := tape.New()
+
+	all := tp.Subscribe(nil, 1)
+	btc := tp.Subscribe([]string{"BTCUSDT"}, 0)
+
+	require.NoError(t, tp.Add("ETHUSDT", trade(1, "10", "1", 0, false)))
+	require.NoError(t, tp.Add("BTCUSDT", trade(2, "100", "1", 0, true)))
+
+	assert.Equal(t, "ETHUSDT", (<-all.Events()).Symbol)
+	assert.Equal(t, uint64(1), tp.Dropped(all))
+
+	event := <-btc.Events()
+	assert.Equal(t, "BTCUSDT", event.Symbol)
+	assert.Equal(t, int64(2), event.Trade.ID)
+	assert.Equal(t, tape.SideSell, event.Trade.Side)
+
+	tp.Unsubscribe(btc)
+	tp.Unsubscribe(btc)
+
+	_, ok := <-btc.Events()
+	assert.False(t, ok)
+}
+
+func TestFormatWindow(t *testing.T) {
+	for w, want := range map[time.Duration]string{
+		30 * time.Second:               "30s",
+		time.Minute:                    "1m",
+		90 * time.Second:               "1m30s",
+		time.Hour:                      "1h",
+		time.Hour + 30*time.Minute:     "1h30m",
+		time.Hour + time.Second:        "1h0m1s",
+		24 * time.Hour:                 "24h",
+		15*time.Minute + 5*time.Second: "15m5s",
+	} {
+		assert.Equal(t, want, tape.FormatWindow(w))
+	}
+}
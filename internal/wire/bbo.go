@@ -0,0 +1,150 @@
+package wire
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// BBO encodes frame in fixed little-endian layout for the lowest overhead:
+//
+//	header, 12 bytes: version uint8 | type uint8 | count uint16 | seq uint64
+//	quote,  33 bytes: symbol [16]byte zero padded | bid float64 | ask float64 | flags uint8
+//
+// Type is 0 for legacy, 1 for snapshot and 2 for delta frames, see quotes.proto FrameType.
+// Flags are BBOFlagSynthetic and BBOFlagStale. Missing or malformed prices are encoded as NaN.
+// Quotes of symbols longer than BBOSymbolSize are skipped and counted.
+var BBO Codec = bboCodec{}
+
+const (
+	BBOVersion     = 2
+	BBOHeaderSize  = 12
+	BBOQuoteSize   = 33
+	BBOSymbolSize  = 16
+	bboMaxQuotes   = math.MaxUint16
+	bboPriceOffset = BBOSymbolSize
+	bboFlagsOffset = bboPriceOffset + 16
+)
+
+// Flags of BBO quotes.
+const (
+	BBOFlagSynthetic = 1 << iota
+	BBOFlagStale
+)
+
+type bboCodec struct{}
+
+func (bboCodec) Name() string {
+	return "bbo"
+}
+
+func (bboCodec) MessageType() int {
+	return websocket.BinaryMessage
+}
+
+func (bboCodec) Encode(f *Frame) ([]byte, error) {
+	if len(f.Quotes) > bboMaxQuotes {
+		return nil, NewError(fmt.Errorf("bbo: %d quotes exceed %d", len(f.Quotes), bboMaxQuotes))
+	}
+
+	b := make([]byte, BBOHeaderSize, BBOHeaderSize+BBOQuoteSize*len(f.Quotes))
+
+	b[0] = BBOVersion
+	b[1] = byte(frameTypes[f.Type])
+	binary.LittleEndian.PutUint64(b[4:], f.Seq)
+
+	for _, q := range f.Quotes {
+		if len(q.Symbol) > BBOSymbolSize {
+			metrics.SkippedQuotes.WithLabelValues(BBO.Name()).Inc()
+			continue
+		}
+
+		quote := make([]byte, BBOQuoteSize)
+
+		copy(quote, q.Symbol)
+		binary.LittleEndian.PutUint64(quote[bboPriceOffset:], math.Float64bits(price(q.Bid)))
+		binary.LittleEndian.PutUint64(quote[bboPriceOffset+8:], math.Float64bits(price(q.Ask)))
+		quote[bboFlagsOffset] = flags(q)
+
+		b = append(b, quote...)
+	}
+
+	count := (len(b) - BBOHeaderSize) / BBOQuoteSize
+	binary.LittleEndian.PutUint16(b[2:], uint16(count)) //nolint:gosec // explanation: checked above
+
+	return b, nil
+}
+
+// DecodeBBO decodes frame encoded by BBO codec, prices are formatted with the shortest representation.
+func DecodeBBO(b []byte) (*Frame, error) {
+	if len(b) < BBOHeaderSize {
+		return nil, NewError(errTruncated)
+	}
+
+	if b[0] != BBOVersion {
+		return nil, NewError(fmt.Errorf("bbo: unsupported version %d", b[0]))
+	}
+
+	count := int(binary.LittleEndian.Uint16(b[2:]))
+	if len(b) != BBOHeaderSize+count*BBOQuoteSize {
+		return nil, NewError(errTruncated)
+	}
+
+	f := &Frame{
+		Type:   frameTypeNames[uint64(b[1])],
+		Seq:    binary.LittleEndian.Uint64(b[4:]),
+		Quotes: make([]*storage.Data, 0, count),
+	}
+
+	for i := 0; i < count; i++ {
+		quote := b[BBOHeaderSize+i*BBOQuoteSize:]
+
+		f.Quotes = append(f.Quotes, &storage.Data{
+			Symbol:    strings.TrimRight(string(quote[:BBOSymbolSize]), "\x00"),
+			Bid:       formatPrice(math.Float64frombits(binary.LittleEndian.Uint64(quote[bboPriceOffset:]))),
+			Ask:       formatPrice(math.Float64frombits(binary.LittleEndian.Uint64(quote[bboPriceOffset+8:]))),
+			Synthetic: quote[bboFlagsOffset]&BBOFlagSynthetic != 0,
+			Stale:     quote[bboFlagsOffset]&BBOFlagStale != 0,
+		})
+	}
+
+	return f, nil
+}
+
+func flags(q *storage.Data) byte {
+	var f byte
+
+	if q.Synthetic {
+		f |= BBOFlagSynthetic
+	}
+
+	if q.Stale {
+		f |= BBOFlagStale
+	}
+
+	return f
+}
+
+func price(s string) float64 {
+	p, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return math.NaN()
+	}
+
+	return p
+}
+
+func formatPrice(p float64) string {
+	if math.IsNaN(p) {
+		return ""
+	}
+
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
@@ -0,0 +1,87 @@
+// Package wire encodes quotes sent to websocket clients. Encoding is negotiated per
+// connection by subprotocol or query parameter, JSON is the default.
+package wire
+
+import (
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// Frame types of the delta protocol, legacy frames have empty type.
+const (
+	FrameSnapshot = "snapshot"
+	FrameDelta    = "delta"
+)
+
+// Frame is a batch of quotes sent to a client. Legacy frames (empty Type) carry
+// every quote and are encoded as plain array by JSON and MessagePack codecs.
+type Frame struct {
+	Type   string
+	Quotes []*storage.Data
+	Seq    uint64
+}
+
+// frameTypes are protobuf QuoteUpdate.Type and BBO type values.
+var (
+	frameTypes     = map[string]uint64{FrameSnapshot: 1, FrameDelta: 2}
+	frameTypeNames = map[uint64]string{1: FrameSnapshot, 2: FrameDelta}
+)
+
+// envelope is JSON and MessagePack layout of snapshot and delta frames.
+type envelope struct {
+	Type   string          `json:"type"`
+	Quotes []*storage.Data `json:"quotes"`
+	Seq    uint64          `json:"seq"`
+}
+
+// value returns frame representation for JSON and MessagePack codecs.
+func value(f *Frame) any {
+	if f.Type == "" {
+		return quotes(f)
+	}
+
+	return &envelope{Type: f.Type, Seq: f.Seq, Quotes: quotes(f)}
+}
+
+// Codec encodes frames of one wire format.
+type Codec interface {
+	// Name is the subprotocol and query parameter value selecting codec.
+	Name() string
+	// MessageType is websocket.TextMessage or websocket.BinaryMessage.
+	MessageType() int
+	Encode(f *Frame) ([]byte, error)
+}
+
+// JSON is the default codec, frame is encoded as array of quotes.
+var JSON Codec = jsonCodec{}
+
+// codecs in order of preference.
+var codecs = []Codec{JSON, MsgPack, Protobuf, BBO}
+
+// Lookup returns codec by name.
+func Lookup(name string) (Codec, bool) {
+	for _, c := range codecs {
+		if c.Name() == name {
+			return c, true
+		}
+	}
+
+	return nil, false
+}
+
+// Names returns names of supported codecs.
+func Names() []string {
+	names := make([]string, 0, len(codecs))
+	for _, c := range codecs {
+		names = append(names, c.Name())
+	}
+
+	return names
+}
+
+func quotes(f *Frame) []*storage.Data {
+	if f.Quotes == nil {
+		return []*storage.Data{}
+	}
+
+	return f.Quotes
+}
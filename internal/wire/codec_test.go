@@ -0,0 +1,273 @@
+package wire_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/ole-larsen/binance-subscriber/internal/metrics"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+	"github.com/ole-larsen/binance-subscriber/internal/wire"
+)
+
+var frame = &wire.Frame{Quotes: []*storage.Data{
+	{Symbol: "BTCUSDT", Bid: "97000.01", Ask: "97000.02"},
+	{Symbol: "ETHUSDT", Bid: "3500.5", Ask: "3500.51"},
+}}
+
+func TestLookup(t *testing.T) {
+	assert.Equal(t, []string{"json", "msgpack", "protobuf", "bbo"}, wire.Names())
+
+	for _, name := range wire.Names() {
+		codec, ok := wire.Lookup(name)
+		require.True(t, ok)
+		assert.Equal(t, name, codec.Name())
+	}
+
+	_, ok := wire.Lookup("xml")
+	assert.False(t, ok)
+}
+
+func TestCodecs(t *testing.T) {
+	tests := []struct {
+		codec       wire.Codec
+		decode      func(t *testing.T, b []byte) []*storage.Data
+		name        string
+		messageType int
+	}{
+		{
+			name:        "json",
+			codec:       wire.JSON,
+			messageType: websocket.TextMessage,
+			decode: func(t *testing.T, b []byte) []*storage.Data {
+				var quotes []*storage.Data
+				require.NoError(t, json.Unmarshal(b, &quotes))
+
+				return quotes
+			},
+		},
+		{
+			name:        "msgpack",
+			codec:       wire.MsgPack,
+			messageType: websocket.BinaryMessage,
+			decode: func(t *testing.T, b []byte) []*storage.Data {
+				var quotes []*storage.Data
+
+				dec := msgpack.NewDecoder(bytes.NewReader(b))
+				dec.SetCustomStructTag("json")
+				require.NoError(t, dec.Decode(&quotes))
+
+				return quotes
+			},
+		},
+		{
+			name:        "protobuf",
+			codec:       wire.Protobuf,
+			messageType: websocket.BinaryMessage,
+			decode: func(t *testing.T, b []byte) []*storage.Data {
+				f, err := wire.DecodeProtobuf(b)
+				require.NoError(t, err)
+
+				return f.Quotes
+			},
+		},
+		{
+			name:        "bbo",
+			codec:       wire.BBO,
+			messageType: websocket.BinaryMessage,
+			decode: func(t *testing.T, b []byte) []*storage.Data {
+				f, err := wire.DecodeBBO(b)
+				require.NoError(t, err)
+
+				return f.Quotes
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.messageType, tt.codec.MessageType())
+
+			b, err := tt.codec.Encode(frame)
+			require.NoError(t, err)
+			assert.Equal(t, frame.Quotes, tt.decode(t, b))
+
+			if tt.codec == wire.BBO {
+				assert.Len(t, b, wire.BBOHeaderSize+2*wire.BBOQuoteSize)
+			}
+
+			b, err = tt.codec.Encode(&wire.Frame{})
+			require.NoError(t, err)
+			assert.Empty(t, tt.decode(t, b))
+		})
+	}
+}
+
+func TestCodecs_Synthetic(t *testing.T) {
+	synthetic := &wire.Frame{Quotes: []*storage.Data{
+		{Symbol: "ETHBTC_SYN", Bid: "0.03", Ask: "0.04", Synthetic: true},
+		{Symbol: "BTCUSDT", Bid: "1", Ask: "2", Stale: true},
+	}}
+
+	b, err := wire.JSON.Encode(synthetic)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"symbol":"ETHBTC_SYN","bid":"0.03","ask":"0.04","synthetic":true},`+
+		`{"symbol":"BTCUSDT","bid":"1","ask":"2","stale":true}]`, string(b))
+
+	b, err = wire.Protobuf.Encode(synthetic)
+	require.NoError(t, err)
+
+	f, err := wire.DecodeProtobuf(b)
+	require.NoError(t, err)
+	assert.Equal(t, synthetic.Quotes, f.Quotes)
+
+	b, err = wire.BBO.Encode(synthetic)
+	require.NoError(t, err)
+
+	f, err = wire.DecodeBBO(b)
+	require.NoError(t, err)
+	assert.Equal(t, synthetic.Quotes, f.Quotes)
+}
+
+func TestJSON_EmptyFrame(t *testing.T) {
+	b, err := wire.JSON.Encode(&wire.Frame{})
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(b))
+}
+
+func TestBBO_Errors(t *testing.T) {
+	// quotes of symbols longer than 16 bytes are skipped, the frame is still sent
+	skipped := testutil.ToFloat64(metrics.SkippedQuotes.WithLabelValues("bbo"))
+
+	b, err := wire.BBO.Encode(&wire.Frame{Quotes: []*storage.Data{
+		{Symbol: "AVERYLONGSYMBOLNAME", Bid: "1", Ask: "2"},
+		{Symbol: "BTCUSDT", Bid: "n/a", Ask: "1"},
+	}})
+	require.NoError(t, err)
+	assert.InDelta(t, skipped+1, testutil.ToFloat64(metrics.SkippedQuotes.WithLabelValues("bbo")), 0)
+
+	f, err := wire.DecodeBBO(b)
+	require.NoError(t, err)
+	require.Len(t, f.Quotes, 1)
+	assert.Equal(t, &storage.Data{Symbol: "BTCUSDT", Ask: "1"}, f.Quotes[0])
+
+	_, err = wire.DecodeBBO(b[:len(b)-1])
+	require.ErrorContains(t, err, "truncated frame")
+
+	b[0] = 1
+	_, err = wire.DecodeBBO(b)
+	require.ErrorContains(t, err, "unsupported version 1")
+}
+
+func TestDecodeProtobuf_Compatible(t *testing.T) {
+	// delta frame as sent by the previous encoder, fields out of order
+	f, err := wire.DecodeProtobuf([]byte{
+		0x10, 0x02, 0x18, 0x2a, 0x0a, 0x11, 0x0a, 0x07, 'B', 'T', 'C', 'U', 'S', 'D', 'T',
+		0x12, 0x01, '1', 0x1a, 0x01, '2', 0x28, 0x01,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &wire.Frame{
+		Type:   wire.FrameDelta,
+		Seq:    42,
+		Quotes: []*storage.Data{{Symbol: "BTCUSDT", Bid: "1", Ask: "2", Stale: true}},
+	}, f)
+}
+
+func TestDecodeProtobuf_Errors(t *testing.T) {
+	_, err := wire.DecodeProtobuf([]byte{0x0a, 0x05, 0x01})
+	require.Error(t, err)
+}
+
+func TestCodecs_DeltaFrames(t *testing.T) {
+	delta := &wire.Frame{Type: wire.FrameDelta, Seq: 42, Quotes: frame.Quotes[:1]}
+
+	b, err := wire.JSON.Encode(delta)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"delta","seq":42,"quotes":[{"symbol":"BTCUSDT","bid":"97000.01","ask":"97000.02"}]}`, string(b))
+
+	b, err = wire.MsgPack.Encode(delta)
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, msgpack.Unmarshal(b, &decoded))
+	assert.Equal(t, "delta", decoded["type"])
+	assert.EqualValues(t, 42, decoded["seq"])
+
+	b, err = wire.Protobuf.Encode(delta)
+	require.NoError(t, err)
+
+	f, err := wire.DecodeProtobuf(b)
+	require.NoError(t, err)
+	assert.Equal(t, delta, f)
+
+	b, err = wire.BBO.Encode(delta)
+	require.NoError(t, err)
+
+	f, err = wire.DecodeBBO(b)
+	require.NoError(t, err)
+	assert.Equal(t, delta, f)
+
+	snapshot := &wire.Frame{Type: wire.FrameSnapshot, Seq: 7, Quotes: []*storage.Data{}}
+
+	b, err = wire.Protobuf.Encode(snapshot)
+	require.NoError(t, err)
+
+	f, err = wire.DecodeProtobuf(b)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot, f)
+}
+
+func TestParseCommand(t *testing.T) {
+	cmd, err := wire.ParseCommand([]byte(`{"op":"resync"}`))
+	require.NoError(t, err)
+	assert.Equal(t, wire.OpResync, cmd.Op)
+
+	_, err = wire.ParseCommand([]byte(`{"op":"dance"}`))
+	require.Error(t, err)
+	assert.JSONEq(t, `{"type":"error","error":"unknown op \"dance\""}`, string(wire.EncodeError(err)))
+
+	_, err = wire.ParseCommand([]byte(`ping`))
+	require.ErrorContains(t, err, "invalid command")
+}
+
+func TestParseConflation(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		err   string
+		want  time.Duration
+	}{
+		{name: "empty disables", value: ""},
+		{name: "zero disables", value: "0"},
+		{name: "zero duration disables", value: "0s"},
+		{name: "milliseconds", value: "250ms", want: 250 * time.Millisecond},
+		{name: "too short", value: "1ms", err: "between 10ms and 1m0s"},
+		{name: "too long", value: "2m", err: "between 10ms and 1m0s"},
+		{name: "invalid", value: "fast", err: "invalid conflation interval"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := wire.ParseConflation(tt.value)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	cmd, err := wire.ParseCommand([]byte(`{"op":"conflate","interval":"500ms"}`))
+	require.NoError(t, err)
+	assert.Equal(t, 500*time.Millisecond, cmd.Conflate)
+
+	_, err = wire.ParseCommand([]byte(`{"op":"conflate","interval":"-1s"}`))
+	require.Error(t, err)
+}
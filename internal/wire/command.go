@@ -0,0 +1,85 @@
+package wire
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Commands accepted on the delta protocol.
+const (
+	// OpResync requests fresh snapshot after a sequence gap.
+	OpResync = "resync"
+	// OpConflate sets conflation interval, zero interval disables conflation.
+	OpConflate = "conflate"
+)
+
+// Conflation interval bounds.
+const (
+	MinConflation = 10 * time.Millisecond
+	MaxConflation = time.Minute
+)
+
+// Command is a client request sent as JSON text message, e.g. {"op":"resync"}
+// or {"op":"conflate","interval":"250ms"}.
+type Command struct {
+	Op       string        `json:"op"`
+	Interval string        `json:"interval,omitempty"`
+	Conflate time.Duration `json:"-"`
+}
+
+// ParseCommand decodes and validates client command.
+func ParseCommand(b []byte) (*Command, error) {
+	var cmd Command
+
+	if err := json.Unmarshal(b, &cmd); err != nil {
+		return nil, NewError(fmt.Errorf("invalid command: %w", err))
+	}
+
+	switch cmd.Op {
+	case OpResync:
+	case OpConflate:
+		interval, err := ParseConflation(cmd.Interval)
+		if err != nil {
+			return nil, err
+		}
+
+		cmd.Conflate = interval
+	default:
+		return nil, NewError(fmt.Errorf("unknown op %q", cmd.Op))
+	}
+
+	return &cmd, nil
+}
+
+// ParseConflation parses conflation interval such as "250ms", empty string and "0" disable conflation.
+func ParseConflation(s string) (time.Duration, error) {
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+
+	interval, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, NewError(fmt.Errorf("invalid conflation interval: %w", err))
+	}
+
+	if interval != 0 && (interval < MinConflation || interval > MaxConflation) {
+		return 0, NewError(fmt.Errorf("conflation interval must be between %s and %s", MinConflation, MaxConflation))
+	}
+
+	return interval, nil
+}
+
+// EncodeError returns JSON text frame reporting rejected command, it is sent whatever codec is negotiated.
+func EncodeError(err error) []byte {
+	var wireErr *Error
+	if errors.As(err, &wireErr) {
+		err = wireErr.err
+	}
+
+	//nolint:errchkjson // explanation: map of strings is always encodable
+	b, _ := json.Marshal(map[string]string{"type": "error", "error": err.Error()})
+
+	return b
+}
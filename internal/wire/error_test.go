@@ -0,0 +1,22 @@
+package wire_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ole-larsen/binance-subscriber/internal/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewError(t *testing.T) {
+	require.NoError(t, wire.NewError(nil))
+
+	stdErr := errors.New("something went wrong")
+	err := wire.NewError(stdErr)
+
+	var wireErr *wire.Error
+	require.True(t, errors.As(err, &wireErr))
+	assert.Equal(t, "[wire]: something went wrong", wireErr.Error())
+	assert.True(t, errors.Is(err, stdErr))
+}
@@ -0,0 +1,31 @@
+package wire
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errTruncated = errors.New("truncated frame")
+
+// Error - custom wire error.
+type Error struct {
+	err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("[wire]: %v", e.err)
+}
+
+func NewError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{
+		err: err,
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
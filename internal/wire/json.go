@@ -0,0 +1,26 @@
+package wire
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func (jsonCodec) MessageType() int {
+	return websocket.TextMessage
+}
+
+func (jsonCodec) Encode(f *Frame) ([]byte, error) {
+	data, err := json.Marshal(value(f))
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,34 @@
+package wire
+
+import (
+	"bytes"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgPack encodes frame as MessagePack with the same layout and keys as JSON.
+var MsgPack Codec = msgpackCodec{}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string {
+	return "msgpack"
+}
+
+func (msgpackCodec) MessageType() int {
+	return websocket.BinaryMessage
+}
+
+func (msgpackCodec) Encode(f *Frame) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+
+	if err := enc.Encode(value(f)); err != nil {
+		return nil, NewError(err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,63 @@
+package wire
+
+import (
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ole-larsen/binance-subscriber/internal/grpcserver/quotesv1"
+	"github.com/ole-larsen/binance-subscriber/internal/storage"
+)
+
+// Protobuf encodes frame as binance.subscriber.quotes.v1.Frame message, see
+// grpcserver/quotesv1/quotes.proto.
+var Protobuf Codec = protobufCodec{}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string {
+	return "protobuf"
+}
+
+func (protobufCodec) MessageType() int {
+	return websocket.BinaryMessage
+}
+
+func (protobufCodec) Encode(f *Frame) ([]byte, error) {
+	msg := &quotesv1.Frame{
+		Quotes: make([]*quotesv1.Quote, 0, len(f.Quotes)),
+		Type:   quotesv1.QuoteUpdate_Type(frameTypes[f.Type]), //nolint:gosec // explanation: frame types are 0, 1 and 2
+		Seq:    f.Seq,
+	}
+
+	for _, q := range f.Quotes {
+		msg.Quotes = append(msg.Quotes, quotesv1.NewQuote(q))
+	}
+
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	return b, nil
+}
+
+// DecodeProtobuf decodes frame encoded by Protobuf codec, unknown fields are skipped.
+func DecodeProtobuf(b []byte) (*Frame, error) {
+	var msg quotesv1.Frame
+
+	if err := proto.Unmarshal(b, &msg); err != nil {
+		return nil, NewError(err)
+	}
+
+	f := &Frame{
+		Type:   frameTypeNames[uint64(msg.GetType())], //nolint:gosec // explanation: unknown types are not mapped
+		Seq:    msg.GetSeq(),
+		Quotes: make([]*storage.Data, 0, len(msg.GetQuotes())),
+	}
+
+	for _, q := range msg.GetQuotes() {
+		f.Quotes = append(f.Quotes, q.Data())
+	}
+
+	return f, nil
+}